@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/logic/appservice"
+)
+
+type AfterSaleHandler struct {
+	*Handler
+	afterSaleAppSvc *appservice.AfterSaleAppSvc
+}
+
+func NewAfterSaleHandler(handler *Handler, afterSaleAppSvc *appservice.AfterSaleAppSvc) *AfterSaleHandler {
+	return &AfterSaleHandler{Handler: handler, afterSaleAppSvc: afterSaleAppSvc}
+}
+
+func (ah *AfterSaleHandler) ApplyAfterSale(c *gin.Context) (any, error) {
+	req := new(request.AfterSaleApplyReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := ah.afterSaleAppSvc.ApplyAfterSale(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (ah *AfterSaleHandler) CancelAfterSale(c *gin.Context) (any, error) {
+	req := new(request.AfterSaleCancelReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	return nil, ah.afterSaleAppSvc.CancelAfterSale(c, c.GetInt64("user_id"), req.TicketNo)
+}
+
+func (ah *AfterSaleHandler) GetUserAfterSale(c *gin.Context) (any, error) {
+	ticketNo := c.Query("ticket_no")
+	if ticketNo == "" {
+		return nil, errcode.ErrParams
+	}
+
+	resp, err := ah.afterSaleAppSvc.GetUserAfterSale(c, c.GetInt64("user_id"), ticketNo)
+	return resp, err
+}
+
+func (ah *AfterSaleHandler) ListUserAfterSales(c *gin.Context) (any, error) {
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ah.afterSaleAppSvc.ListUserAfterSales(c, c.GetInt64("user_id"), pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (ah *AfterSaleHandler) AdminListAfterSales(c *gin.Context) (any, error) {
+	var state *int8
+	if s := c.Query("state"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 8)
+		if err != nil {
+			return nil, errcode.ErrParams.WithCause(err)
+		}
+		st := int8(v)
+		state = &st
+	}
+
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ah.afterSaleAppSvc.ListAfterSales(c, state, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (ah *AfterSaleHandler) AdminGetAfterSale(c *gin.Context) (any, error) {
+	ticketNo := c.Query("ticket_no")
+	if ticketNo == "" {
+		return nil, errcode.ErrParams
+	}
+
+	resp, err := ah.afterSaleAppSvc.GetAfterSaleDetail(c, ticketNo)
+	return resp, err
+}
+
+func (ah *AfterSaleHandler) AdminApproveAfterSale(c *gin.Context) (any, error) {
+	req := new(request.AfterSaleAuditReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := ah.afterSaleAppSvc.ApproveAfterSale(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (ah *AfterSaleHandler) AdminRejectAfterSale(c *gin.Context) (any, error) {
+	req := new(request.AfterSaleAuditReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := ah.afterSaleAppSvc.RejectAfterSale(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
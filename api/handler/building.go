@@ -9,12 +9,26 @@ import (
 	"github.com/kackerx/go-mall/common/app"
 	"github.com/kackerx/go-mall/common/errcode"
 	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/dal/cache"
 	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/tx"
 	"github.com/kackerx/go-mall/library"
 	"github.com/kackerx/go-mall/logic/appservice"
 	"github.com/kackerx/go-mall/logic/domainservice"
 )
 
+// BuildingHandler 调试接口里需要依赖数据库、缓存的部分
+type BuildingHandler struct {
+	*Handler
+	db         dao.DBProvider
+	cache      *cache.Cache
+	userAppSvc *appservice.UserAppSvc
+}
+
+func NewBuildingHandler(handler *Handler, db dao.DBProvider, cache *cache.Cache, userAppSvc *appservice.UserAppSvc) *BuildingHandler {
+	return &BuildingHandler{Handler: handler, db: db, cache: cache, userAppSvc: userAppSvc}
+}
+
 func TestErr(c *gin.Context) {
 	// 使用Wrap包装错误, 生成项目错误
 	err := errors.New("dao error")
@@ -55,56 +69,29 @@ func TestRespSuccess(c *gin.Context) {
 		Success(data)
 }
 
-func TestCreateDemoOrder(c *gin.Context) {
+func (bh *BuildingHandler) TestCreateDemoOrder(c *gin.Context) (any, error) {
 	req := new(request.DemoOrderCreateReq)
 	if err := c.ShouldBind(req); err != nil {
-		app.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
-		return
+		return nil, errcode.ErrParams.WithCause(err)
 	}
 
 	req.UserID = 111
-	demoDao := dao.NewDemoDao(c)
-	domainSvc := domainservice.NewDemoDomainSvc(c, demoDao)
-	svc := appservice.NewDemoAppSvc(c, domainSvc)
+	demoDao := dao.NewDemoDao(c, bh.db)
+	domainSvc := domainservice.NewDemoDomainSvc(c, demoDao, tx.NewManager(bh.db))
+	svc := appservice.NewDemoAppSvc(c, domainSvc, bh.cache)
 
 	order, err := svc.CreateDemoOrder(req)
-	if err != nil {
-		app.NewResponse(c).Error(errcode.ErrServer.WithCause(err))
-		return
-	}
-
-	app.NewResponse(c).Success(order)
+	return order, err
 }
 
-func TestWhoisLibReq(c *gin.Context) {
+func TestWhoisLibReq(c *gin.Context) (any, error) {
 	detail, err := library.NewWhoisLib().GetHostIPDetail(c)
-	if err != nil {
-		app.NewResponse(c).Error(errcode.ErrServer.WithCause(err))
-		return
-	}
-
-	app.NewResponse(c).Success(detail)
+	return detail, err
 }
 
-func TestMakeToken(c *gin.Context) {
-	userDomainSvc := domainservice.NewUserDomainSvc()
-	userAppSvc := appservice.NewUserAppSvc(userDomainSvc)
-
-	resp, err := userAppSvc.GenToken(c)
-	if err != nil {
-		if errors.Is(err, errcode.ErrUserInvalid) {
-			logger.New(c).Error("invalid user", "err", err)
-			app.NewResponse(c).Error(errcode.ErrUserInvalid)
-		} else {
-			var appErr *errcode.AppError
-			errors.As(err, &appErr)
-			app.NewResponse(c).Error(appErr)
-		}
-
-		return
-	}
-
-	app.NewResponse(c).Success(resp)
+func (bh *BuildingHandler) TestMakeToken(c *gin.Context) (any, error) {
+	resp, err := bh.userAppSvc.GenToken(c)
+	return resp, err
 }
 
 func TestGetToken(c *gin.Context) {
@@ -114,25 +101,12 @@ func TestGetToken(c *gin.Context) {
 	})
 }
 
-func TestRefreshToken(c *gin.Context) {
+func (bh *BuildingHandler) TestRefreshToken(c *gin.Context) (any, error) {
 	refreshToken := c.Query("refresh_token")
 	if refreshToken == "" {
-		app.NewResponse(c).Error(errcode.ErrParams)
-		return
-	}
-
-	svc := domainservice.NewUserDomainSvc()
-	appSvc := appservice.NewUserAppSvc(svc)
-	token, err := appSvc.RefreshToken(c, refreshToken)
-	if err != nil {
-		if errors.Is(err, errcode.ErrTooManyRequests) {
-			app.NewResponse(c).Error(errcode.ErrTooManyRequests)
-		} else {
-			appErr := err.(*errcode.AppError)
-			app.NewResponse(c).Error(appErr)
-		}
-		return
+		return nil, errcode.ErrParams
 	}
 
-	app.NewResponse(c).Success(token)
+	token, err := bh.userAppSvc.RefreshToken(c, refreshToken)
+	return token, err
 }
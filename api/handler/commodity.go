@@ -3,8 +3,6 @@ package handler
 import (
 	"github.com/gin-gonic/gin"
 
-	"github.com/kackerx/go-mall/common/app"
-	"github.com/kackerx/go-mall/common/errcode"
 	"github.com/kackerx/go-mall/logic/appservice"
 )
 
@@ -17,10 +15,10 @@ func NewCommodityHandler(handler *Handler, app *appservice.CommodityApp) *Commod
 	return &CommodityHandler{Handler: handler, app: app}
 }
 
-func (a *CommodityHandler) InitCategory(c *gin.Context) {
-	if err := a.app.InitCategoryData(); err != nil {
-		app.NewResponse(c).Error(errcode.Wrap("InitCategoryData", err))
-	} else {
-		app.NewResponse(c).Success("ok")
+func (a *CommodityHandler) InitCategory(c *gin.Context) (any, error) {
+	if err := a.app.InitCategoryData(c); err != nil {
+		return nil, err
 	}
+
+	return "ok", nil
 }
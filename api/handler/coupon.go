@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/logic/appservice"
+)
+
+type CouponHandler struct {
+	*Handler
+	couponAppSvc *appservice.CouponAppSvc
+}
+
+func NewCouponHandler(handler *Handler, couponAppSvc *appservice.CouponAppSvc) *CouponHandler {
+	return &CouponHandler{Handler: handler, couponAppSvc: couponAppSvc}
+}
+
+func (ch *CouponHandler) ListTemplates(c *gin.Context) (any, error) {
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ch.couponAppSvc.ListClaimableTemplates(c, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (ch *CouponHandler) ClaimCoupon(c *gin.Context) (any, error) {
+	req := new(request.CouponClaimReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := ch.couponAppSvc.ClaimCoupon(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (ch *CouponHandler) ListUserCoupons(c *gin.Context) (any, error) {
+	var state *int8
+	if s := c.Query("state"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 8)
+		if err != nil {
+			return nil, errcode.ErrParams.WithCause(err)
+		}
+		st := int8(v)
+		state = &st
+	}
+
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ch.couponAppSvc.ListUserCoupons(c, c.GetInt64("user_id"), state, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (ch *CouponHandler) AdminCreateTemplate(c *gin.Context) (any, error) {
+	req := new(request.CouponTemplateCreateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := ch.couponAppSvc.CreateTemplate(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/logic/appservice"
+)
+
+type FavoriteHandler struct {
+	*Handler
+	favoriteAppSvc *appservice.FavoriteAppSvc
+}
+
+func NewFavoriteHandler(handler *Handler, favoriteAppSvc *appservice.FavoriteAppSvc) *FavoriteHandler {
+	return &FavoriteHandler{Handler: handler, favoriteAppSvc: favoriteAppSvc}
+}
+
+func (fh *FavoriteHandler) AddFavorite(c *gin.Context) (any, error) {
+	req := new(request.FavoriteReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	return nil, fh.favoriteAppSvc.AddFavorite(c, c.GetInt64("user_id"), req)
+}
+
+func (fh *FavoriteHandler) RemoveFavorite(c *gin.Context) (any, error) {
+	req := new(request.FavoriteReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	return nil, fh.favoriteAppSvc.RemoveFavorite(c, c.GetInt64("user_id"), req)
+}
+
+func (fh *FavoriteHandler) ListFavorites(c *gin.Context) (any, error) {
+	targetType := int8(enum.FavoriteTargetCommodity)
+	if s := c.Query("target_type"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 8)
+		if err != nil || (v != enum.FavoriteTargetCommodity && v != enum.FavoriteTargetStore) {
+			return nil, errcode.ErrParams
+		}
+		targetType = int8(v)
+	}
+
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fh.favoriteAppSvc.ListFavorites(c, c.GetInt64("user_id"), targetType, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (fh *FavoriteHandler) GetFavoriteCount(c *gin.Context) (any, error) {
+	commodityID, err := strconv.ParseInt(c.Query("commodity_id"), 10, 64)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.favoriteAppSvc.GetFavoriteCount(c, commodityID)
+	return resp, err
+}
+
+func (fh *FavoriteHandler) RecordBrowse(c *gin.Context) (any, error) {
+	req := new(request.BrowseRecordReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	return nil, fh.favoriteAppSvc.RecordBrowse(c, c.GetInt64("user_id"), req)
+}
+
+func (fh *FavoriteHandler) ListBrowseHistory(c *gin.Context) (any, error) {
+	pagination := app.NewPagination(c)
+	resp, err := fh.favoriteAppSvc.ListBrowseHistory(c, c.GetInt64("user_id"), pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (fh *FavoriteHandler) ClearBrowseHistory(c *gin.Context) (any, error) {
+	return nil, fh.favoriteAppSvc.ClearBrowseHistory(c, c.GetInt64("user_id"))
+}
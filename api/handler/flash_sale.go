@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/i18n"
+	"github.com/kackerx/go-mall/logic/appservice"
+)
+
+type FlashSaleHandler struct {
+	*Handler
+	flashSaleAppSvc *appservice.FlashSaleAppSvc
+}
+
+func NewFlashSaleHandler(handler *Handler, flashSaleAppSvc *appservice.FlashSaleAppSvc) *FlashSaleHandler {
+	return &FlashSaleHandler{Handler: handler, flashSaleAppSvc: flashSaleAppSvc}
+}
+
+func (fh *FlashSaleHandler) GetFlashSale(c *gin.Context) (any, error) {
+	flashSaleID, err := strconv.ParseInt(c.Query("flash_sale_id"), 10, 64)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.flashSaleAppSvc.GetFlashSale(c, flashSaleID)
+	return resp, err
+}
+
+func (fh *FlashSaleHandler) IssueToken(c *gin.Context) (any, error) {
+	flashSaleID, err := strconv.ParseInt(c.Query("flash_sale_id"), 10, 64)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.flashSaleAppSvc.IssueToken(c, c.GetInt64("user_id"), flashSaleID)
+	return resp, err
+}
+
+func (fh *FlashSaleHandler) Grab(c *gin.Context) (any, error) {
+	req := new(request.FlashSaleGrabReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	return nil, fh.flashSaleAppSvc.Grab(c, c.GetInt64("user_id"), req)
+}
+
+func (fh *FlashSaleHandler) GetResult(c *gin.Context) (any, error) {
+	flashSaleID, err := strconv.ParseInt(c.Query("flash_sale_id"), 10, 64)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.flashSaleAppSvc.GetResult(c, c.GetInt64("user_id"), flashSaleID)
+	if err != nil {
+		return nil, err
+	}
+	if appErr, ok := errcode.Lookup(resp.FailCode); ok {
+		resp.Reason = appErr.Localize(i18n.Match(c.GetHeader("Accept-Language")))
+	}
+
+	return resp, nil
+}
+
+func (fh *FlashSaleHandler) AdminCreateFlashSale(c *gin.Context) (any, error) {
+	req := new(request.FlashSaleCreateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.flashSaleAppSvc.CreateFlashSale(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (fh *FlashSaleHandler) AdminSettle(c *gin.Context) (any, error) {
+	req := new(request.FlashSaleSettleReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.flashSaleAppSvc.Settle(c, req)
+	return resp, err
+}
+
+func (fh *FlashSaleHandler) AdminWarmUp(c *gin.Context) (any, error) {
+	req := new(request.FlashSaleWarmReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := fh.flashSaleAppSvc.WarmUp(c, req)
+	return resp, err
+}
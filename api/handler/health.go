@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/health"
+)
+
+type HealthHandler struct {
+	*Handler
+	registry *health.Registry
+	ready    func() bool
+}
+
+// NewHealthHandler ready返回false时说明服务在排空, readyz直接返回不可用
+func NewHealthHandler(handler *Handler, registry *health.Registry, ready func() bool) *HealthHandler {
+	return &HealthHandler{Handler: handler, registry: registry, ready: ready}
+}
+
+// Healthz 存活检查, 进程能响应就返回200, 不检查外部依赖
+func (hh *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Readyz 就绪检查, 依赖全部可用时返回200, 否则返回503和各依赖的状态
+func (hh *HealthHandler) Readyz(c *gin.Context) {
+	if !hh.ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusDown, "draining": true})
+		return
+	}
+
+	res := hh.registry.Check(c.Request.Context())
+	if res.Status != health.StatusUp {
+		c.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/logic/appservice"
+)
+
+type OrderHandler struct {
+	*Handler
+	orderAppSvc *appservice.OrderAppSvc
+}
+
+func NewOrderHandler(handler *Handler, orderAppSvc *appservice.OrderAppSvc) *OrderHandler {
+	return &OrderHandler{Handler: handler, orderAppSvc: orderAppSvc}
+}
+
+func (oh *OrderHandler) Checkout(c *gin.Context) (any, error) {
+	req := new(request.OrderCheckoutReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := oh.orderAppSvc.Checkout(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (oh *OrderHandler) CreateOrder(c *gin.Context) (any, error) {
+	req := new(request.OrderCreateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := oh.orderAppSvc.CreateOrder(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (oh *OrderHandler) GetOrderDetail(c *gin.Context) (any, error) {
+	orderNo := c.Query("order_no")
+	if orderNo == "" {
+		return nil, errcode.ErrParams
+	}
+
+	resp, err := oh.orderAppSvc.GetOrderDetail(c, c.GetInt64("user_id"), orderNo)
+	return resp, err
+}
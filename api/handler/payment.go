@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/logic/appservice"
+)
+
+type PaymentHandler struct {
+	*Handler
+	paymentAppSvc *appservice.PaymentAppSvc
+}
+
+func NewPaymentHandler(handler *Handler, paymentAppSvc *appservice.PaymentAppSvc) *PaymentHandler {
+	return &PaymentHandler{Handler: handler, paymentAppSvc: paymentAppSvc}
+}
+
+func (ph *PaymentHandler) CreatePayment(c *gin.Context) (any, error) {
+	req := new(request.PaymentCreateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := ph.paymentAppSvc.CreatePayment(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (ph *PaymentHandler) QueryPayment(c *gin.Context) (any, error) {
+	paymentNo := c.Query("payment_no")
+	if paymentNo == "" {
+		return nil, errcode.ErrParams
+	}
+
+	resp, err := ph.paymentAppSvc.QueryPayment(c, c.GetInt64("user_id"), paymentNo)
+	return resp, err
+}
+
+// Notify 支付网关的异步回调, 返回非200时网关会重试
+func (ph *PaymentHandler) Notify(c *gin.Context) (any, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	return nil, ph.paymentAppSvc.HandleNotify(c, c.Param("channel"), c.Request.Header, body)
+}
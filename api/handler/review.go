@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/logic/appservice"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type ReviewHandler struct {
+	*Handler
+	reviewAppSvc *appservice.ReviewAppSvc
+}
+
+func NewReviewHandler(handler *Handler, reviewAppSvc *appservice.ReviewAppSvc) *ReviewHandler {
+	return &ReviewHandler{Handler: handler, reviewAppSvc: reviewAppSvc}
+}
+
+func (rh *ReviewHandler) CreateReview(c *gin.Context) (any, error) {
+	req := new(request.ReviewCreateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := rh.reviewAppSvc.CreateReview(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (rh *ReviewHandler) FollowUpReview(c *gin.Context) (any, error) {
+	req := new(request.ReviewFollowUpReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := rh.reviewAppSvc.FollowUpReview(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (rh *ReviewHandler) ListCommodityReviews(c *gin.Context) (any, error) {
+	commodityID, err := strconv.ParseInt(c.Query("commodity_id"), 10, 64)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rh.reviewAppSvc.ListCommodityReviews(c, commodityID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (rh *ReviewHandler) GetRatingStats(c *gin.Context) (any, error) {
+	commodityID, err := strconv.ParseInt(c.Query("commodity_id"), 10, 64)
+	if err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := rh.reviewAppSvc.GetRatingStats(c, commodityID)
+	return resp, err
+}
+
+func (rh *ReviewHandler) AdminListReviews(c *gin.Context) (any, error) {
+	filter := new(do.ReviewListFilter)
+	if s := c.Query("commodity_id"); s != "" {
+		commodityID, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errcode.ErrParams.WithCause(err)
+		}
+		filter.CommodityID = commodityID
+	}
+	if s := c.Query("state"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 8)
+		if err != nil {
+			return nil, errcode.ErrParams.WithCause(err)
+		}
+		st := int8(v)
+		filter.State = &st
+	}
+
+	pagination, err := app.ParsePagination(c)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rh.reviewAppSvc.ListReviews(c, filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.WithPagination(resp, pagination), nil
+}
+
+func (rh *ReviewHandler) AdminModerateReview(c *gin.Context) (any, error) {
+	req := new(request.ReviewModerateReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := rh.reviewAppSvc.ModerateReview(c, c.GetInt64("user_id"), req)
+	return resp, err
+}
+
+func (rh *ReviewHandler) AdminReplyReview(c *gin.Context) (any, error) {
+	req := new(request.ReviewReplyReq)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	resp, err := rh.reviewAppSvc.ReplyReview(c, req)
+	return resp, err
+}
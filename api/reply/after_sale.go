@@ -0,0 +1,32 @@
+package reply
+
+type AfterSaleResp struct {
+	TicketNo     string               `json:"ticket_no"`
+	OrderNo      string               `json:"order_no"`
+	Type         int8                 `json:"type"`
+	State        int8                 `json:"state"`
+	Reason       string               `json:"reason"`
+	RefundAmount int64                `json:"refund_amount"`
+	AuditRemark  string               `json:"audit_remark,omitempty"`
+	RefundedAt   string               `json:"refunded_at,omitempty"`
+	CreatedAt    string               `json:"created_at,omitempty"`
+	Items        []*AfterSaleItemResp `json:"items"`
+	Logs         []*AfterSaleLogResp  `json:"logs,omitempty"`
+}
+
+type AfterSaleItemResp struct {
+	OrderItemID  int64 `json:"order_item_id"`
+	SkuID        int64 `json:"sku_id"`
+	Quantity     int64 `json:"quantity"`
+	RefundAmount int64 `json:"refund_amount"`
+}
+
+type AfterSaleLogResp struct {
+	OperatorType string `json:"operator_type"`
+	OperatorID   int64  `json:"operator_id"`
+	Action       string `json:"action"`
+	FromState    int8   `json:"from_state"`
+	ToState      int8   `json:"to_state"`
+	Remark       string `json:"remark,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package reply
+
+type CouponTemplateResp struct {
+	ID             int64   `json:"id"`
+	Name           string  `json:"name"`
+	Type           int8    `json:"type"`
+	Threshold      int64   `json:"threshold"`
+	DiscountAmount int64   `json:"discount_amount"`
+	DiscountRate   int64   `json:"discount_rate"`
+	MaxDiscount    int64   `json:"max_discount"`
+	ScopeType      int8    `json:"scope_type"`
+	ScopeIDs       []int64 `json:"scope_ids"`
+	UserSegment    string  `json:"user_segment,omitempty"`
+	Stackable      int8    `json:"stackable"`
+	PerUserLimit   int64   `json:"per_user_limit"`
+	ValidDays      int     `json:"valid_days"`
+	ClaimStartAt   string  `json:"claim_start_at"`
+	ClaimEndAt     string  `json:"claim_end_at"`
+}
+
+type UserCouponResp struct {
+	ID        int64               `json:"id"`
+	State     int8                `json:"state"`
+	ValidFrom string              `json:"valid_from"`
+	ValidTo   string              `json:"valid_to"`
+	OrderNo   string              `json:"order_no,omitempty"`
+	UsedAt    string              `json:"used_at,omitempty"`
+	Template  *CouponTemplateResp `json:"template"`
+}
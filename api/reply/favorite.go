@@ -0,0 +1,36 @@
+package reply
+
+// CommoditySnapshotResp 收藏和浏览记录里的商品快照, 商品下架或删除时Unavailable为true
+type CommoditySnapshotResp struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	CoverImg    string `json:"cover_img"`
+	MinPrice    int64  `json:"min_price"`
+	Unavailable bool   `json:"unavailable"`
+}
+
+// StoreSnapshotResp 收藏里的店铺快照, 店铺关闭或删除时Unavailable为true
+type StoreSnapshotResp struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Logo        string `json:"logo"`
+	Unavailable bool   `json:"unavailable"`
+}
+
+type FavoriteResp struct {
+	TargetType int8                   `json:"target_type"`
+	TargetID   int64                  `json:"target_id"`
+	CreatedAt  string                 `json:"created_at"`
+	Commodity  *CommoditySnapshotResp `json:"commodity,omitempty"`
+	Store      *StoreSnapshotResp     `json:"store,omitempty"`
+}
+
+type FavoriteCountResp struct {
+	CommodityID int64 `json:"commodity_id"`
+	Count       int64 `json:"count"`
+}
+
+type BrowseRecordResp struct {
+	ViewedAt  string                 `json:"viewed_at"`
+	Commodity *CommoditySnapshotResp `json:"commodity"`
+}
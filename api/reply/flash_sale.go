@@ -0,0 +1,24 @@
+package reply
+
+type FlashSaleResp struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	SkuID      int64  `json:"sku_id"`
+	Price      int64  `json:"price"`
+	TotalStock int64  `json:"total_stock"`
+	StartAt    string `json:"start_at"`
+	EndAt      string `json:"end_at"`
+	Warmed     int8   `json:"warmed"`
+	Settled    int8   `json:"settled"`
+}
+
+type FlashSaleTokenResp struct {
+	Token string `json:"token"`
+}
+
+type FlashSaleResultResp struct {
+	Status   string `json:"status"` // queued, success, failed
+	OrderNo  string `json:"order_no,omitempty"`
+	FailCode int    `json:"fail_code,omitempty"`
+	Reason   string `json:"reason,omitempty"` // 失败原因, 按Accept-Language返回
+}
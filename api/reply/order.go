@@ -0,0 +1,55 @@
+package reply
+
+type OrderResp struct {
+	OrderNo        string           `json:"order_no"`
+	State          int8             `json:"state"`
+	TotalAmount    int64            `json:"total_amount"`
+	DiscountAmount int64            `json:"discount_amount"`
+	PayAmount      int64            `json:"pay_amount"`
+	PaidAt         string           `json:"paid_at,omitempty"`
+	ExpireAt       string           `json:"expire_at,omitempty"`
+	CreatedAt      string           `json:"created_at,omitempty"`
+	Items          []*OrderItemResp `json:"items"`
+}
+
+type OrderItemResp struct {
+	CommodityID    int64  `json:"commodity_id"`
+	SkuID          int64  `json:"sku_id"`
+	CommodityName  string `json:"commodity_name"`
+	SkuName        string `json:"sku_name"`
+	CoverImg       string `json:"cover_img"`
+	Price          int64  `json:"price"`
+	Quantity       int64  `json:"quantity"`
+	Amount         int64  `json:"amount"`
+	DiscountAmount int64  `json:"discount_amount"`
+	PayAmount      int64  `json:"pay_amount"`
+}
+
+type CheckoutResp struct {
+	TotalAmount    int64                 `json:"total_amount"`
+	DiscountAmount int64                 `json:"discount_amount"`
+	PayAmount      int64                 `json:"pay_amount"`
+	Coupons        []*CheckoutCouponResp `json:"coupons"`
+	Lines          []*CheckoutLineResp   `json:"lines"`
+}
+
+type CheckoutCouponResp struct {
+	UserCouponID   int64  `json:"user_coupon_id"`
+	TemplateID     int64  `json:"template_id"`
+	Name           string `json:"name"`
+	Type           int8   `json:"type"`
+	DiscountAmount int64  `json:"discount_amount"`
+}
+
+type CheckoutLineResp struct {
+	SkuID          int64                       `json:"sku_id"`
+	Amount         int64                       `json:"amount"`
+	DiscountAmount int64                       `json:"discount_amount"`
+	PayAmount      int64                       `json:"pay_amount"`
+	Discounts      []*CheckoutLineDiscountResp `json:"discounts"`
+}
+
+type CheckoutLineDiscountResp struct {
+	UserCouponID int64 `json:"user_coupon_id"`
+	Amount       int64 `json:"amount"`
+}
@@ -0,0 +1,11 @@
+package reply
+
+type PaymentResp struct {
+	PaymentNo string `json:"payment_no"`
+	OrderNo   string `json:"order_no"`
+	Channel   string `json:"channel"`
+	Amount    int64  `json:"amount"`
+	Status    int8   `json:"status"`
+	PayURL    string `json:"pay_url,omitempty"`
+	PaidAt    string `json:"paid_at,omitempty"`
+}
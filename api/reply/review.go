@@ -0,0 +1,32 @@
+package reply
+
+type ReviewResp struct {
+	ID            int64       `json:"id"`
+	Nickname      string      `json:"nickname"` // 脱敏后的昵称
+	SkuName       string      `json:"sku_name"`
+	Rating        int8        `json:"rating,omitempty"`
+	Content       string      `json:"content"`
+	Images        []string    `json:"images"`
+	State         int8        `json:"state"`
+	MerchantReply string      `json:"merchant_reply,omitempty"`
+	RepliedAt     string      `json:"replied_at,omitempty"`
+	CreatedAt     string      `json:"created_at"`
+	FollowUp      *ReviewResp `json:"follow_up,omitempty"`
+}
+
+// AdminReviewResp 管理后台的评价, 带上审核相关的信息
+type AdminReviewResp struct {
+	ReviewResp
+	ParentID     int64  `json:"parent_id"`
+	UserID       int64  `json:"user_id"`
+	CommodityID  int64  `json:"commodity_id"`
+	OrderItemID  int64  `json:"order_item_id"`
+	RejectReason string `json:"reject_reason,omitempty"`
+}
+
+type RatingStatsResp struct {
+	CommodityID  int64          `json:"commodity_id"`
+	Count        int64          `json:"count"`
+	Average      float64        `json:"average"`
+	Distribution map[int8]int64 `json:"distribution"`
+}
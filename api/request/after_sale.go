@@ -0,0 +1,23 @@
+package request
+
+type AfterSaleApplyReq struct {
+	OrderNo string              `json:"order_no" binding:"required"`
+	Type    int8                `json:"type" binding:"required,oneof=1 2"`
+	Reason  string              `json:"reason" binding:"required,max=200"`
+	Items   []*AfterSaleItemReq `json:"items" binding:"required,min=1,unique=OrderItemID,dive"`
+}
+
+type AfterSaleItemReq struct {
+	OrderItemID  int64 `json:"order_item_id" binding:"required,gt=0"`
+	Quantity     int64 `json:"quantity" binding:"required,gt=0"`
+	RefundAmount int64 `json:"refund_amount" binding:"gte=0"` // 单位: 分, 不传按明细实付金额等比计算
+}
+
+type AfterSaleCancelReq struct {
+	TicketNo string `json:"ticket_no" binding:"required"`
+}
+
+type AfterSaleAuditReq struct {
+	TicketNo string `json:"ticket_no" binding:"required"`
+	Remark   string `json:"remark" binding:"max=200"`
+}
@@ -0,0 +1,23 @@
+package request
+
+type CouponTemplateCreateReq struct {
+	Name           string  `json:"name" binding:"required,max=64"`
+	Type           int8    `json:"type" binding:"required,oneof=1 2 3"`
+	Threshold      int64   `json:"threshold" binding:"gte=0"`
+	DiscountAmount int64   `json:"discount_amount" binding:"gte=0"`
+	DiscountRate   int64   `json:"discount_rate" binding:"gte=0,lt=100"`
+	MaxDiscount    int64   `json:"max_discount" binding:"gte=0"`
+	ScopeType      int8    `json:"scope_type" binding:"oneof=0 1 2"`
+	ScopeIDs       []int64 `json:"scope_ids" binding:"max=100,dive,gt=0"`
+	UserSegment    string  `json:"user_segment" binding:"omitempty,oneof=new_user"`
+	Stackable      bool    `json:"stackable"`
+	TotalStock     int64   `json:"total_stock" binding:"required,gt=0"`
+	PerUserLimit   int64   `json:"per_user_limit" binding:"required,gt=0"`
+	ValidDays      int     `json:"valid_days" binding:"required,gt=0,lte=365"`
+	ClaimStartAt   string  `json:"claim_start_at" binding:"required,datetime=2006-01-02 15:04:05"`
+	ClaimEndAt     string  `json:"claim_end_at" binding:"required,datetime=2006-01-02 15:04:05"`
+}
+
+type CouponClaimReq struct {
+	TemplateID int64 `json:"template_id" binding:"required,gt=0"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type FavoriteReq struct {
+	TargetType int8  `json:"target_type" binding:"required,oneof=1 2"`
+	TargetID   int64 `json:"target_id" binding:"required,gt=0"`
+}
+
+type BrowseRecordReq struct {
+	CommodityID int64 `json:"commodity_id" binding:"required,gt=0"`
+}
@@ -0,0 +1,23 @@
+package request
+
+type FlashSaleCreateReq struct {
+	Name       string `json:"name" binding:"required,max=64"`
+	SkuID      int64  `json:"sku_id" binding:"required,gt=0"`
+	Price      int64  `json:"price" binding:"required,gt=0"`
+	TotalStock int64  `json:"total_stock" binding:"required,gt=0"`
+	StartAt    string `json:"start_at" binding:"required,datetime=2006-01-02 15:04:05"`
+	EndAt      string `json:"end_at" binding:"required,datetime=2006-01-02 15:04:05"`
+}
+
+type FlashSaleWarmReq struct {
+	FlashSaleID int64 `json:"flash_sale_id" binding:"required,gt=0"`
+}
+
+type FlashSaleSettleReq struct {
+	FlashSaleID int64 `json:"flash_sale_id" binding:"required,gt=0"`
+}
+
+type FlashSaleGrabReq struct {
+	FlashSaleID int64  `json:"flash_sale_id" binding:"required,gt=0"`
+	Token       string `json:"token" binding:"required"`
+}
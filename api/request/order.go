@@ -0,0 +1,17 @@
+package request
+
+type OrderCreateReq struct {
+	Items     []*OrderItemReq `json:"items" binding:"required,min=1,max=50,dive"`
+	CouponIDs []int64         `json:"coupon_ids" binding:"max=5,dive,gt=0"`
+}
+
+type OrderItemReq struct {
+	SkuID    int64 `json:"sku_id" binding:"required,gt=0"`
+	Quantity int64 `json:"quantity" binding:"required,gt=0,lte=99"`
+}
+
+type OrderCheckoutReq struct {
+	Items      []*OrderItemReq `json:"items" binding:"required,min=1,max=50,dive"`
+	CouponIDs  []int64         `json:"coupon_ids" binding:"max=5,dive,gt=0"`
+	AutoSelect bool            `json:"auto_select"` // 为true时忽略coupon_ids, 自动选择最优的优惠券组合
+}
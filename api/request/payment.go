@@ -0,0 +1,7 @@
+package request
+
+type PaymentCreateReq struct {
+	OrderNo        string `json:"order_no" binding:"required"`
+	Channel        string `json:"channel" binding:"required"`
+	IdempotencyKey string `json:"idempotency_key" binding:"required,max=64"` // 客户端为每次支付尝试生成, 重试时保持不变
+}
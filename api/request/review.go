@@ -0,0 +1,25 @@
+package request
+
+type ReviewCreateReq struct {
+	OrderItemID int64    `json:"order_item_id" binding:"required,gt=0"`
+	Rating      int8     `json:"rating" binding:"required,min=1,max=5"`
+	Content     string   `json:"content" binding:"required,max=500"`
+	Images      []string `json:"images" binding:"max=9,dive,url"`
+}
+
+type ReviewFollowUpReq struct {
+	ReviewID int64    `json:"review_id" binding:"required,gt=0"`
+	Content  string   `json:"content" binding:"required,max=500"`
+	Images   []string `json:"images" binding:"max=9,dive,url"`
+}
+
+type ReviewModerateReq struct {
+	ReviewID int64  `json:"review_id" binding:"required,gt=0"`
+	Approve  bool   `json:"approve"`
+	Reason   string `json:"reason" binding:"max=200"`
+}
+
+type ReviewReplyReq struct {
+	ReviewID int64  `json:"review_id" binding:"required,gt=0"`
+	Content  string `json:"content" binding:"required,max=500"`
+}
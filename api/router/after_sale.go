@@ -0,0 +1,25 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerAfterSaleRoutes(rg *gin.RouterGroup, auth *middleware.Auth, afterSaleHandler *handler.AfterSaleHandler) {
+	g := rg.Group("/aftersale/", auth.AuthUser())
+
+	g.POST("apply", app.Wrap(afterSaleHandler.ApplyAfterSale))
+	g.POST("cancel", app.Wrap(afterSaleHandler.CancelAfterSale))
+	g.GET("detail", app.Wrap(afterSaleHandler.GetUserAfterSale))
+	g.GET("list", app.Wrap(afterSaleHandler.ListUserAfterSales))
+
+	admin := rg.Group("/admin/aftersale/", auth.AuthUser(), auth.AuthAdmin())
+
+	admin.GET("list", app.Wrap(afterSaleHandler.AdminListAfterSales))
+	admin.GET("detail", app.Wrap(afterSaleHandler.AdminGetAfterSale))
+	admin.POST("approve", app.Wrap(afterSaleHandler.AdminApproveAfterSale))
+	admin.POST("reject", app.Wrap(afterSaleHandler.AdminRejectAfterSale))
+}
@@ -4,18 +4,19 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
 	"github.com/kackerx/go-mall/common/middleware"
 )
 
-func registerBuildingRoutes(rg *gin.RouterGroup) {
+func registerBuildingRoutes(rg *gin.RouterGroup, auth *middleware.Auth, buildingHandler *handler.BuildingHandler) {
 	g := rg.Group("/building/")
 
 	g.GET("ping", handler.TestErr)
 	g.GET("resperr", handler.TestRespErr)
 	g.GET("/respsuccess", handler.TestRespSuccess)
-	g.POST("/demoorder/add", handler.TestCreateDemoOrder)
-	g.GET("/whois", handler.TestWhoisLibReq)
-	g.GET("/gentoken", handler.TestMakeToken)
-	g.GET("/gettoken", middleware.AuthUser(), handler.TestGetToken)
-	g.GET("/refreshtoken", middleware.AuthUser(), handler.TestRefreshToken)
+	g.POST("/demoorder/add", app.Wrap(buildingHandler.TestCreateDemoOrder))
+	g.GET("/whois", app.Wrap(handler.TestWhoisLibReq))
+	g.GET("/gentoken", app.Wrap(buildingHandler.TestMakeToken))
+	g.GET("/gettoken", auth.AuthUser(), handler.TestGetToken)
+	g.GET("/refreshtoken", auth.AuthUser(), app.Wrap(buildingHandler.TestRefreshToken))
 }
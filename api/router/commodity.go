@@ -4,10 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
 )
 
 func registerCommodityRoutes(rg *gin.RouterGroup, commodityHandler *handler.CommodityHandler) {
 	g := rg.Group("/commodity/")
 
-	g.POST("init-category", commodityHandler.InitCategory)
+	g.POST("init-category", app.Wrap(commodityHandler.InitCategory))
 }
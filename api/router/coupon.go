@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerCouponRoutes(rg *gin.RouterGroup, auth *middleware.Auth, limiter *middleware.RateLimiter, couponHandler *handler.CouponHandler) {
+	g := rg.Group("/coupon/")
+
+	g.GET("templates", app.Wrap(couponHandler.ListTemplates))
+	g.POST("claim", auth.AuthUser(), limiter.Limit("coupon_claim"), app.Wrap(couponHandler.ClaimCoupon))
+	g.GET("mine", auth.AuthUser(), app.Wrap(couponHandler.ListUserCoupons))
+
+	admin := rg.Group("/admin/coupon/", auth.AuthUser(), auth.AuthAdmin())
+
+	admin.POST("template/create", app.Wrap(couponHandler.AdminCreateTemplate))
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerFavoriteRoutes(rg *gin.RouterGroup, auth *middleware.Auth, favoriteHandler *handler.FavoriteHandler) {
+	g := rg.Group("/favorite/")
+
+	g.GET("count", app.Wrap(favoriteHandler.GetFavoriteCount))
+	g.POST("add", auth.AuthUser(), app.Wrap(favoriteHandler.AddFavorite))
+	g.POST("remove", auth.AuthUser(), app.Wrap(favoriteHandler.RemoveFavorite))
+	g.GET("list", auth.AuthUser(), app.Wrap(favoriteHandler.ListFavorites))
+
+	history := rg.Group("/history/", auth.AuthUser())
+
+	history.POST("record", app.Wrap(favoriteHandler.RecordBrowse))
+	history.GET("list", app.Wrap(favoriteHandler.ListBrowseHistory))
+	history.POST("clear", app.Wrap(favoriteHandler.ClearBrowseHistory))
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerFlashSaleRoutes(rg *gin.RouterGroup, auth *middleware.Auth, flashSaleHandler *handler.FlashSaleHandler) {
+	g := rg.Group("/flashsale/")
+
+	g.GET("detail", app.Wrap(flashSaleHandler.GetFlashSale))
+	g.GET("token", auth.AuthUser(), app.Wrap(flashSaleHandler.IssueToken))
+	g.POST("grab", auth.AuthUser(), app.Wrap(flashSaleHandler.Grab))
+	g.GET("result", auth.AuthUser(), app.Wrap(flashSaleHandler.GetResult))
+
+	admin := rg.Group("/admin/flashsale/", auth.AuthUser(), auth.AuthAdmin())
+
+	admin.POST("create", app.Wrap(flashSaleHandler.AdminCreateFlashSale))
+	admin.POST("warm", app.Wrap(flashSaleHandler.AdminWarmUp))
+	admin.POST("settle", app.Wrap(flashSaleHandler.AdminSettle))
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/metrics"
+)
+
+// registerHealthRoutes 探针和指标接口要在全局中间件之前注册, 不产生访问日志和trace
+func registerHealthRoutes(engin *gin.Engine, healthHandler *handler.HealthHandler) {
+	engin.GET("/healthz", healthHandler.Healthz)
+	engin.GET("/readyz", healthHandler.Readyz)
+	engin.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerOrderRoutes(rg *gin.RouterGroup, auth *middleware.Auth, limiter *middleware.RateLimiter, idempotency *middleware.Idempotency, orderHandler *handler.OrderHandler) {
+	g := rg.Group("/order/")
+
+	g.POST("checkout", auth.AuthUser(), limiter.Limit("order"), app.Wrap(orderHandler.Checkout))
+	g.POST("create", auth.AuthUser(), limiter.Limit("order"), idempotency.Idempotent(), app.Wrap(orderHandler.CreateOrder))
+	g.GET("detail", auth.AuthUser(), app.Wrap(orderHandler.GetOrderDetail))
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerPaymentRoutes(rg *gin.RouterGroup, auth *middleware.Auth, limiter *middleware.RateLimiter, idempotency *middleware.Idempotency, paymentHandler *handler.PaymentHandler) {
+	g := rg.Group("/payment/")
+
+	g.POST("create", auth.AuthUser(), limiter.Limit("payment"), idempotency.Idempotent(), app.Wrap(paymentHandler.CreatePayment))
+	g.GET("query", auth.AuthUser(), app.Wrap(paymentHandler.QueryPayment))
+	g.POST("notify/:channel", app.Wrap(paymentHandler.Notify))
+}
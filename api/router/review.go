@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/middleware"
+)
+
+func registerReviewRoutes(rg *gin.RouterGroup, auth *middleware.Auth, reviewHandler *handler.ReviewHandler) {
+	g := rg.Group("/review/")
+
+	g.GET("list", app.Wrap(reviewHandler.ListCommodityReviews))
+	g.GET("stats", app.Wrap(reviewHandler.GetRatingStats))
+	g.POST("create", auth.AuthUser(), app.Wrap(reviewHandler.CreateReview))
+	g.POST("follow-up", auth.AuthUser(), app.Wrap(reviewHandler.FollowUpReview))
+
+	admin := rg.Group("/admin/review/", auth.AuthUser(), auth.AuthAdmin())
+
+	admin.GET("list", app.Wrap(reviewHandler.AdminListReviews))
+	admin.POST("moderate", app.Wrap(reviewHandler.AdminModerateReview))
+	admin.POST("reply", app.Wrap(reviewHandler.AdminReplyReview))
+}
@@ -9,13 +9,34 @@ import (
 
 func RegisterRoute(
 	engin *gin.Engine,
+	auth *middleware.Auth,
+	limiter *middleware.RateLimiter,
+	idempotency *middleware.Idempotency,
+	healthHandler *handler.HealthHandler,
+	buildingHandler *handler.BuildingHandler,
 	userHandler *handler.UserHandler,
 	commodityHandler *handler.CommodityHandler,
+	orderHandler *handler.OrderHandler,
+	paymentHandler *handler.PaymentHandler,
+	afterSaleHandler *handler.AfterSaleHandler,
+	couponHandler *handler.CouponHandler,
+	flashSaleHandler *handler.FlashSaleHandler,
+	reviewHandler *handler.ReviewHandler,
+	favoriteHandler *handler.FavoriteHandler,
 ) {
-	engin.Use(middleware.StartTrace(), middleware.LogAccess(), middleware.GinPanicRecovery())
+	registerHealthRoutes(engin, healthHandler)
+
+	engin.Use(middleware.StartTrace(), middleware.Metrics(), middleware.LogAccess(), middleware.GinPanicRecovery(), middleware.ReadYourWrites())
 	routeGroup := engin.Group("")
 
-	registerBuildingRoutes(routeGroup)
-	registerUserRoutes(routeGroup, userHandler)
+	registerBuildingRoutes(routeGroup, auth, buildingHandler)
+	registerUserRoutes(routeGroup, auth, limiter, idempotency, userHandler)
 	registerCommodityRoutes(routeGroup, commodityHandler)
+	registerOrderRoutes(routeGroup, auth, limiter, idempotency, orderHandler)
+	registerPaymentRoutes(routeGroup, auth, limiter, idempotency, paymentHandler)
+	registerAfterSaleRoutes(routeGroup, auth, afterSaleHandler)
+	registerCouponRoutes(routeGroup, auth, limiter, couponHandler)
+	registerFlashSaleRoutes(routeGroup, auth, flashSaleHandler)
+	registerReviewRoutes(routeGroup, auth, reviewHandler)
+	registerFavoriteRoutes(routeGroup, auth, favoriteHandler)
 }
@@ -4,13 +4,14 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/kackerx/go-mall/api/handler"
+	"github.com/kackerx/go-mall/common/app"
 	"github.com/kackerx/go-mall/common/middleware"
 )
 
-func registerUserRoutes(rg *gin.RouterGroup, userHandler *handler.UserHandler) {
-	g := rg.Group("/user/")
+func registerUserRoutes(rg *gin.RouterGroup, auth *middleware.Auth, limiter *middleware.RateLimiter, idempotency *middleware.Idempotency, userHandler *handler.UserHandler) {
+	g := rg.Group("/user/", limiter.Limit("user"))
 
-	g.POST("register", userHandler.RegisterUser)
-	g.POST("login", userHandler.LoginUser)
-	g.GET("loginout", middleware.AuthUser(), userHandler.LoginoutUser)
+	g.POST("register", idempotency.Idempotent(), app.Wrap(userHandler.RegisterUser))
+	g.POST("login", app.Wrap(userHandler.LoginUser))
+	g.GET("loginout", auth.AuthUser(), app.Wrap(userHandler.LoginoutUser))
 }
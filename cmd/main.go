@@ -1,41 +1,250 @@
 package main
 
 import (
-	"fmt"
-	"net/http"
+	"context"
+	"flag"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/kackerx/go-mall/api/handler"
 	"github.com/kackerx/go-mall/api/router"
+	"github.com/kackerx/go-mall/common/app"
 	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/health"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/middleware"
+	"github.com/kackerx/go-mall/common/server"
+	"github.com/kackerx/go-mall/common/tracing"
+	"github.com/kackerx/go-mall/common/util"
 	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/dal/cache"
 	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/tx"
+	"github.com/kackerx/go-mall/library/eventbus"
+	"github.com/kackerx/go-mall/library/payment"
+	"github.com/kackerx/go-mall/library/ratelimit"
 	"github.com/kackerx/go-mall/logic/appservice"
+	"github.com/kackerx/go-mall/logic/do"
 	"github.com/kackerx/go-mall/logic/domainservice"
 )
 
 func main() {
-	conf := config.Conf
+	configPath := flag.String("config", config.DefaultPath(), "配置文件路径, 密钥类配置用GOMALL_前缀的环境变量传入")
+	flag.Parse()
+
+	conf, err := config.Load(*configPath)
+	if err != nil {
+		panic(err)
+	}
+	logger.SetDefault(logger.NewZap(conf.App))
+	defer logger.Sync()
+	errcode.SetStackCapture(conf.App.Log.ErrorStack)
+	app.SetPaginationOption(conf.App.Pagination)
+	util.SetCursorSecret(conf.App.CursorSecret)
+	middleware.SetAccessLogOption(conf.App.Log.Access)
+	shutdownTracing, err := tracing.Init(context.Background(), conf.Tracing, conf.App.Name, conf.App.Env)
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := dao.NewDB(conf.DB)
+	if err != nil {
+		panic(err)
+	}
+	redisClient, err := cache.NewClient(conf.Redis)
+	if err != nil {
+		panic(err)
+	}
+	redisCache := cache.New(redisClient)
+
 	if conf.App.Env == enum.ModeProd {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	e := gin.Default()
+	// handler把*gin.Context当ctx往下传, 需要回落到Request.Context()才能取到trace
+	e.ContextWithFallback = true
 
+	txManager := tx.NewManager(db)
 	baseHandler := handler.NewHandler()
-	userDomainSvc := domainservice.NewUserDomainSvc()
-	userAppSvc := appservice.NewUserAppSvc(userDomainSvc)
+	auth := middleware.NewAuth(redisCache, conf.App.AdminUserIDs)
+
+	var rateLimitConf config.RateLimit
+	if conf.RateLimit != nil {
+		rateLimitConf = *conf.RateLimit
+	}
+	var rateLimiter ratelimit.Limiter
+	if rateLimitConf.Backend == "redis" {
+		rateLimiter = ratelimit.NewRedisLimiter(redisClient, rateLimitConf.KeyPrefix)
+	} else {
+		rateLimiter = ratelimit.NewMemoryLimiter()
+	}
+	limiter := middleware.NewRateLimiter(rateLimiter, rateLimitConf.Groups)
+	idempotency := middleware.NewIdempotency(redisCache, conf.Idempotency)
+
+	var outboxConf config.Outbox
+	if conf.Outbox != nil {
+		outboxConf = *conf.Outbox
+	}
+	var eventPublisher eventbus.Publisher
+	var inProcessPublisher *eventbus.InProcessPublisher
+	if outboxConf.Publisher == "redis" {
+		eventPublisher = eventbus.NewRedisStreamPublisher(redisClient, outboxConf.StreamPrefix)
+	} else {
+		inProcessPublisher = eventbus.NewInProcessPublisher()
+		eventPublisher = inProcessPublisher
+	}
+	outboxDomainSvc := domainservice.NewOutboxDomainSvc(dao.NewOutboxDao(db), txManager, eventPublisher, domainservice.OutboxRelayOption{
+		PollInterval: time.Duration(outboxConf.PollInterval) * time.Millisecond,
+		BatchSize:    outboxConf.BatchSize,
+		MaxAttempts:  outboxConf.MaxAttempts,
+		LeaseTimeout: time.Duration(outboxConf.LeaseTimeout) * time.Second,
+	})
+
+	userDao := dao.NewUserDao(db)
+	userDomainSvc := domainservice.NewUserDomainSvc(userDao, redisCache)
+	userAppSvc := appservice.NewUserAppSvc(userDomainSvc, outboxDomainSvc, txManager)
 	userHandler := handler.NewUserHandler(baseHandler, userAppSvc)
+	buildingHandler := handler.NewBuildingHandler(baseHandler, db, redisCache, userAppSvc)
 
-	commodityDao := dao.NewCommodityDao()
+	commodityDao := dao.NewCommodityDao(db)
 	commodityDomainSvc := domainservice.NewCommoditySvc(commodityDao)
 	commodityApp := appservice.NewCommodityApp(commodityDomainSvc)
 	commodityHandler := handler.NewCommodityHandler(baseHandler, commodityApp)
 
-	router.RegisterRoute(e, userHandler, commodityHandler)
-	fmt.Println("listen on 9999")
-	if err := http.ListenAndServe(":9999", e); err != nil {
-		return
+	couponDao := dao.NewCouponDao(db)
+	couponDomainSvc := domainservice.NewCouponDomainSvc(couponDao, userDao, commodityDao, redisCache)
+	couponAppSvc := appservice.NewCouponAppSvc(couponDomainSvc)
+	couponHandler := handler.NewCouponHandler(baseHandler, couponAppSvc)
+
+	orderDao := dao.NewOrderDao(db)
+	orderDomainSvc := domainservice.NewOrderDomainSvc(orderDao, commodityDao, couponDomainSvc)
+	orderAppSvc := appservice.NewOrderAppSvc(orderDomainSvc)
+	orderHandler := handler.NewOrderHandler(baseHandler, orderAppSvc)
+
+	var paymentConf config.Payment
+	if conf.Payment != nil {
+		paymentConf = *conf.Payment
+	}
+	paymentGateways := payment.NewRegistry()
+	if mockConf := paymentConf.Mock; mockConf != nil && mockConf.Enable {
+		paymentGateways.Register(payment.NewMockGateway(
+			mockConf.Secret,
+			payment.MockScenario(mockConf.Scenario),
+			time.Duration(mockConf.NotifyDelay)*time.Millisecond,
+		))
+	}
+	paymentDao := dao.NewPaymentDao(db)
+	paymentDomainSvc := domainservice.NewPaymentDomainSvc(paymentDao, orderDao, couponDao, outboxDomainSvc, txManager, paymentGateways, paymentConf.NotifyBaseURL)
+	paymentAppSvc := appservice.NewPaymentAppSvc(paymentDomainSvc)
+	// 用Redis Stream投递时, 由消费方订阅payment.refund_required调用退款
+	if inProcessPublisher != nil {
+		inProcessPublisher.Subscribe(do.EventPaymentRefundRequired, paymentAppSvc.OnRefundRequired)
+	}
+	paymentHandler := handler.NewPaymentHandler(baseHandler, paymentAppSvc)
+
+	afterSaleDao := dao.NewAfterSaleDao(db)
+	afterSaleDomainSvc := domainservice.NewAfterSaleDomainSvc(afterSaleDao, orderDao, paymentDao, paymentGateways)
+	afterSaleAppSvc := appservice.NewAfterSaleAppSvc(afterSaleDomainSvc)
+	afterSaleHandler := handler.NewAfterSaleHandler(baseHandler, afterSaleAppSvc)
+
+	reviewDomainSvc := domainservice.NewReviewDomainSvc(dao.NewReviewDao(db), orderDao, userDao, redisCache)
+	reviewAppSvc := appservice.NewReviewAppSvc(reviewDomainSvc)
+	reviewHandler := handler.NewReviewHandler(baseHandler, reviewAppSvc)
+
+	favoriteDomainSvc := domainservice.NewFavoriteDomainSvc(dao.NewFavoriteDao(db), commodityDao, redisCache)
+	historyDomainSvc := domainservice.NewBrowseHistoryDomainSvc(commodityDao, redisCache)
+	favoriteAppSvc := appservice.NewFavoriteAppSvc(favoriteDomainSvc, historyDomainSvc, commodityDomainSvc)
+	favoriteHandler := handler.NewFavoriteHandler(baseHandler, favoriteAppSvc)
+
+	var flashSaleConf config.FlashSale
+	if conf.FlashSale != nil {
+		flashSaleConf = *conf.FlashSale
+	}
+	flashSaleDomainSvc := domainservice.NewFlashSaleDomainSvc(dao.NewFlashSaleDao(db), commodityDao, flashSaleConf.UserQPS, redisCache)
+	flashSaleAppSvc := appservice.NewFlashSaleAppSvc(flashSaleDomainSvc)
+	flashSaleHandler := handler.NewFlashSaleHandler(baseHandler, flashSaleAppSvc)
+
+	// 日志级别、访问日志选项、分页参数、秒杀限流阈值支持改配置文件热更新, 其余配置需要重启
+	reloader, err := config.NewReloader(*configPath, conf)
+	if err != nil {
+		panic(err)
+	}
+	reloader.Subscribe(func(c *config.Config) {
+		if level := c.App.Log.Level; level != "" {
+			if err := logger.SetLevel(level); err != nil {
+				logger.New(context.Background()).Error("reload log level failed", "err", err)
+			}
+		}
+		errcode.SetStackCapture(c.App.Log.ErrorStack)
+		app.SetPaginationOption(c.App.Pagination)
+		middleware.SetAccessLogOption(c.App.Log.Access)
+		if c.RateLimit != nil {
+			limiter.SetRules(c.RateLimit.Groups)
+		}
+		if c.FlashSale != nil {
+			flashSaleDomainSvc.SetUserQPS(c.FlashSale.UserQPS)
+		}
+		logger.New(context.Background()).Info("config reloaded")
+	})
+	reloader.Start()
+
+	srv := server.New(conf.App.Server, e)
+
+	healthRegistry := health.NewRegistry()
+	healthRegistry.Register("mysql_master", time.Second, db.PingMaster)
+	healthRegistry.Register("mysql_slave", time.Second, db.PingSlave)
+	healthRegistry.Register("redis", time.Second, func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	healthHandler := handler.NewHealthHandler(baseHandler, healthRegistry, srv.Ready)
+
+	router.RegisterRoute(e, auth, limiter, idempotency, healthHandler, buildingHandler, userHandler, commodityHandler, orderHandler, paymentHandler, afterSaleHandler, couponHandler, flashSaleHandler, reviewHandler, favoriteHandler)
+
+	// 后台任务用单独的ctx, 等HTTP请求排空后再停, 避免排空期间的请求写进队列没人消费
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workersDone := make(chan struct{})
+	workers.Add(3)
+	go func() {
+		defer workers.Done()
+		flashSaleAppSvc.RunOrderConsumer(workerCtx, max(flashSaleConf.OrderWorkers, 1))
+	}()
+	go func() {
+		defer workers.Done()
+		outboxDomainSvc.RunRelay(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		orderDomainSvc.CloseExpiredOrders(workerCtx)
+	}()
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	go db.WatchReplicas(workerCtx, time.Duration(conf.DB.HealthCheckInterval)*time.Second)
+
+	srv.OnShutdown("workers", func(ctx context.Context) error {
+		stopWorkers()
+		select {
+		case <-workersDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	srv.OnShutdown("mysql", func(ctx context.Context) error {
+		return db.Close()
+	})
+	srv.OnShutdown("redis", func(ctx context.Context) error {
+		return redisClient.Close()
+	})
+	srv.OnShutdown("tracing", shutdownTracing)
+
+	if err = srv.Run(context.Background()); err != nil {
+		logger.New(context.Background()).Error("server exited with error", "err", err)
 	}
 }
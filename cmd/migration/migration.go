@@ -1,16 +1,139 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
 
-	"github.com/kackerx/go-mall/dal/dao"
-	"github.com/kackerx/go-mall/dal/model"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/spf13/cobra"
+
+	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/dal/migrate"
+	"github.com/kackerx/go-mall/resources"
+)
+
+// 命令行参数
+var (
+	configPath  string
+	dryRun      bool
+	lockTimeout time.Duration
+	upSteps     int
+	downSteps   int
+	dir         string
 )
 
 func main() {
-	if err := dao.DB().Migrator().AutoMigrate(&model.DemoOrder{}); err != nil {
-		panic(err)
+	rootCmd := &cobra.Command{
+		Use:          "migration",
+		Short:        "数据库表结构版本化迁移, SQL文件在resources/migrations下, 随二进制一起嵌入",
+		SilenceUsage: true,
+	}
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", config.DefaultPath(), "配置文件路径, 迁移只连主库")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "只打印将要执行的SQL, 不执行")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 30*time.Second, "等待其他实例释放迁移锁的最长时间")
+
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "执行未执行的迁移",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withMigrator(cmd.Context(), func(m *migrate.Migrator) error {
+				return m.Up(cmd.Context(), upSteps)
+			})
+		},
+	}
+	upCmd.Flags().IntVarP(&upSteps, "steps", "n", 0, "最多执行几个, 0表示全部")
+
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "回滚最近执行的迁移",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withMigrator(cmd.Context(), func(m *migrate.Migrator) error {
+				return m.Down(cmd.Context(), downSteps)
+			})
+		},
+	}
+	downCmd.Flags().IntVarP(&downSteps, "steps", "n", 1, "回滚几个")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "查看每个迁移的执行状态",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withMigrator(cmd.Context(), func(m *migrate.Migrator) error {
+				statuses, err := m.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+				for _, s := range statuses {
+					appliedAt := "pending"
+					if !s.AppliedAt.IsZero() {
+						appliedAt = s.AppliedAt.Format(time.DateTime)
+					}
+					fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+				}
+				return w.Flush()
+			})
+		},
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "新建一对up/down迁移文件, 版本号自动递增",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// 新文件写到源码目录, 重新编译后才会嵌入二进制
+			migrations, err := migrate.Load(os.DirFS(dir))
+			if err != nil {
+				return err
+			}
+			up, down, err := migrate.NextFileNames(migrations, args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, name := range []string{up, down} {
+				path := filepath.Join(dir, name)
+				if err = os.WriteFile(path, []byte("-- "+name+"\n"), 0o644); err != nil {
+					return err
+				}
+				fmt.Println("created", path)
+			}
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&dir, "dir", "resources/migrations", "迁移文件所在的源码目录")
+
+	rootCmd.AddCommand(upCmd, downCmd, statusCmd, createCmd)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		os.Exit(1)
+	}
+}
+
+func withMigrator(ctx context.Context, fn func(m *migrate.Migrator) error) error {
+	conf, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	migrations, err := migrate.Load(resources.MigrationFS())
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("mysql", conf.DB.Master.Dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err = db.PingContext(ctx); err != nil {
+		return fmt.Errorf("connect master: %w", err)
 	}
 
-	fmt.Println("Migrator End")
+	return fn(migrate.New(db, migrations, os.Stdout, migrate.WithDryRun(dryRun), migrate.WithLockTimeout(lockTimeout)))
 }
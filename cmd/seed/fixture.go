@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// 每个scale单位生成的数据量
+const (
+	usersPerScale       = 20
+	storesPerScale      = 5
+	commoditiesPerScale = 40
+
+	seedUserPrefix = "seed_"
+)
+
+// Fixture 一份完整的种子数据. 行之间的关联用指针表示, 落库拿到自增ID后再回填外键
+type Fixture struct {
+	Stores      []*model.Store
+	Commodities []*CommodityFixture
+	Users       []*UserFixture
+}
+
+type CommodityFixture struct {
+	Store     *model.Store
+	Commodity *model.Commodity
+	Skus      []*model.CommoditySku
+}
+
+type UserFixture struct {
+	User      *model.User
+	Addresses []*model.UserAddress
+	Orders    []*OrderFixture
+}
+
+type OrderFixture struct {
+	Order   *model.Order
+	Skus    []*model.CommoditySku // 与Order.Items一一对应
+	Payment *model.Payment        // 未支付和已关闭的订单为nil
+}
+
+var (
+	storeNames = []string{"优选", "旗舰店", "专营店", "官方店", "生活馆", "精品店"}
+	brands     = []string{"小米", "华为", "海尔", "美的", "优衣库", "李宁", "安踏", "格力", "联想", "三只松鼠"}
+	products   = []string{"智能手机", "蓝牙耳机", "空气净化器", "电饭煲", "运动鞋", "纯棉T恤", "羽绒服", "笔记本电脑", "坚果礼盒", "保温杯"}
+	skuColors  = []string{"黑色", "白色", "灰色", "蓝色", "红色"}
+	skuSpecs   = []string{"标准版", "升级版", "128G", "256G", "S码", "M码", "L码"}
+	slogans    = []string{"", "买买买", "理性消费", "剁手党", "只看不买"}
+)
+
+// Generate 用r生成scale倍的数据, 所有时间都基于now往前推. r的种子和now相同时结果完全相同;
+// 密码字段只放明文占位, bcrypt每次加盐不同, 落库前再统一替换
+func Generate(r *util.Random, scale int, categories []*do.CommodityCategory, now time.Time) *Fixture {
+	f := &Fixture{}
+	leaves := leafCategories(categories)
+
+	for i := 0; i < storesPerScale*scale; i++ {
+		f.Stores = append(f.Stores, &model.Store{
+			Name:      util.Pick(r, brands) + util.Pick(r, storeNames) + fmt.Sprintf("%03d", i+1),
+			Intro:     "种子数据生成的店铺",
+			IsOpen:    1,
+			CreatedAt: pastTime(r, now, 365),
+		})
+	}
+
+	var skus []*model.CommoditySku
+	skuCommodity := make(map[*model.CommoditySku]*model.Commodity)
+	for i := 0; i < commoditiesPerScale*scale; i++ {
+		cf := &CommodityFixture{
+			Store: util.Pick(r, f.Stores),
+			Commodity: &model.Commodity{
+				Name:        util.Pick(r, brands) + util.Pick(r, products),
+				Intro:       "种子数据生成的商品",
+				IsPublished: enum.CommodityPublished,
+				CreatedAt:   pastTime(r, now, 180),
+			},
+		}
+		if len(leaves) > 0 {
+			cf.Commodity.CategoryID = util.Pick(r, leaves).ID
+		}
+		if r.Chance(10) {
+			cf.Commodity.IsPublished = enum.CommodityUnpublished
+		}
+
+		basePrice := r.Between(10, 5000) * 100
+		for j, n := 0, int(r.Between(1, 4)); j < n; j++ {
+			sku := &model.CommoditySku{
+				Name:      util.Pick(r, skuColors) + " " + util.Pick(r, skuSpecs),
+				Price:     basePrice + int64(j)*r.Between(0, 50)*100,
+				Stock:     r.Between(0, 500),
+				CreatedAt: cf.Commodity.CreatedAt,
+			}
+			cf.Skus = append(cf.Skus, sku)
+			if cf.Commodity.IsPublished == enum.CommodityPublished {
+				skus = append(skus, sku)
+				skuCommodity[sku] = cf.Commodity
+			}
+		}
+		f.Commodities = append(f.Commodities, cf)
+	}
+
+	for i := 0; i < usersPerScale*scale; i++ {
+		uf := &UserFixture{
+			User: &model.User{
+				UserName:  fmt.Sprintf("%s%05d", seedUserPrefix, i+1),
+				Nickname:  r.ChineseName(),
+				Verified:  1,
+				Slogan:    util.Pick(r, slogans),
+				CreatedAt: pastTime(r, now, 365),
+			},
+		}
+		if r.Chance(3) {
+			uf.User.IsBlocked = enum.UserBlockStateBlocked
+		}
+
+		for j, n := 0, int(r.Between(1, 3)); j < n; j++ {
+			province, city, district := r.Region()
+			address := &model.UserAddress{
+				UserName:  uf.User.Nickname,
+				UserPhone: r.Phone(),
+				Province:  province,
+				City:      city,
+				District:  district,
+				Detail:    r.Street(),
+				CreatedAt: uf.User.CreatedAt,
+			}
+			if j == 0 {
+				address.IsUserDefault = enum.AddressIsUserDefault
+			}
+			uf.Addresses = append(uf.Addresses, address)
+		}
+
+		if len(skus) > 0 {
+			for j, n := 0, int(r.Between(0, 5)); j < n; j++ {
+				uf.Orders = append(uf.Orders, genOrder(r, skus, skuCommodity, now))
+			}
+		}
+		f.Users = append(f.Users, uf)
+	}
+
+	return f
+}
+
+// genOrder 生成一个订单, 状态按大致的线上比例分布, 已支付过的订单附带一条成功的模拟支付单
+func genOrder(r *util.Random, skus []*model.CommoditySku,
+	skuCommodity map[*model.CommoditySku]*model.Commodity, now time.Time) *OrderFixture {
+	createdAt := pastTime(r, now, 90)
+	of := &OrderFixture{
+		Order: &model.Order{
+			OrderNo:   serialNo(r, "O", createdAt),
+			CreatedAt: createdAt,
+			ExpireAt:  createdAt.Add(enum.OrderPayExpireDuration),
+			PaidAt:    time.Unix(0, 0),
+		},
+	}
+
+	for i, n := 0, int(r.Between(1, 3)); i < n; i++ {
+		sku := util.Pick(r, skus)
+		commodity := skuCommodity[sku]
+		quantity := r.Between(1, 3)
+		of.Order.Items = append(of.Order.Items, &model.OrderItem{
+			CommodityName: commodity.Name,
+			SkuName:       sku.Name,
+			CoverImg:      commodity.CoverImg,
+			Price:         sku.Price,
+			Quantity:      quantity,
+			Amount:        sku.Price * quantity,
+			PayAmount:     sku.Price * quantity,
+			CreatedAt:     createdAt,
+		})
+		of.Skus = append(of.Skus, sku)
+		of.Order.TotalAmount += sku.Price * quantity
+	}
+	of.Order.PayAmount = of.Order.TotalAmount
+
+	switch n := r.Intn(100); {
+	case n < 10:
+		of.Order.State = enum.OrderStateCreated
+	case n < 25:
+		of.Order.State = enum.OrderStateClosed
+	case n < 45:
+		of.Order.State = enum.OrderStatePaid
+	case n < 65:
+		of.Order.State = enum.OrderStateShipped
+	default:
+		of.Order.State = enum.OrderStateCompleted
+	}
+
+	if of.Order.State != enum.OrderStateCreated && of.Order.State != enum.OrderStateClosed {
+		of.Order.PaidAt = createdAt.Add(time.Duration(r.Between(10, 1500)) * time.Second)
+		paymentNo := serialNo(r, "P", createdAt)
+		of.Payment = &model.Payment{
+			PaymentNo:      paymentNo,
+			IdempotencyKey: seedUserPrefix + paymentNo,
+			OrderNo:        of.Order.OrderNo,
+			Channel:        enum.PaymentChannelMock,
+			Amount:         of.Order.PayAmount,
+			Status:         enum.PaymentStatusSuccess,
+			TradeNo:        "MOCK" + paymentNo,
+			PaidAt:         of.Order.PaidAt,
+			CreatedAt:      createdAt,
+		}
+	}
+
+	return of
+}
+
+// leafCategories 没有子类目的类目, 商品只挂在叶子类目上
+func leafCategories(categories []*do.CommodityCategory) []*do.CommodityCategory {
+	hasChild := make(map[int64]bool)
+	for _, c := range categories {
+		hasChild[c.ParentID] = true
+	}
+
+	var leaves []*do.CommodityCategory
+	for _, c := range categories {
+		if !hasChild[c.ID] {
+			leaves = append(leaves, c)
+		}
+	}
+
+	return leaves
+}
+
+// pastTime now之前maxDays天内的随机时间, 精确到秒
+func pastTime(r *util.Random, now time.Time, maxDays int64) time.Time {
+	return now.Add(-time.Duration(r.Between(0, maxDays*24*3600)) * time.Second).Truncate(time.Second)
+}
+
+// serialNo 与util.GenSerialNo格式相同, 随机部分取自r
+func serialNo(r *util.Random, prefix string, at time.Time) string {
+	return fmt.Sprintf("%s%s%06d", prefix, at.Format("20060102150405"), r.Intn(1000000))
+}
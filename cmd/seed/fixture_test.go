@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/util"
+)
+
+func TestGenerateReproducible(t *testing.T) {
+	categories, err := loadCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+
+	a := Generate(util.NewSeededRandom(7), 2, categories, now)
+	b := Generate(util.NewSeededRandom(7), 2, categories, now)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed should generate the same fixture")
+	}
+	if len(a.Users) != 2*usersPerScale || len(a.Stores) != 2*storesPerScale || len(a.Commodities) != 2*commoditiesPerScale {
+		t.Fatalf("scale not applied: %d users, %d stores, %d commodities", len(a.Users), len(a.Stores), len(a.Commodities))
+	}
+
+	leaves := make(map[int64]bool)
+	for _, c := range leafCategories(categories) {
+		leaves[c.ID] = true
+	}
+	for _, cf := range a.Commodities {
+		if !leaves[cf.Commodity.CategoryID] {
+			t.Errorf("commodity %s on non-leaf category %d", cf.Commodity.Name, cf.Commodity.CategoryID)
+		}
+	}
+
+	for _, uf := range a.Users {
+		if uf.Addresses[0].IsUserDefault != enum.AddressIsUserDefault {
+			t.Errorf("user %s first address should be default", uf.User.UserName)
+		}
+		for _, of := range uf.Orders {
+			var total int64
+			for _, item := range of.Order.Items {
+				total += item.Amount
+			}
+			if total != of.Order.TotalAmount || len(of.Skus) != len(of.Order.Items) {
+				t.Errorf("order %s total %d, items sum %d", of.Order.OrderNo, of.Order.TotalAmount, total)
+			}
+			paid := of.Order.State != enum.OrderStateCreated && of.Order.State != enum.OrderStateClosed
+			if paid != (of.Payment != nil) {
+				t.Errorf("order %s state %d, payment %v", of.Order.OrderNo, of.Order.State, of.Payment)
+			}
+		}
+	}
+}
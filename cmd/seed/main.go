@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/dal/tx"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+	"github.com/kackerx/go-mall/resources"
+)
+
+const batchSize = 200
+
+func main() {
+	configPath := flag.String("config", config.DefaultPath(), "配置文件路径, 只能指向dev或test环境")
+	scale := flag.Int("scale", 1, fmt.Sprintf("数据量倍数, 每倍%d个用户、%d个店铺、%d个商品", usersPerScale, storesPerScale, commoditiesPerScale))
+	seed := flag.Int64("seed", 1, "随机种子, 种子相同生成的数据相同")
+	password := flag.String("password", "Seed@123456", "所有种子用户的登录密码")
+	flag.Parse()
+
+	if err := run(*configPath, *scale, *seed, *password); err != nil {
+		fmt.Fprintln(os.Stderr, "seed:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, scale int, seed int64, password string) error {
+	if scale <= 0 {
+		return errors.New("--scale must be positive")
+	}
+	conf, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	// 配置文件和GOMALL_APP_ENV环境变量都可能把环境指到生产, 以合并后的最终值为准
+	if conf.App.Env == enum.ModeProd {
+		return fmt.Errorf("refuse to seed %s environment", enum.ModeProd)
+	}
+
+	db, err := dao.NewDB(conf.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	var seeded int64
+	if err = db.Master(ctx).Model(&model.User{}).Where("user_name LIKE ?", seedUserPrefix+"%").Count(&seeded).Error; err != nil {
+		return err
+	}
+	if seeded > 0 {
+		// 用户名固定为seed_00001..., 重复执行会撞唯一索引, 需要先清库
+		return fmt.Errorf("database already has %d seed users, reset it before seeding again", seeded)
+	}
+
+	categories, err := loadCategories()
+	if err != nil {
+		return err
+	}
+	fixture := Generate(util.NewSeededRandom(seed), scale, categories, time.Now())
+
+	hashed, err := util.BcryptPassword(password)
+	if err != nil {
+		return err
+	}
+	for _, uf := range fixture.Users {
+		uf.User.Password = hashed
+	}
+
+	start := time.Now()
+	err = tx.NewManager(db).Do(ctx, func(ctx context.Context) error {
+		if err := domainservice.NewCommoditySvc(dao.NewCommodityDao(db)).InitCategory(ctx); err != nil {
+			return err
+		}
+		return save(ctx, db, fixture)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("seeded %d categories, %d stores, %d commodities, %d users in %s, password: %s\n",
+		len(categories), len(fixture.Stores), len(fixture.Commodities), len(fixture.Users),
+		time.Since(start).Round(time.Millisecond), password)
+	return nil
+}
+
+func loadCategories() ([]*do.CommodityCategory, error) {
+	reader, err := resources.LoadResourceFile("category.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []*do.CommodityCategory
+	if err = json.NewDecoder(reader).Decode(&categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// save 按外键依赖顺序批量写入, 每一步写完后用自增ID回填下一步的外键
+func save(ctx context.Context, db *dao.DB, f *Fixture) error {
+	conn := db.Master(ctx)
+	if err := conn.CreateInBatches(f.Stores, batchSize).Error; err != nil {
+		return fmt.Errorf("create stores: %w", err)
+	}
+
+	commodities := make([]*model.Commodity, 0, len(f.Commodities))
+	for _, cf := range f.Commodities {
+		cf.Commodity.StoreID = cf.Store.ID
+		commodities = append(commodities, cf.Commodity)
+	}
+	if err := conn.CreateInBatches(commodities, batchSize).Error; err != nil {
+		return fmt.Errorf("create commodities: %w", err)
+	}
+
+	var skus []*model.CommoditySku
+	for _, cf := range f.Commodities {
+		for _, sku := range cf.Skus {
+			sku.CommodityID = cf.Commodity.ID
+			skus = append(skus, sku)
+		}
+	}
+	if err := conn.CreateInBatches(skus, batchSize).Error; err != nil {
+		return fmt.Errorf("create skus: %w", err)
+	}
+
+	users := make([]*model.User, 0, len(f.Users))
+	for _, uf := range f.Users {
+		users = append(users, uf.User)
+	}
+	if err := conn.CreateInBatches(users, batchSize).Error; err != nil {
+		return fmt.Errorf("create users: %w", err)
+	}
+
+	var addresses []*model.UserAddress
+	var orders []*model.Order
+	for _, uf := range f.Users {
+		for _, address := range uf.Addresses {
+			address.UserID = uf.User.ID
+			addresses = append(addresses, address)
+		}
+		for _, of := range uf.Orders {
+			of.Order.UserID = uf.User.ID
+			for i, item := range of.Order.Items {
+				item.CommodityID = of.Skus[i].CommodityID
+				item.SkuID = of.Skus[i].ID
+			}
+			orders = append(orders, of.Order)
+		}
+	}
+	if err := conn.CreateInBatches(addresses, batchSize).Error; err != nil {
+		return fmt.Errorf("create addresses: %w", err)
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+	// 订单明细作为关联随订单一起写入
+	if err := conn.CreateInBatches(orders, batchSize).Error; err != nil {
+		return fmt.Errorf("create orders: %w", err)
+	}
+
+	var payments []*model.Payment
+	for _, uf := range f.Users {
+		for _, of := range uf.Orders {
+			if of.Payment != nil {
+				of.Payment.OrderID = of.Order.ID
+				of.Payment.UserID = of.Order.UserID
+				payments = append(payments, of.Payment)
+			}
+		}
+	}
+	if len(payments) == 0 {
+		return nil
+	}
+	if err := conn.CreateInBatches(payments, batchSize).Error; err != nil {
+		return fmt.Errorf("create payments: %w", err)
+	}
+
+	return nil
+}
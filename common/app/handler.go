@@ -0,0 +1,40 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerFunc 业务接口只返回响应数据和错误, 由Wrap统一写响应
+type HandlerFunc func(c *gin.Context) (any, error)
+
+// Paged 带分页信息的响应数据
+type Paged struct {
+	Data       any
+	Pagination *Pagination
+}
+
+// WithPagination 接口需要在响应里返回分页信息时, 用它包一下返回的数据
+func WithPagination(data any, pagination *Pagination) *Paged {
+	return &Paged{Data: data, Pagination: pagination}
+}
+
+// Wrap 把HandlerFunc转成gin的处理函数: 有错误时走Fail, 否则把返回的数据作为成功响应.
+// fn自己已经写过响应(如重定向)时不再重复写
+func Wrap(fn HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := fn(c)
+		if err != nil {
+			NewResponse(c).Fail(err)
+			return
+		}
+		if c.Writer.Written() {
+			return
+		}
+
+		if paged, ok := data.(*Paged); ok {
+			NewResponse(c).SetPagination(paged.Pagination).Success(paged.Data)
+			return
+		}
+		NewResponse(c).Success(data)
+	}
+}
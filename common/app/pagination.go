@@ -1,17 +1,51 @@
 package app
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
 	"github.com/kackerx/go-mall/config"
 )
 
+var defaultPagination = &config.Pagination{DefaultSize: 20, MaxSize: 100}
+
+// paginationOption 配置文件里的分页参数, 没有配置时为nil, 用defaultPagination
+var paginationOption atomic.Pointer[config.Pagination]
+
+// SetPaginationOption 启动和配置热更新时调用, opt为nil时恢复默认值
+func SetPaginationOption(opt *config.Pagination) {
+	if opt == nil {
+		paginationOption.Store(nil)
+		return
+	}
+
+	o := *opt
+	paginationOption.Store(&o)
+}
+
+func loadPaginationOption() *config.Pagination {
+	if opt := paginationOption.Load(); opt != nil {
+		return opt
+	}
+	return defaultPagination
+}
+
+// Pagination 分页参数和结果. 按页码翻页时返回total; 按游标翻页时不统计总数, 返回next_cursor, 为空说明没有下一页
 type Pagination struct {
-	Page     int `json:"page,omitempty"`
-	PageSize int `json:"page_size,omitempty"`
-	Total    int `json:"total,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size,omitempty"`
+	Total      int    `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	keyset bool
+	after  *util.Cursor
+	scope  string
 }
 
 func NewPagination(c *gin.Context) *Pagination {
@@ -20,7 +54,7 @@ func NewPagination(c *gin.Context) *Pagination {
 		page = 1
 	}
 
-	cnf := config.Conf.App.Pagination
+	cnf := loadPaginationOption()
 	pageSize, _ := strconv.Atoi(c.Query("page_size"))
 	if pageSize > cnf.MaxSize {
 		pageSize = cnf.MaxSize
@@ -30,7 +64,40 @@ func NewPagination(c *gin.Context) *Pagination {
 		pageSize = cnf.DefaultSize
 	}
 
-	return &Pagination{page, pageSize, 0}
+	return &Pagination{Page: page, PageSize: pageSize}
+}
+
+// ParsePagination 请求带了cursor参数时按游标翻页, 第一页传空值, 之后传上一页返回的next_cursor;
+// 没带时和NewPagination一样按page/page_size翻页. 游标被篡改、已失效或换了接口和筛选条件时返回ErrParams
+func ParsePagination(c *gin.Context) (*Pagination, error) {
+	p := NewPagination(c)
+	cursor, ok := c.GetQuery("cursor")
+	if !ok {
+		return p, nil
+	}
+
+	p.Page = 0
+	p.keyset = true
+	p.scope = cursorScope(c)
+	if cursor != "" {
+		after, err := util.DecodeCursor(cursor, p.scope)
+		if err != nil {
+			return nil, errcode.ErrParams.WithCause(err)
+		}
+		p.after = after
+	}
+
+	return p, nil
+}
+
+// cursorScope 游标绑定的列表: 请求路径(含路径参数)、当前用户和除分页参数外的query参数
+func cursorScope(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	query.Del("cursor")
+	query.Del("page")
+	query.Del("page_size")
+	sum := sha256.Sum256([]byte(c.Request.URL.Path + "?" + query.Encode() + "#" + strconv.FormatInt(c.GetInt64("user_id"), 10)))
+	return hex.EncodeToString(sum[:8])
 }
 
 func (p *Pagination) SetTotal(total int) *Pagination {
@@ -47,5 +114,26 @@ func (p *Pagination) GetPageSize() int {
 }
 
 func (p *Pagination) Offset() int {
+	if p.keyset {
+		return 0
+	}
 	return (p.Page - 1) * p.PageSize
 }
+
+// IsKeyset 是否按游标翻页
+func (p *Pagination) IsKeyset() bool {
+	return p.keyset
+}
+
+// After 上一页最后一行的游标, 游标模式的第一页为nil
+func (p *Pagination) After() *util.Cursor {
+	return p.after
+}
+
+// SetNextCursor 游标模式下设置下一页的游标, cursor为nil说明已经是最后一页
+func (p *Pagination) SetNextCursor(cursor *util.Cursor) *Pagination {
+	if cursor != nil {
+		p.NextCursor = util.EncodeCursor(cursor, p.scope)
+	}
+	return p
+}
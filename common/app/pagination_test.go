@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+)
+
+func TestParsePagination(t *testing.T) {
+	parse := func(query string) (*Pagination, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/list?"+query, nil)
+		return ParsePagination(c)
+	}
+
+	p, _ := parse("page=3&page_size=10")
+	if p.IsKeyset() || p.Offset() != 20 {
+		t.Errorf("offset mode: keyset=%v offset=%d", p.IsKeyset(), p.Offset())
+	}
+
+	// 游标模式第一页
+	p, _ = parse("cursor=&page_size=10")
+	if !p.IsKeyset() || p.After() != nil || p.Page != 0 {
+		t.Errorf("first keyset page: %+v", p)
+	}
+
+	p.SetNextCursor(&util.Cursor{ID: 42})
+	p, err := parse("cursor=" + p.NextCursor)
+	if err != nil || p.After() == nil || p.After().ID != 42 {
+		t.Fatalf("next keyset page: %+v, err=%v", p, err)
+	}
+
+	// 换了筛选条件, 之前的游标失效
+	p.SetNextCursor(&util.Cursor{ID: 50})
+	if _, err = parse("rating=5&cursor=" + p.NextCursor); errcode.FromError(err).Code() != errcode.ErrParams.Code() {
+		t.Errorf("cursor reused with other filters err = %v, want ErrParams", err)
+	}
+
+	if _, err = parse("cursor=forged"); errcode.FromError(err).Code() != errcode.ErrParams.Code() {
+		t.Errorf("forged cursor err = %v, want ErrParams", err)
+	}
+}
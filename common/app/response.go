@@ -1,17 +1,32 @@
 package app
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/i18n"
 	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/tracing"
 )
 
+// errcodeCtxKey 响应的业务错误码存在gin上下文里, 给指标中间件打标签用
+const errcodeCtxKey = "errcode"
+
+// ResponseCode 取本次请求响应的业务错误码, 没有经过NewResponse响应时返回0
+func ResponseCode(c *gin.Context) int {
+	return c.GetInt(errcodeCtxKey)
+}
+
 type response struct {
 	ctx        *gin.Context
 	Code       int         `json:"code,omitempty"`
 	Msg        string      `json:"msg,omitempty"`
 	RequestID  string      `json:"request_id,omitempty"`
+	Retryable  bool        `json:"retryable,omitempty"`
+	Details    any         `json:"details,omitempty"`
 	Data       any         `json:"data,omitempty"`
 	Pagination *Pagination `json:"pagination,omitempty"`
 }
@@ -19,11 +34,10 @@ type response struct {
 func (r *response) Success(data any) {
 	r.Code = errcode.Success.Code()
 	r.Msg = errcode.Success.Msg()
-	if v, ok := r.ctx.Get("traceid"); ok {
-		r.RequestID = v.(string)
-	}
+	r.RequestID = tracing.TraceID(r.ctx.Request.Context())
 
 	r.Data = data
+	r.ctx.Set(errcodeCtxKey, r.Code)
 	r.ctx.JSON(errcode.Success.HttpStatusCode(), r)
 }
 
@@ -31,16 +45,37 @@ func (r *response) SuccessOK() {
 	r.Success("")
 }
 
+// Error 响应预定义错误, 等同于Fail
 func (r *response) Error(err *errcode.AppError) {
-	r.Code = err.Code()
-	r.Msg = err.Msg()
-	if v, ok := r.ctx.Get("traceid"); ok {
-		r.RequestID = v.(string)
+	r.Fail(err)
+}
+
+// Fail 响应任意错误: 取错误链上最近的预定义错误返回给客户端, 没有时按ErrServer处理.
+// 根因只记日志不返回给客户端, 整个请求只在这里记一次错误日志
+func (r *response) Fail(err error) {
+	appErr := errcode.FromError(err)
+	lang := i18n.Match(r.ctx.GetHeader("Accept-Language"))
+	r.Code = appErr.Code()
+	r.Msg = appErr.Localize(lang)
+	r.RequestID = tracing.TraceID(r.ctx.Request.Context())
+	r.Retryable = appErr.Retryable()
+	r.Details = appErr.Details()
+	if r.Details == nil && errors.Is(appErr, errcode.ErrParams) {
+		// ShouldBind的校验错误作为根因挂在ErrParams上, 展开成逐字段的说明
+		if details := fieldErrors(appErr.Unwrap(), lang); len(details) > 0 {
+			r.Details = details
+		}
 	}
 
-	// 兜底错误响应日志
-	logger.New(r.ctx).Error("api_response_error", "error", err)
-	r.ctx.JSON(err.HttpStatusCode(), r)
+	r.ctx.Set(errcodeCtxKey, r.Code)
+	// 兜底错误响应日志, 客户端错误记warn, 服务端错误记error
+	status := appErr.HttpStatusCode()
+	if status >= http.StatusInternalServerError {
+		logger.New(r.ctx).Error("api_response_error", "code", r.Code, "error", err)
+	} else {
+		logger.New(r.ctx).Warn("api_response_error", "code", r.Code, "error", err)
+	}
+	r.ctx.JSON(status, r)
 }
 
 func (r *response) SetPagination(pagination *Pagination) *response {
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+func TestErrorValidationDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	type req struct {
+		UserName string `json:"user_name" binding:"required"`
+		Age      int    `json:"age" binding:"gte=18"`
+	}
+
+	engine := gin.New()
+	engine.POST("/", func(c *gin.Context) {
+		if err := c.ShouldBindJSON(new(req)); err != nil {
+			NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age": 3}`))
+	r.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var body struct {
+		Code    int           `json:"code"`
+		Msg     string        `json:"msg"`
+		Details []*FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != errcode.ErrParams.Code() || body.Msg != "Invalid parameters, please check" {
+		t.Errorf("code = %d, msg = %q", body.Code, body.Msg)
+	}
+	if len(body.Details) != 2 || body.Details[0].Field != "user_name" || body.Details[1].Message != "age must be greater than or equal to 18" {
+		t.Errorf("details = %s", w.Body.String())
+	}
+}
+
+func TestWrapFail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/known", Wrap(func(c *gin.Context) (any, error) {
+		return nil, errcode.Wrap("service err", errcode.ErrOrderNotFound.WithCause(errors.New("record not found")))
+	}))
+	engine.GET("/unknown", Wrap(func(c *gin.Context) (any, error) {
+		return nil, errors.New("dial tcp 10.0.0.1:3306: connection refused")
+	}))
+	engine.GET("/paged", Wrap(func(c *gin.Context) (any, error) {
+		return WithPagination([]int{1, 2}, &Pagination{Page: 1, PageSize: 2}), nil
+	}))
+
+	cases := []struct {
+		path     string
+		status   int
+		code     int
+		contains string
+	}{
+		{"/known", http.StatusNotFound, errcode.ErrOrderNotFound.Code(), errcode.ErrOrderNotFound.Msg()},
+		{"/unknown", http.StatusInternalServerError, errcode.ErrServer.Code(), errcode.ErrServer.Msg()},
+		{"/paged", http.StatusOK, 0, `"pagination":{`},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		var body struct {
+			Code int `json:"code"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != tc.status || body.Code != tc.code || !strings.Contains(w.Body.String(), tc.contains) {
+			t.Errorf("%s: status %d, body %s", tc.path, w.Code, w.Body.String())
+		}
+		// 根因只进日志, 不能出现在响应里
+		if strings.Contains(w.Body.String(), "record not found") || strings.Contains(w.Body.String(), "3306") {
+			t.Errorf("%s leaks internal cause: %s", tc.path, w.Body.String())
+		}
+	}
+}
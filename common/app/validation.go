@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/kackerx/go-mall/common/i18n"
+)
+
+// FieldError 一个字段的校验失败信息, Field是请求里的字段名(json/form/header标签), 不是Go结构体字段名
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// 让校验错误里的字段名使用请求中的名字, 客户端才能对应到自己提交的字段
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri", "header"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldErrors 把ShouldBind返回的错误转成逐字段的说明, 不是校验或者类型错误时返回nil
+func fieldErrors(err error, lang string) []*FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]*FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := fieldPath(fe.Namespace())
+			key := "validation." + fe.Tag()
+			if !i18n.Has(lang, key) {
+				key = "validation.default"
+			}
+			details = append(details, &FieldError{
+				Field:   field,
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: i18n.T(lang, key, map[string]string{"field": field, "param": fe.Param()}),
+			})
+		}
+		return details
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []*FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: i18n.T(lang, "validation.type", map[string]string{"field": typeErr.Field}),
+		}}
+	}
+
+	return nil
+}
+
+// fieldPath 去掉Namespace开头的结构体名, 如UserRegisterReq.body.user_name变成body.user_name
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+
+	return namespace
+}
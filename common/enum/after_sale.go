@@ -0,0 +1,33 @@
+package enum
+
+// 售后单状态, 状态之间的流转见do.AfterSale的状态机
+const (
+	AfterSaleStatePending      = 0 // 待审核
+	AfterSaleStateApproved     = 1 // 审核通过, 退款中
+	AfterSaleStateRefunded     = 2 // 已退款
+	AfterSaleStateRejected     = 3 // 审核拒绝
+	AfterSaleStateCancelled    = 4 // 用户撤销
+	AfterSaleStateRefundFailed = 5 // 网关退款失败, 可重新审核通过后重试
+)
+
+// 售后类型
+const (
+	AfterSaleTypeRefund       = 1 // 仅退款
+	AfterSaleTypeReturnRefund = 2 // 退货退款
+)
+
+// 售后操作日志的操作人类型
+const (
+	OperatorTypeUser   = "user"
+	OperatorTypeAdmin  = "admin"
+	OperatorTypeSystem = "system"
+)
+
+// 售后操作日志的动作
+const (
+	AfterSaleActionApply   = "apply"
+	AfterSaleActionCancel  = "cancel"
+	AfterSaleActionApprove = "approve"
+	AfterSaleActionReject  = "reject"
+	AfterSaleActionRefund  = "refund"
+)
@@ -0,0 +1,36 @@
+package enum
+
+import "time"
+
+// 优惠券类型
+const (
+	CouponTypeFixed     = 1 // 立减券, 无门槛直接减DiscountAmount
+	CouponTypePercent   = 2 // 折扣券, 按DiscountRate打折, MaxDiscount封顶
+	CouponTypeThreshold = 3 // 满减券, 适用商品原价满Threshold减DiscountAmount
+)
+
+// 优惠券适用范围
+const (
+	CouponScopeAll      = 0 // 全场通用
+	CouponScopeCategory = 1 // 指定类目及其所有子类目
+	CouponScopeSku      = 2 // 指定SKU
+)
+
+// 用户优惠券状态
+const (
+	UserCouponStateUnused = 0 // 未使用
+	UserCouponStateLocked = 1 // 下单锁定, 待订单支付
+	UserCouponStateUsed   = 2 // 已使用
+)
+
+// 优惠券可领取的用户分群
+const (
+	CouponSegmentAll     = ""         // 所有用户
+	CouponSegmentNewUser = "new_user" // 注册NewUserDuration内的新用户
+)
+
+const (
+	NewUserDuration      = 30 * 24 * time.Hour
+	MaxCouponsPerOrder   = 5  // 单笔订单最多叠加的优惠券数量
+	MaxCouponCombination = 12 // 计算最优组合时参与组合的候选券上限, 组合数为2^n
+)
@@ -0,0 +1,21 @@
+package enum
+
+import "time"
+
+// 收藏对象类型
+const (
+	FavoriteTargetCommodity = 1
+	FavoriteTargetStore     = 2
+)
+
+// 店铺营业状态
+const (
+	StoreClosed = 0
+	StoreOpen   = 1
+)
+
+const (
+	FavoriteCountTTL  = 24 * time.Hour      // 商品收藏数缓存的过期时间
+	BrowseHistoryMax  = 100                 // 每个用户最多保留的浏览记录数
+	BrowseHistoryKeep = 90 * 24 * time.Hour // 浏览记录的保留时长
+)
@@ -0,0 +1,17 @@
+package enum
+
+import "time"
+
+// 秒杀结果状态, 客户端抢购成功后轮询
+const (
+	FlashSaleResultQueued  = "queued"  // 已抢到, 排队创建订单中
+	FlashSaleResultSuccess = "success" // 订单已创建
+	FlashSaleResultFailed  = "failed"  // 创建订单失败, 库存已退回
+)
+
+const (
+	FlashSaleTokenTTL        = time.Minute    // 抢购令牌的有效期
+	FlashSaleResultTTL       = 24 * time.Hour // 秒杀结束后抢购结果的保留时间
+	FlashSaleQueuePopTimeout = 5 * time.Second
+	FlashSaleRequeueAfter    = time.Minute // 取出后超过这个时间还没处理完的抢购请求, 认为消费者已崩溃, 重新入队
+)
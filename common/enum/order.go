@@ -0,0 +1,23 @@
+package enum
+
+import "time"
+
+// 订单状态, 状态之间的流转见do.Order的状态机
+const (
+	OrderStateCreated   = 0 // 已创建, 待支付
+	OrderStatePaid      = 1 // 已支付, 待发货
+	OrderStateShipped   = 2 // 已发货
+	OrderStateCompleted = 3 // 已完成(确认收货)
+	OrderStateClosed    = 4 // 已关闭(取消/超时未支付/全额退款)
+)
+
+const (
+	OrderPayExpireDuration = 30 * time.Minute // 订单待支付的有效期
+	OrderCloseInterval     = time.Minute      // 扫描超时未支付订单的间隔
+	OrderCloseBatchSize    = 100              // 每次最多关闭的订单数
+)
+
+const (
+	CommodityUnpublished = 0
+	CommodityPublished   = 1 // 商品上架状态--已上架
+)
@@ -0,0 +1,8 @@
+package enum
+
+// outbox事件的投递状态
+const (
+	OutboxStatusPending   = 0 // 待投递, 包括投递失败等待重试的
+	OutboxStatusPublished = 1 // 已投递
+	OutboxStatusDead      = 2 // 超过最大重试次数, 需要人工处理
+)
@@ -0,0 +1,14 @@
+package enum
+
+// 支付单状态
+const (
+	PaymentStatusPending  = 0 // 待支付, 已在网关下单等待回调
+	PaymentStatusSuccess  = 1 // 支付成功
+	PaymentStatusFailed   = 2 // 支付失败
+	PaymentStatusRefunded = 3 // 支付成功但订单已无法支付(如已关闭或已被其他支付单支付), 已原路退回
+)
+
+// 支付渠道
+const (
+	PaymentChannelMock = "mock" // 进程内的模拟支付网关, 用于开发和测试环境
+)
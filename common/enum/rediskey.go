@@ -5,6 +5,10 @@ const (
 	RedisKeyDemoOrderDetail = "gomall:demo:order_detail_%s"
 )
 
+const (
+	RedisKeyIdempotency = "gomall:idempotency:%s" // 接口幂等记录, hash: fingerprint, status, code, content_type, body
+)
+
 const (
 	RedisKeyAccessToken        = "gomall:user:access_token_%s"
 	RedisKeyRefreshToken       = "gomall:user:refresh_token_%s"
@@ -12,3 +16,29 @@ const (
 	RediskeyTokenRefreshLock   = "gomall:user:token_refresh_lock_%s"
 	RediskeyPasswordresetToken = "gomall:user:password_reset_token_%s"
 )
+
+const (
+	RedisKeyCouponStock       = "gomall:coupon:stock_%d"           // 优惠券模板剩余库存
+	RedisKeyCouponUserClaimed = "gomall:coupon:user_claimed_%d_%d" // 用户已领取某模板的数量, 模板ID_用户ID
+)
+
+const (
+	RedisKeyFlashSaleInfo      = "gomall:flashsale:info_%d"          // 秒杀活动信息, 抢购时不再查库
+	RedisKeyFlashSaleStock     = "gomall:flashsale:stock_%d"         // 秒杀剩余库存
+	RedisKeyFlashSaleUsers     = "gomall:flashsale:users_%d"         // 已抢到的用户集合, 每人限抢一件
+	RedisKeyFlashSaleResult    = "gomall:flashsale:result_%d_%d"     // 抢购结果, 活动ID_用户ID
+	RedisKeyFlashSaleToken     = "gomall:flashsale:token_%d_%d"      // 抢购令牌, 活动ID_用户ID
+	RedisKeyFlashSaleRateLimit = "gomall:flashsale:rate_limit_%d_%d" // 用户每秒请求数, 用户ID_秒级时间戳
+	RedisKeyFlashSaleQueue     = "gomall:flashsale:queue"            // 待创建订单的抢购请求
+	RedisKeyFlashSaleRunning   = "gomall:flashsale:running"          // 消费者已取出、还没处理完的抢购请求
+	RedisKeyFlashSaleRunningAt = "gomall:flashsale:running_at"       // 抢购请求被取出的时间, 用于找出崩溃的消费者没处理完的请求
+)
+
+const (
+	RedisKeyReviewRatingStats = "gomall:review:rating_stats_%d" // 商品评分统计, hash: count, sum, r1~r5
+)
+
+const (
+	RedisKeyFavoriteCount = "gomall:favorite:commodity_count_%d" // 商品被收藏的次数
+	RedisKeyBrowseHistory = "gomall:history:user_%d"             // 用户浏览记录, zset: 商品ID -> 浏览时间戳(毫秒)
+)
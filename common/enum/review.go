@@ -0,0 +1,18 @@
+package enum
+
+import "time"
+
+// 评价审核状态
+const (
+	ReviewStatePending  = 0 // 待审核
+	ReviewStateApproved = 1 // 审核通过, 对外展示并计入评分统计
+	ReviewStateRejected = 2 // 审核不通过或被下架
+)
+
+const (
+	ReviewMaxImages = 9
+	ReviewMinRating = 1
+	ReviewMaxRating = 5
+
+	ReviewRatingStatsTTL = 24 * time.Hour // 评分统计缓存的过期时间, 重建和审核并发时的偏差最多保留这么久
+)
@@ -1,21 +1,93 @@
 package errcode
 
-var codes = map[int]struct{}{}
+import "net/http"
 
-// 预定义的错误是最终控制器层返回给请求的客户端的, 会封装统一的响应组件来处理
+// 预定义的错误是最终控制器层返回给请求的客户端的, 会封装统一的响应组件来处理.
+// 每个错误码声明HTTP状态码和文案key, 文案按Accept-Language从resources/i18n下的语言包取,
+// 新增错误码时两个语言包都要补上对应的key
 var (
-	Success            = newError(0, "success")
-	ErrServer          = newError(10000000, "服务器内部错误")
-	ErrParams          = newError(10000001, "参数错误, 请检查")
-	ErrNotFound        = newError(10000002, "资源未找到")
-	ErrPanic           = newError(10000003, "系统开小差了~ 请稍后重试")
-	ErrToken           = newError(10000004, "权限校验失败")
-	ErrForbidden       = newError(10000005, "未授权")
-	ErrTooManyRequests = newError(10000006, "请求过多")
+	Success                  = newError(0, http.StatusOK, "common.success")
+	ErrServer                = newError(10000000, http.StatusInternalServerError, "common.server", retryable)
+	ErrParams                = newError(10000001, http.StatusBadRequest, "common.params")
+	ErrNotFound              = newError(10000002, http.StatusNotFound, "common.not_found")
+	ErrPanic                 = newError(10000003, http.StatusInternalServerError, "common.panic", retryable)
+	ErrToken                 = newError(10000004, http.StatusUnauthorized, "common.token")
+	ErrForbidden             = newError(10000005, http.StatusForbidden, "common.forbidden")
+	ErrTooManyRequests       = newError(10000006, http.StatusTooManyRequests, "common.too_many_requests", retryable)
+	ErrIdempotencyInProgress = newError(10000007, http.StatusConflict, "common.idempotency_in_progress", retryable)
+	ErrIdempotencyKeyReused  = newError(10000008, http.StatusUnprocessableEntity, "common.idempotency_key_reused")
 )
 
 var (
-	ErrUserInvalid      = newError(10000101, "用户异常")
-	ErrUserNameOccupied = newError(10000102, "用户名已经占用")
-	ErrUserNotRight     = newError(10000103, "用户或密码不正确")
+	ErrUserInvalid      = newError(10000101, http.StatusForbidden, "user.invalid")
+	ErrUserNameOccupied = newError(10000102, http.StatusConflict, "user.name_occupied")
+	ErrUserNotRight     = newError(10000103, http.StatusUnauthorized, "user.not_right")
+)
+
+var (
+	ErrCommodityNotFound       = newError(10000201, http.StatusNotFound, "commodity.not_found")
+	ErrCommodityUnpublished    = newError(10000202, http.StatusConflict, "commodity.unpublished")
+	ErrCommodityStockNotEnough = newError(10000203, http.StatusConflict, "commodity.stock_not_enough")
+)
+
+var (
+	ErrOrderNotFound     = newError(10000301, http.StatusNotFound, "order.not_found")
+	ErrOrderStateInvalid = newError(10000302, http.StatusConflict, "order.state_invalid")
+	ErrOrderExpired      = newError(10000303, http.StatusConflict, "order.expired")
+)
+
+var (
+	ErrPaymentNotFound       = newError(10000401, http.StatusNotFound, "payment.not_found")
+	ErrPaymentChannel        = newError(10000402, http.StatusBadRequest, "payment.channel")
+	ErrPaymentKeyConflict    = newError(10000403, http.StatusConflict, "payment.key_conflict")
+	ErrPaymentGatewayTimeout = newError(10000404, http.StatusGatewayTimeout, "payment.gateway_timeout", retryable)
+	ErrPaymentSignature      = newError(10000405, http.StatusUnauthorized, "payment.signature")
+	ErrPaymentAmountMismatch = newError(10000406, http.StatusUnprocessableEntity, "payment.amount_mismatch")
+	ErrPaymentInProgress     = newError(10000407, http.StatusConflict, "payment.in_progress")
+)
+
+var (
+	ErrAfterSaleNotFound         = newError(10000501, http.StatusNotFound, "after_sale.not_found")
+	ErrAfterSaleStateInvalid     = newError(10000502, http.StatusConflict, "after_sale.state_invalid")
+	ErrAfterSaleOrderInvalid     = newError(10000503, http.StatusConflict, "after_sale.order_invalid")
+	ErrAfterSaleQuantityExceeded = newError(10000504, http.StatusUnprocessableEntity, "after_sale.quantity_exceeded")
+	ErrAfterSaleAmountExceeded   = newError(10000505, http.StatusUnprocessableEntity, "after_sale.amount_exceeded")
+	ErrAfterSaleRefundFailed     = newError(10000506, http.StatusBadGateway, "after_sale.refund_failed", retryable)
+)
+
+var (
+	ErrCouponNotFound      = newError(10000601, http.StatusNotFound, "coupon.not_found")
+	ErrCouponNotClaimable  = newError(10000602, http.StatusConflict, "coupon.not_claimable")
+	ErrCouponOutOfStock    = newError(10000603, http.StatusConflict, "coupon.out_of_stock")
+	ErrCouponClaimLimit    = newError(10000604, http.StatusConflict, "coupon.claim_limit")
+	ErrCouponUnavailable   = newError(10000605, http.StatusConflict, "coupon.unavailable")
+	ErrCouponNotApplicable = newError(10000606, http.StatusUnprocessableEntity, "coupon.not_applicable")
+	ErrCouponNotStackable  = newError(10000607, http.StatusUnprocessableEntity, "coupon.not_stackable")
+)
+
+var (
+	ErrFlashSaleNotFound       = newError(10000701, http.StatusNotFound, "flash_sale.not_found")
+	ErrFlashSaleNotStarted     = newError(10000702, http.StatusConflict, "flash_sale.not_started")
+	ErrFlashSaleEnded          = newError(10000703, http.StatusConflict, "flash_sale.ended")
+	ErrFlashSaleSoldOut        = newError(10000704, http.StatusConflict, "flash_sale.sold_out")
+	ErrFlashSaleAlreadyGrabbed = newError(10000705, http.StatusConflict, "flash_sale.already_grabbed")
+	ErrFlashSaleTokenInvalid   = newError(10000706, http.StatusForbidden, "flash_sale.token_invalid")
+	ErrFlashSaleTooFrequent    = newError(10000707, http.StatusTooManyRequests, "flash_sale.too_frequent", retryable)
+	ErrFlashSaleNotWarmed      = newError(10000708, http.StatusServiceUnavailable, "flash_sale.not_warmed", retryable)
+	ErrFlashSaleResultNotFound = newError(10000709, http.StatusNotFound, "flash_sale.result_not_found")
+	ErrFlashSaleOrderFailed    = newError(10000710, http.StatusInternalServerError, "flash_sale.order_failed")
+	ErrFlashSaleSettleNotReady = newError(10000711, http.StatusConflict, "flash_sale.settle_not_ready", retryable)
+)
+
+var (
+	ErrReviewNotFound      = newError(10000801, http.StatusNotFound, "review.not_found")
+	ErrReviewNotAllowed    = newError(10000802, http.StatusConflict, "review.not_allowed")
+	ErrReviewDuplicate     = newError(10000803, http.StatusConflict, "review.duplicate")
+	ErrReviewStateInvalid  = newError(10000804, http.StatusConflict, "review.state_invalid")
+	ErrReviewFollowUpLimit = newError(10000805, http.StatusConflict, "review.follow_up_limit")
+)
+
+var (
+	ErrFavoriteTargetNotFound = newError(10000901, http.StatusNotFound, "favorite.target_not_found")
+	ErrStoreNotFound          = newError(10000902, http.StatusNotFound, "store.not_found")
 )
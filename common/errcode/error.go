@@ -2,10 +2,13 @@ package errcode
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
 	"runtime"
+
+	"github.com/kackerx/go-mall/common/i18n"
 )
 
 /*
@@ -21,12 +24,28 @@ import (
  */
 
 type AppError struct {
-	code     int    `json:"code"`
-	msg      string `json:"msg"`
-	cause    error  `json:"cause"`    // 保存根因, 如数据库错误这种底层错误, 生成项目的AppError或者预定义的
-	occurred string `json:"occurred"` // 错误发生的位置
+	code      int
+	msg       string
+	cause     error     // 保存根因, 如数据库错误这种底层错误, 生成项目的AppError或者预定义的
+	occurred  string    // 错误发生的位置
+	status    int       // 响应的HTTP状态码
+	key       string    // 语言包里的文案key, Wrap出来的错误没有key, 文案就是msg
+	retryable bool      // 客户端原样重试是否可能成功
+	details   any       // 返回给客户端的补充信息, 如参数校验失败的字段
+	stack     []uintptr // 开启调用栈采集时WithCause/Wrap处的完整调用栈, 见SetStackCapture
+}
+
+// errorOption 预定义错误的可选属性
+type errorOption func(e *AppError)
+
+// retryable 标记为可重试, 如超时、限流这类临时性错误
+func retryable(e *AppError) {
+	e.retryable = true
 }
 
+// catalog 所有预定义的错误, 按错误码索引
+var catalog = map[int]*AppError{}
+
 func (e *AppError) String() string {
 	return e.Error()
 }
@@ -55,15 +74,24 @@ func (e *AppError) Error() string {
 	return string(errByte)
 }
 
-func newError(code int, msg string) *AppError {
-	if code > -1 {
-		if _, ok := codes[code]; ok {
-			panic(fmt.Sprintf("预定义错误码不能重复: %d", code))
-		}
+func newError(code, status int, key string, opts ...errorOption) *AppError {
+	if _, ok := catalog[code]; ok {
+		panic(fmt.Sprintf("预定义错误码不能重复: %d", code))
+	}
+
+	e := &AppError{code: code, status: status, key: key}
+	e.msg = e.Localize(i18n.Default)
+	for _, opt := range opts {
+		opt(e)
 	}
+	catalog[code] = e
+	return e
+}
 
-	codes[code] = struct{}{}
-	return &AppError{code: code, msg: msg, cause: nil}
+// Lookup 按错误码查找预定义错误
+func Lookup(code int) (*AppError, bool) {
+	e, ok := catalog[code]
+	return e, ok
 }
 
 func (e *AppError) Code() int {
@@ -74,25 +102,36 @@ func (e *AppError) Msg() string {
 	return e.msg
 }
 
+// Localize 按语言取面向客户端的文案, Wrap出来的错误没有文案key, 返回原始msg
+func (e *AppError) Localize(lang string) string {
+	if e.key == "" {
+		return e.msg
+	}
+
+	return i18n.T(lang, e.key, nil)
+}
+
 func (e *AppError) HttpStatusCode() int {
-	switch e.code {
-	case Success.code:
-		return http.StatusOK
-	case ErrServer.code, ErrPanic.code:
-		return http.StatusInternalServerError
-	case ErrParams.code:
-		return http.StatusBadRequest
-	case ErrNotFound.code:
-		return http.StatusNotFound
-	case ErrTooManyRequests.code:
-		return http.StatusTooManyRequests
-	case ErrToken.code:
-		return http.StatusUnauthorized
-	case ErrForbidden.code:
-		return http.StatusForbidden
-	default:
+	if e.status == 0 {
 		return http.StatusInternalServerError
 	}
+
+	return e.status
+}
+
+func (e *AppError) Retryable() bool {
+	return e.retryable
+}
+
+func (e *AppError) Details() any {
+	return e.details
+}
+
+// WithDetails 附加返回给客户端的补充信息, 不改变预定义错误本身
+func (e *AppError) WithDetails(details any) *AppError {
+	newErr := e.Clone()
+	newErr.details = details
+	return newErr
 }
 
 // WithCause AppError添加根因
@@ -100,11 +139,13 @@ func (e *AppError) WithCause(err error) *AppError {
 	newErr := e.Clone()
 	newErr.cause = err
 	newErr.occurred = getAppErrOccurredInfo()
+	newErr.stack = callers()
 	return newErr
 }
 
 func (e *AppError) Clone() *AppError {
-	return &AppError{e.code, e.msg, e.cause, e.occurred}
+	newErr := *e
+	return &newErr
 }
 
 // Wrap 底层错误包装成应用层错误, 当调用第三方组件不确定是什么err时Wrap一下, 返回500
@@ -113,7 +154,7 @@ func Wrap(msg string, err error) *AppError {
 		return nil
 	}
 
-	return &AppError{-1, msg, err, getAppErrOccurredInfo()}
+	return &AppError{code: -1, msg: msg, cause: err, occurred: getAppErrOccurredInfo(), stack: callers()}
 }
 
 func getAppErrOccurredInfo() string {
@@ -128,7 +169,20 @@ func getAppErrOccurredInfo() string {
 	return fmt.Sprintf("func: %s, file: %s, line: %d", funcName, file, line)
 }
 
-func (e *AppError) UnWrap() error {
+// FromError 错误链上最近的预定义错误, Wrap出来的内部错误会跳过; 链上没有预定义错误或err为nil时返回ErrServer
+func FromError(err error) *AppError {
+	for err != nil {
+		if appErr, ok := err.(*AppError); ok && appErr.code >= 0 {
+			return appErr
+		}
+		err = errors.Unwrap(err)
+	}
+
+	return ErrServer
+}
+
+// Unwrap 返回根因, errors.Is/As会沿着根因链查找
+func (e *AppError) Unwrap() error {
 	return e.cause
 }
 
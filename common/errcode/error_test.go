@@ -0,0 +1,50 @@
+package errcode
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kackerx/go-mall/common/i18n"
+)
+
+func TestCatalogMessages(t *testing.T) {
+	for code, e := range catalog {
+		for _, lang := range []string{i18n.ZhCN, i18n.En} {
+			if !i18n.Has(lang, e.key) {
+				t.Errorf("code %d: %s bundle missing key %s", code, lang, e.key)
+			}
+		}
+		if e.HttpStatusCode() < 200 || e.HttpStatusCode() > 599 {
+			t.Errorf("code %d: bad http status %d", code, e.HttpStatusCode())
+		}
+	}
+}
+
+func TestAppErrorLocalize(t *testing.T) {
+	if got := ErrUserNameOccupied.Localize(i18n.En); got != "User name is already taken" {
+		t.Errorf("en = %q", got)
+	}
+	if ErrUserNameOccupied.Msg() != ErrUserNameOccupied.Localize(i18n.ZhCN) {
+		t.Error("Msg should be the default language message")
+	}
+	if ErrUserNameOccupied.HttpStatusCode() != http.StatusConflict {
+		t.Errorf("status = %d", ErrUserNameOccupied.HttpStatusCode())
+	}
+
+	wrapped := Wrap("dao err", errors.New("boom"))
+	if wrapped.Localize(i18n.En) != "dao err" || wrapped.HttpStatusCode() != http.StatusInternalServerError {
+		t.Errorf("wrapped = %q, %d", wrapped.Localize(i18n.En), wrapped.HttpStatusCode())
+	}
+
+	withDetails := ErrParams.WithDetails([]string{"x"})
+	if ErrParams.Details() != nil || withDetails.Details() == nil || !errors.Is(withDetails, ErrParams) {
+		t.Error("WithDetails should copy the predefined error")
+	}
+	if !ErrPaymentGatewayTimeout.Retryable() || ErrParams.Retryable() {
+		t.Error("retryable flag not applied")
+	}
+	if e, ok := Lookup(ErrOrderNotFound.Code()); !ok || e != ErrOrderNotFound {
+		t.Error("Lookup should find predefined error")
+	}
+}
@@ -0,0 +1,153 @@
+package errcode
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const maxStackDepth = 32
+
+var captureStack atomic.Bool
+
+// SetStackCapture 开启后WithCause和Wrap会额外记录完整调用栈, 每次创建错误都有开销, 默认关闭, 排查问题时打开
+func SetStackCapture(enabled bool) {
+	captureStack.Store(enabled)
+}
+
+// callers 跳过runtime.Callers、callers本身和WithCause/Wrap, 从业务调用处开始记录
+func callers() []uintptr {
+	if !captureStack.Load() {
+		return nil
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+// StackTrace 调用栈, 每帧格式为"函数名 文件:行号", 没开启采集时为空
+func (e *AppError) StackTrace() []string {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	trace := make([]string, 0, len(e.stack))
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		trace = append(trace, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+
+	return trace
+}
+
+// Format %s和%v输出Error(), %+v逐层输出错误链, 最后是最内层AppError的调用栈
+func (e *AppError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			e.writeVerbose(s)
+			return
+		}
+		io.WriteString(s, e.Error())
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+func (e *AppError) writeVerbose(w io.Writer) {
+	var stack []string
+	var err error = e
+	for i := 0; err != nil; i++ {
+		if i > 0 {
+			io.WriteString(w, "\ncaused by: ")
+		}
+
+		appErr, ok := err.(*AppError)
+		if !ok {
+			// 非AppError的Error()已经包含它内部的链, 不再展开
+			io.WriteString(w, err.Error())
+			break
+		}
+		fmt.Fprintf(w, "[%d] %s", appErr.code, appErr.msg)
+		if appErr.occurred != "" {
+			fmt.Fprintf(w, "\n    at %s", appErr.occurred)
+		}
+		if trace := appErr.StackTrace(); len(trace) > 0 {
+			stack = trace
+		}
+		err = appErr.cause
+	}
+
+	if len(stack) > 0 {
+		io.WriteString(w, "\nstack:\n    ")
+		io.WriteString(w, strings.Join(stack, "\n    "))
+	}
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler, 日志里错误码、文案、根因链和调用栈是独立的字段
+func (e *AppError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if e == nil {
+		return nil
+	}
+
+	enc.AddInt("code", e.code)
+	enc.AddString("msg", e.msg)
+	if e.occurred != "" {
+		enc.AddString("occurred", e.occurred)
+	}
+	if e.cause != nil {
+		if err := enc.AddArray("causes", causeChain{e.cause}); err != nil {
+			return err
+		}
+	}
+	if trace := e.StackTrace(); len(trace) > 0 {
+		return enc.AddArray("stack", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, frame := range trace {
+				arr.AppendString(frame)
+			}
+			return nil
+		}))
+	}
+
+	return nil
+}
+
+// causeChain 根因链, AppError输出为对象, 其他错误输出为字符串
+type causeChain struct {
+	err error
+}
+
+func (c causeChain) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for err := c.err; err != nil; {
+		appErr, ok := err.(*AppError)
+		if !ok {
+			arr.AppendString(err.Error())
+			return nil
+		}
+
+		if err := arr.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddInt("code", appErr.code)
+			enc.AddString("msg", appErr.msg)
+			if appErr.occurred != "" {
+				enc.AddString("occurred", appErr.occurred)
+			}
+			return nil
+		})); err != nil {
+			return err
+		}
+		err = appErr.cause
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestUnwrapChain(t *testing.T) {
+	root := fmt.Errorf("open config: %w", fs.ErrNotExist)
+	err := ErrServer.WithCause(Wrap("load failed", ErrNotFound.WithCause(root)))
+
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is should walk the cause chain")
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		t.Error("errors.As matched a type not in the chain")
+	}
+	var appErr *AppError
+	if !errors.As(Wrap("outer", err), &appErr) || appErr.Code() != -1 {
+		t.Errorf("errors.As = %v", appErr)
+	}
+}
+
+func TestFormatVerbose(t *testing.T) {
+	SetStackCapture(true)
+	defer SetStackCapture(false)
+
+	err := ErrServer.WithCause(Wrap("dao err", errors.New("connection refused")))
+	out := fmt.Sprintf("%+v", err)
+	for _, want := range []string{"[10000000]", "caused by: [-1] dao err", "caused by: connection refused", "stack:", "TestFormatVerbose"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%%+v missing %q:\n%s", want, out)
+		}
+	}
+	if fmt.Sprintf("%v", err) != err.Error() {
+		t.Errorf("plain %%v should print Error()")
+	}
+}
+
+func TestStackCaptureDisabled(t *testing.T) {
+	SetStackCapture(false)
+	if trace := Wrap("x", errors.New("y")).StackTrace(); trace != nil {
+		t.Errorf("stack captured while disabled: %v", trace)
+	}
+}
+
+func TestMarshalLogObject(t *testing.T) {
+	SetStackCapture(true)
+	defer SetStackCapture(false)
+
+	enc := zapcore.NewMapObjectEncoder()
+	err := ErrOrderNotFound.WithCause(Wrap("query order", errors.New("record not found")))
+	if e := err.MarshalLogObject(enc); e != nil {
+		t.Fatal(e)
+	}
+
+	if enc.Fields["code"] != ErrOrderNotFound.Code() || enc.Fields["msg"] != ErrOrderNotFound.Msg() {
+		t.Errorf("fields = %v", enc.Fields)
+	}
+	causes, _ := enc.Fields["causes"].([]any)
+	if len(causes) != 2 || causes[1] != "record not found" {
+		t.Fatalf("causes = %#v", enc.Fields["causes"])
+	}
+	if inner, _ := causes[0].(map[string]any); inner["msg"] != "query order" {
+		t.Errorf("first cause = %#v", causes[0])
+	}
+	if stack, _ := enc.Fields["stack"].([]any); len(stack) == 0 {
+		t.Error("stack field missing")
+	}
+}
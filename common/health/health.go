@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	defaultCheckTimeout = 2 * time.Second
+)
+
+// CheckFunc 检查一个依赖是否可用, 返回nil表示正常
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+// ComponentResult 单个依赖的检查结果
+type ComponentResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Result 所有依赖的检查结果, 有一个依赖不可用整体就是down
+type Result struct {
+	Status     string                      `json:"status"`
+	Components map[string]*ComponentResult `json:"components"`
+}
+
+// Registry 就绪检查项的注册表, 各模块在启动时注册自己依赖的组件
+type Registry struct {
+	mu     sync.RWMutex
+	checks []check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register 注册一个检查项, timeout<=0时使用默认的2秒
+func (r *Registry) Register(name string, timeout time.Duration, fn CheckFunc) {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, timeout: timeout, fn: fn})
+}
+
+// Check 并发执行所有检查项, 每项单独超时, 慢的依赖不会拖住其他检查
+func (r *Registry) Check(ctx context.Context) *Result {
+	r.mu.RLock()
+	checks := make([]check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]*ComponentResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	res := &Result{Status: StatusUp, Components: make(map[string]*ComponentResult, len(checks))}
+	for i, c := range checks {
+		res.Components[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			res.Status = StatusDown
+		}
+	}
+
+	return res
+}
+
+func runCheck(ctx context.Context, c check) *ComponentResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	begin := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		// 检查函数不响应ctx时也按超时返回
+		err = ctx.Err()
+	}
+
+	res := &ComponentResult{Status: StatusUp, LatencyMs: time.Since(begin).Milliseconds()}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+
+	return res
+}
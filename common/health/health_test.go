@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryCheck(t *testing.T) {
+	r := NewRegistry()
+	r.Register("ok", time.Second, func(ctx context.Context) error {
+		return nil
+	})
+	r.Register("fail", time.Second, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	r.Register("slow", 50*time.Millisecond, func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	begin := time.Now()
+	res := r.Check(context.Background())
+	if cost := time.Since(begin); cost > 500*time.Millisecond {
+		t.Fatalf("Check took %v, slow check should time out", cost)
+	}
+
+	if res.Status != StatusDown {
+		t.Fatalf("status = %s, want down", res.Status)
+	}
+	if c := res.Components["ok"]; c.Status != StatusUp {
+		t.Errorf("ok = %+v, want up", c)
+	}
+	if c := res.Components["fail"]; c.Status != StatusDown || c.Error != "connection refused" {
+		t.Errorf("fail = %+v", c)
+	}
+	if c := res.Components["slow"]; c.Status != StatusDown || c.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("slow = %+v", c)
+	}
+}
+
+func TestRegistryCheckAllUp(t *testing.T) {
+	r := NewRegistry()
+	r.Register("ok", 0, func(ctx context.Context) error {
+		return nil
+	})
+
+	if res := r.Check(context.Background()); res.Status != StatusUp {
+		t.Fatalf("status = %s, want up", res.Status)
+	}
+}
@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/kackerx/go-mall/resources"
+)
+
+// 支持的语言, 语言包在resources/i18n/<语言>.json
+const (
+	ZhCN = "zh-CN"
+	En   = "en"
+
+	// Default 请求没带Accept-Language或者都不支持时使用的语言
+	Default = ZhCN
+)
+
+var (
+	supported = []string{ZhCN, En} // 第一个是匹配不上时的兜底
+	matcher   = language.NewMatcher([]language.Tag{language.SimplifiedChinese, language.English})
+	bundles   = mustLoadBundles()
+)
+
+func mustLoadBundles() map[string]map[string]string {
+	bundles := make(map[string]map[string]string, len(supported))
+	for _, lang := range supported {
+		reader, err := resources.LoadResourceFile("i18n/" + lang + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: load bundle %s: %v", lang, err))
+		}
+		messages := make(map[string]string)
+		if err = json.NewDecoder(reader).Decode(&messages); err != nil {
+			panic(fmt.Sprintf("i18n: decode bundle %s: %v", lang, err))
+		}
+		bundles[lang] = messages
+	}
+
+	return bundles
+}
+
+// Match 按Accept-Language请求头选出最合适的支持语言, 如"en-US,en;q=0.9"选en
+func Match(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+
+	return supported[index]
+}
+
+// Has 语言包里是否有这个key
+func Has(lang, key string) bool {
+	_, ok := bundles[lang][key]
+	return ok
+}
+
+// T 取key在lang下的文案, 文案里的{name}用args替换. 找不到时依次回退到默认语言和key本身
+func T(lang, key string, args map[string]string) string {
+	msg, ok := bundles[lang][key]
+	if !ok {
+		if msg, ok = bundles[Default][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+
+	pairs := make([]string, 0, 2*len(args))
+	for name, value := range args {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := map[string]string{
+		"":                          ZhCN,
+		"en-US,en;q=0.9":            En,
+		"zh-CN,zh;q=0.9,en;q=0.8":   ZhCN,
+		"zh-TW":                     ZhCN,
+		"fr-FR,en;q=0.5":            En,
+		"ja-JP":                     ZhCN,
+		"not a language header;;;;": ZhCN,
+	}
+	for header, want := range cases {
+		if got := Match(header); got != want {
+			t.Errorf("Match(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(En, "validation.min", map[string]string{"field": "age", "param": "18"}); got != "age must be at least 18" {
+		t.Errorf("got %q", got)
+	}
+	if got := T("fr", "common.params", nil); got != bundles[Default]["common.params"] {
+		t.Errorf("unsupported lang should fall back to default, got %q", got)
+	}
+	if got := T(En, "no.such.key", nil); got != "no.such.key" {
+		t.Errorf("missing key should return key, got %q", got)
+	}
+}
+
+func TestBundlesHaveSameKeys(t *testing.T) {
+	for _, lang := range supported {
+		for key := range bundles[Default] {
+			if !Has(lang, key) {
+				t.Errorf("%s bundle missing key %s", lang, key)
+			}
+		}
+		for key := range bundles[lang] {
+			if !Has(Default, key) {
+				t.Errorf("%s bundle has extra key %s", lang, key)
+			}
+		}
+	}
+}
@@ -15,41 +15,26 @@ type Logger struct {
 	ctx     context.Context
 	traceID string
 	spanID  string
-	pspanID string
 	_logger *zap.Logger
 }
 
-// 从上下文获取trace信息的辅助函数
+// 从上下文的OTel span获取trace信息, 没有span时返回空字符串
 func getTraceInfo(ctx context.Context) (string, string) {
-	if span := trace.SpanFromContext(ctx); span != nil {
-		spanCtx := span.SpanContext()
-		return spanCtx.TraceID().String(),
-			spanCtx.SpanID().String()
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return "", ""
 	}
-	return "", ""
+
+	return spanCtx.TraceID().String(), spanCtx.SpanID().String()
 }
 
 func New(ctx context.Context) *Logger {
-	var (
-		traceID, spanID, pspanID string
-	)
-
-	// if ctx.Value("traceid") != nil {
-	// 	traceID = ctx.Value("traceid").(string)
-	// }
-	// if ctx.Value("spanid") != nil {
-	// 	spanID = ctx.Value("spanid").(string)
-	// }
-	traceID, spanID = getTraceInfo(ctx)
-	if ctx.Value("pspanid") != nil {
-		pspanID = ctx.Value("pspanid").(string)
-	}
+	traceID, spanID := getTraceInfo(ctx)
 
 	return &Logger{
 		ctx:     ctx,
 		traceID: traceID,
 		spanID:  spanID,
-		pspanID: pspanID,
 		_logger: _logger,
 	}
 }
@@ -60,7 +45,7 @@ func (l *Logger) log(lvl zapcore.Level, msg string, kv ...any) {
 	}
 
 	// kv增加trace信息
-	kv = append(kv, "traceid", l.traceID, "spanid", l.spanID, "pspanid", l.pspanID)
+	kv = append(kv, "traceid", l.traceID, "spanid", l.spanID)
 	// kv增加日志调用者信息
 	funcName, fileName, line := l.getLoggerCallerInfo()
 	kv = append(kv, "func", funcName, "file", fileName, "line", line)
@@ -12,50 +12,75 @@ import (
 	"github.com/kackerx/go-mall/config"
 )
 
-var _logger *zap.Logger
+// _logger 在SetDefault之前是Nop, 引入包不需要配置文件, 单测也不会往磁盘写日志
+var _logger = zap.NewNop()
 
-func init() {
+// _level 所有core共用的日志级别, 配置热更新时通过SetLevel调整, 不需要重建logger
+var _level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+// NewZap 按应用配置创建zap日志, 项目里按上下文打日志用New(ctx), 这里只负责底层的zap实例
+func NewZap(cfg *config.App) *zap.Logger {
 	encoderConfg := zap.NewProductionEncoderConfig()
 	encoderConfg.EncodeTime = zapcore.ISO8601TimeEncoder
 	encoder := zapcore.NewJSONEncoder(encoderConfg)
 
-	fileWriteSyncer := getFileLogWriter()
+	fileWriteSyncer := getFileLogWriter(cfg.Log)
 
+	level := cfg.Log.Level
 	var cores []zapcore.Core
-	switch config.Conf.App.Env {
-	// 测试环境和生产环境 --> 文件, info
+	switch cfg.Env {
+	// 测试环境和生产环境 --> 文件, 默认info
 	case enum.ModeTest, enum.ModeProd:
-		cores = append(cores, zapcore.NewCore(encoder, fileWriteSyncer, zapcore.InfoLevel))
-	// 开发环境 --> 控制台 & 文件, debug
+		if level == "" {
+			level = "info"
+		}
+		cores = append(cores, zapcore.NewCore(encoder, fileWriteSyncer, _level))
+	// 开发环境 --> 控制台 & 文件, 默认debug
 	case enum.ModeDev:
+		if level == "" {
+			level = "debug"
+		}
 		cores = append(
 			cores,
-			zapcore.NewCore(encoder, fileWriteSyncer, zap.DebugLevel),
-			zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), zap.DebugLevel),
+			zapcore.NewCore(encoder, fileWriteSyncer, _level),
+			zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), _level),
 		)
 	}
+	// 配置已经校验过级别取值, 这里不会失败
+	_ = SetLevel(level)
 
 	core := zapcore.NewTee(cores...)
-	_logger = zap.New(core)
+	return zap.New(core)
+}
+
+// SetDefault 替换New(ctx)使用的zap实例, 在main里完成配置加载后调用一次
+func SetDefault(l *zap.Logger) {
+	_logger = l
+}
+
+// SetLevel 运行时调整日志级别, 取值debug, info, warn, error
+func SetLevel(level string) error {
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	_level.SetLevel(l)
+	return nil
+}
+
+// Sync 退出前把缓冲的日志刷到磁盘
+func Sync() error {
+	return _logger.Sync()
 }
 
-func getFileLogWriter() zapcore.WriteSyncer {
+func getFileLogWriter(cfg *config.Log) zapcore.WriteSyncer {
 	lumberJackLogger := &lumberjack.Logger{
-		Filename:   config.Conf.App.Log.Path,
-		MaxSize:    config.Conf.App.Log.MaxSize,
-		MaxAge:     config.Conf.App.Log.MaxAge,
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSize,
+		MaxAge:     cfg.MaxAge,
 		MaxBackups: 0,
 		LocalTime:  true,
 		Compress:   false,
 	}
 	return zapcore.AddSync(lumberJackLogger)
 }
-
-func ZapTest() {
-	_logger.Info(
-		"hehe",
-		zap.Any("app", config.Conf.App),
-		zap.Any("db", config.Conf.DB),
-		zap.Any("data", "呵呵呵呵呵呵你是个 人才\n挺厉害的\n"),
-	)
-}
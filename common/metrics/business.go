@@ -0,0 +1,23 @@
+package metrics
+
+const (
+	ResultSuccess = "success"
+	ResultFail    = "fail"
+)
+
+// 业务计数器, 新模块需要时在这里用NewCounter追加
+var (
+	UserRegisters  = NewCounter("user_register_total", "用户注册次数", "result")
+	UserLogins     = NewCounter("user_login_total", "用户登录次数", "platform", "result")
+	OrdersCreated  = NewCounter("order_created_total", "创建订单数", "source")
+	PaymentsResult = NewCounter("payment_result_total", "支付结果回调数", "channel", "status")
+)
+
+// Result 按err是否为nil返回result标签的取值
+func Result(err error) string {
+	if err != nil {
+		return ResultFail
+	}
+
+	return ResultSuccess
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 毫秒级的接口和存储调用, 默认分桶对SQL和Redis来说太粗
+var latencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	httpRequests = NewCounter("http_requests_total", "HTTP请求数", "method", "route", "status", "code")
+	httpDuration = NewHistogram("http_request_duration_seconds", "HTTP请求耗时", latencyBuckets, "method", "route")
+
+	sqlDuration = NewHistogram("sql_duration_seconds", "SQL执行耗时", latencyBuckets, "operation")
+	sqlErrors   = NewCounter("sql_errors_total", "SQL执行出错次数", "operation")
+
+	redisDuration = NewHistogram("redis_command_duration_seconds", "Redis命令耗时", latencyBuckets, "command")
+	redisErrors   = NewCounter("redis_errors_total", "Redis命令出错次数, 不包括key不存在", "command")
+)
+
+// ObserveHTTP route是路由模板, 如/order/detail, 没匹配到路由时传空字符串; code是响应里的业务错误码
+func ObserveHTTP(method, route string, status, code int, dur time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status), strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(dur.Seconds())
+}
+
+// ObserveSQL 按语句类型统计, 不按表统计避免标签基数过大
+func ObserveSQL(sql string, dur time.Duration, err error) {
+	operation := SQLOperation(sql)
+	sqlDuration.WithLabelValues(operation).Observe(dur.Seconds())
+	if err != nil {
+		sqlErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func ObserveRedis(command string, dur time.Duration, err error) {
+	redisDuration.WithLabelValues(command).Observe(dur.Seconds())
+	if err != nil {
+		redisErrors.WithLabelValues(command).Inc()
+	}
+}
+
+// SQLOperation 取SQL的语句类型, 如select, insert
+func SQLOperation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexAny(sql, " \n\t("); i > 0 {
+		sql = sql[:i]
+	}
+
+	switch op := strings.ToLower(sql); op {
+	case "select", "insert", "update", "delete", "begin", "commit", "rollback", "savepoint":
+		return op
+	default:
+		return "other"
+	}
+}
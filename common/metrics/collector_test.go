@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSQLOperation(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM `users` WHERE id = 1":       "select",
+		"  INSERT INTO `orders` (`id`) VALUES (1)": "insert",
+		"update `orders` set state = 2":            "update",
+		"SAVEPOINT sp1":                            "savepoint",
+		"SHOW TABLES":                              "other",
+		"":                                         "other",
+	}
+	for sql, want := range cases {
+		if got := SQLOperation(sql); got != want {
+			t.Errorf("SQLOperation(%q) = %s, want %s", sql, got, want)
+		}
+	}
+}
+
+func TestNewCounterReturnsRegistered(t *testing.T) {
+	c1 := NewCounter("test_events_total", "test", "event")
+	c2 := NewCounter("test_events_total", "test", "event")
+	c1.WithLabelValues("a").Inc()
+	c2.WithLabelValues("a").Inc()
+
+	if got := testutil.ToFloat64(c1.WithLabelValues("a")); got != 2 {
+		t.Fatalf("counter = %v, want 2", got)
+	}
+}
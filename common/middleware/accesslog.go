@@ -0,0 +1,336 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/config"
+)
+
+const redactedValue = "***"
+
+// accessLogOption 由config.AccessLog编译而来, 配置热更新时整体替换
+type accessLogOption struct {
+	maxBodySize   int
+	redactFields  map[string]bool // 小写的字段名, 完整的JSON按结构打码
+	jsonFields    *regexp.Regexp  // "password": "xxx", 截断后解析不了的JSON用正则打码
+	formFields    *regexp.Regexp  // password=xxx
+	headers       []string
+	redactHeaders map[string]bool
+	sampleRate    float64
+	routes        map[string]float64
+}
+
+// defaultAccessLogOption 没有配置访问日志选项时使用
+var defaultAccessLogOption = newAccessLogOption(&config.AccessLog{
+	MaxBodySize:   4096,
+	RedactFields:  []string{"password", "password_confirm", "access_token", "refresh_token", "secret"},
+	Headers:       []string{"gomall-token"},
+	RedactHeaders: []string{"gomall-token", "authorization", "cookie"},
+	SampleRate:    1,
+})
+
+// accessLogOpt 没有配置时为nil, 用defaultAccessLogOption
+var accessLogOpt atomic.Pointer[accessLogOption]
+
+// SetAccessLogOption 启动和配置热更新时调用, conf为nil时恢复默认选项
+func SetAccessLogOption(conf *config.AccessLog) {
+	if conf == nil {
+		accessLogOpt.Store(nil)
+		return
+	}
+	accessLogOpt.Store(newAccessLogOption(conf))
+}
+
+func loadAccessLogOption() *accessLogOption {
+	if opt := accessLogOpt.Load(); opt != nil {
+		return opt
+	}
+	return defaultAccessLogOption
+}
+
+func newAccessLogOption(conf *config.AccessLog) *accessLogOption {
+	opt := &accessLogOption{
+		maxBodySize:   conf.MaxBodySize,
+		headers:       conf.Headers,
+		redactHeaders: make(map[string]bool, len(conf.RedactHeaders)),
+		sampleRate:    conf.SampleRate,
+		routes:        make(map[string]float64, len(conf.Routes)),
+	}
+	if len(conf.RedactFields) > 0 {
+		opt.redactFields = make(map[string]bool, len(conf.RedactFields))
+		names := make([]string, 0, len(conf.RedactFields))
+		for _, f := range conf.RedactFields {
+			opt.redactFields[strings.ToLower(f)] = true
+			names = append(names, regexp.QuoteMeta(f))
+		}
+		fields := strings.Join(names, "|")
+		// 值可以是字符串、数字、true/false/null、对象或数组. 走到正则的都是被截断的body, 值可能没有结尾的引号,
+		// 嵌套的对象和数组配不准括号, 从值开始一直打码到结尾
+		value := `"(?:[^"\\]|\\.)*(?:"|\\?$)|-?[0-9][0-9.eE+-]*|true|false|null|\{[^{}]*\}|\[[^\[\]]*\]|[{\[][\s\S]*`
+		opt.jsonFields = regexp.MustCompile(`(?i)("(?:` + fields + `)"\s*:\s*)(?:` + value + `)`)
+		opt.formFields = regexp.MustCompile(`(?i)((?:^|&)(?:` + fields + `)=)[^&]*`)
+	}
+	for _, h := range conf.RedactHeaders {
+		opt.redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, r := range conf.Routes {
+		opt.routes[r.Path] = r.SampleRate
+	}
+
+	return opt
+}
+
+// redactedHeaders 全部请求头, 打码的请求头只保留首尾各4位, 用于panic等需要完整请求的排查日志
+func (o *accessLogOption) redactedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for k, vs := range header {
+		v := strings.Join(vs, ", ")
+		if o.redactHeaders[http.CanonicalHeaderKey(k)] {
+			v = maskSecret(v)
+		}
+		headers[k] = v
+	}
+
+	return headers
+}
+
+func (o *accessLogOption) redact(s string) string {
+	if o.jsonFields == nil || s == "" {
+		return s
+	}
+	if redacted, ok := o.redactJSON(s); ok {
+		return redacted
+	}
+
+	s = o.jsonFields.ReplaceAllString(s, `${1}"`+redactedValue+`"`)
+	return o.formFields.ReplaceAllString(s, "${1}"+redactedValue)
+}
+
+// redactJSON 完整的JSON按结构把敏感字段的值整个换掉, 不管值是什么类型. 字段顺序不变, 空白被去掉.
+// 不是合法JSON时返回false
+func (o *accessLogOption) redactJSON(s string) (string, bool) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	buf := new(bytes.Buffer)
+	if err := o.redactJSONValue(dec, buf); err != nil {
+		return "", false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "", false
+	}
+
+	return buf.String(), true
+}
+
+func (o *accessLogOption) redactJSONValue(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		bs, _ := json.Marshal(tok)
+		buf.Write(bs)
+		return nil
+	}
+
+	buf.WriteRune(rune(delim))
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if delim == '[' {
+			if err = o.redactJSONValue(dec, buf); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		bs, _ := json.Marshal(key)
+		buf.Write(bs)
+		buf.WriteByte(':')
+		if !o.redactFields[strings.ToLower(key)] {
+			if err = o.redactJSONValue(dec, buf); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var skipped json.RawMessage
+		if err = dec.Decode(&skipped); err != nil {
+			return err
+		}
+		buf.WriteString(`"` + redactedValue + `"`)
+	}
+	if tok, err = dec.Token(); err != nil {
+		return err
+	}
+	buf.WriteRune(rune(tok.(json.Delim)))
+
+	return nil
+}
+
+// sampled 按路由的采样比例决定是否记录成功的请求, 路由没有单独配置时用默认比例
+func (o *accessLogOption) sampled(route string) bool {
+	rate, ok := o.routes[route]
+	if !ok {
+		rate = o.sampleRate
+	}
+
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// bodyLogWriter 响应先写入ResponseWriter, 同时留一份前limit个字节用于记录日志
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body  *bytes.Buffer
+	limit int
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	if remain := w.limit + 1 - w.body.Len(); remain > 0 {
+		w.body.Write(b[:min(len(b), remain)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// LogAccess 记录请求和响应, 敏感字段打码, body超长截断, 二进制和文件上传只记录类型和大小.
+// 没被采样的请求只在出错时记录access_end
+func LogAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opt := loadAccessLogOption()
+		sampled := opt.sampled(c.FullPath())
+
+		start := time.Now()
+		reqBody := readRequestBody(c.Request, opt)
+		blw := &bodyLogWriter{body: &bytes.Buffer{}, limit: opt.maxBodySize, ResponseWriter: c.Writer}
+		c.Writer = blw // 这里wrapper了一层, 让输出先写入到blw的body, 然后再让gin写入到自己的writer, 拿到响应
+		if sampled {
+			accessLog(c, opt, "access_start", time.Since(start), reqBody, nil)
+		}
+		defer func() {
+			if sampled || c.Writer.Status() >= http.StatusBadRequest {
+				accessLog(c, opt, "access_end", time.Since(start), reqBody, responseBody(blw, opt))
+			}
+		}()
+		c.Next()
+	}
+}
+
+func accessLog(c *gin.Context, opt *accessLogOption, accessType string, dur time.Duration, body string, out any) {
+	req := c.Request
+	args := []any{
+		"type", accessType,
+		"ip", c.ClientIP(),
+		"method", req.Method,
+		"path", req.URL.Path,
+		"query", opt.redact(req.URL.RawQuery),
+		"body", body,
+		"output", out,
+		"time", int64(dur / time.Millisecond),
+	}
+	for _, h := range opt.headers {
+		if v := req.Header.Get(h); v != "" {
+			if opt.redactHeaders[http.CanonicalHeaderKey(h)] {
+				v = maskSecret(v)
+			}
+			args = append(args, "header_"+strings.ToLower(h), v)
+		}
+	}
+	if userID := c.GetInt64("user_id"); userID > 0 {
+		args = append(args, "user_id", userID)
+	}
+
+	logger.New(c).Info("AccessLog", args...)
+}
+
+// readRequestBody 只读出前maxBodySize个字节用于记录, 再和剩余部分拼回去, 大body不会整个读进内存
+func readRequestBody(req *http.Request, opt *accessLogOption) string {
+	if opt.maxBodySize == 0 || req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	contentType := req.Header.Get("Content-Type")
+	if !isTextual(contentType) {
+		return fmt.Sprintf("[%s %d bytes]", contentType, req.ContentLength)
+	}
+
+	head, err := io.ReadAll(io.LimitReader(req.Body, int64(opt.maxBodySize)+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+	if err != nil {
+		return ""
+	}
+
+	return truncateBody(head, opt)
+}
+
+func responseBody(w *bodyLogWriter, opt *accessLogOption) string {
+	if opt.maxBodySize == 0 || w.Size() <= 0 {
+		return ""
+	}
+	if contentType := w.Header().Get("Content-Type"); !isTextual(contentType) {
+		return fmt.Sprintf("[%s %d bytes]", contentType, w.Size())
+	}
+
+	return truncateBody(w.body.Bytes(), opt)
+}
+
+// truncateBody 先截断再打码. 超长的body本来就只读了开头, 截断后的JSON不完整, 由redact的正则处理,
+// 截断处正好切断的敏感字段值也会打码
+func truncateBody(body []byte, opt *accessLogOption) string {
+	if len(body) <= opt.maxBodySize {
+		return opt.redact(string(body))
+	}
+
+	return opt.redact(string(body[:opt.maxBodySize])) + "...(truncated)"
+}
+
+// isTextual JSON、表单、XML和纯文本记录内容, 文件上传和其他二进制只记录类型和大小
+func isTextual(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/x-www-form-urlencoded":
+		return true
+	}
+
+	return false
+}
+
+// maskSecret 只保留首尾各4位, 能在日志里区分不同的token又不会泄露
+func maskSecret(s string) string {
+	if len(s) <= 8 {
+		return redactedValue
+	}
+
+	return s[:4] + redactedValue + s[len(s)-4:]
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/config"
+)
+
+func TestAccessLogRedact(t *testing.T) {
+	SetAccessLogOption(&config.AccessLog{MaxBodySize: 48, RedactFields: []string{"password", "access_token"}})
+	opt := loadAccessLogOption()
+
+	tests := []struct {
+		in, want string
+	}{
+		{`{"user_name":"kk","Password" : "p\"w"}`, `{"user_name":"kk","Password":"***"}`},
+		// 任意类型的值都要整个打码
+		{`{"password":12345678,"ok":true}`, `{"password":"***","ok":true}`},
+		{`{"data":[{"access_token":{"v":"abc","exp":1}}]}`, `{"data":[{"access_token":"***"}]}`},
+		// 截断处切断了值
+		{`{"user_name":"kkkkkkkkkkkkkkkkkkk","password":"1234`, `{"user_name":"kkkkkkkkkkkkkkkkkkk","password":"***"...(truncated)`},
+		{`{"user_name":"kkkkkkkkkkkkkkkkkkkk","password":1234`, `{"user_name":"kkkkkkkkkkkkkkkkkkkk","password":"***"...(truncated)`},
+		{`{"user_name":"k","password":{"a":{"b":"12345678"}}}x`, `{"user_name":"k","password":"***"...(truncated)`},
+		{`user_name=kk&password=123`, `user_name=kk&password=***`},
+	}
+	for _, tt := range tests {
+		if got := truncateBody([]byte(tt.in), opt); got != tt.want {
+			t.Errorf("truncateBody(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	if got := maskSecret("0123456789abcdef0123456789abcdef01234567"); got != "0123***4567" {
+		t.Errorf("maskSecret = %s", got)
+	}
+}
+
+func TestLogAccess(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger.SetDefault(zap.New(core))
+	defer logger.SetDefault(zap.NewNop())
+	SetAccessLogOption(&config.AccessLog{
+		MaxBodySize:   8,
+		RedactFields:  []string{"password"},
+		Headers:       []string{"gomall-token"},
+		RedactHeaders: []string{"gomall-token"},
+		SampleRate:    1,
+		Routes:        []*config.AccessLogRoute{{Path: "/quiet", SampleRate: 0}, {Path: "/quiet/fail", SampleRate: 0}},
+	})
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(LogAccess())
+	e.POST("/echo", func(c *gin.Context) {
+		// 日志只读了body的开头, 业务仍然要拿到完整body
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	e.POST("/upload", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	e.GET("/quiet", func(c *gin.Context) { c.Status(http.StatusOK) })
+	e.GET("/quiet/fail", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"a":"0123456789"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("gomall-token", "0123456789abcdef")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != `{"a":"0123456789"}` {
+		t.Fatalf("handler got body %s", w.Body.String())
+	}
+	end := logs.TakeAll()[1].ContextMap()
+	if end["body"] != `{"a":"01...(truncated)` || end["header_gomall-token"] != "0123***cdef" {
+		t.Errorf("access_end fields: %v", end)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("binary"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	if body := logs.TakeAll()[0].ContextMap()["body"]; body != "[multipart/form-data; boundary=x 6 bytes]" {
+		t.Errorf("multipart body logged as %v", body)
+	}
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/quiet", nil))
+	if n := logs.Len(); n != 0 {
+		t.Errorf("unsampled route logged %d entries", n)
+	}
+	// 没被采样但出错的请求仍然记录access_end
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/quiet/fail", nil))
+	if entries := logs.TakeAll(); len(entries) != 1 || entries[0].ContextMap()["type"] != "access_end" {
+		t.Errorf("failed unsampled request entries: %v", entries)
+	}
+}
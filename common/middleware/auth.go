@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,7 +13,17 @@ import (
 	"github.com/kackerx/go-mall/logic/do"
 )
 
-func AuthUser() gin.HandlerFunc {
+// Auth 登录和管理后台鉴权, 依赖token缓存和管理员名单
+type Auth struct {
+	cache        *cache.Cache
+	adminUserIDs []int64
+}
+
+func NewAuth(cache *cache.Cache, adminUserIDs []int64) *Auth {
+	return &Auth{cache: cache, adminUserIDs: adminUserIDs}
+}
+
+func (a *Auth) AuthUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.Request.Header.Get("gomall-token")
 		if len(token) != 40 {
@@ -21,7 +32,7 @@ func AuthUser() gin.HandlerFunc {
 			return
 		}
 
-		tokenVerify, err := VerifyAccessToken(c, token)
+		tokenVerify, err := a.VerifyAccessToken(c, token)
 		if err != nil || !tokenVerify.Approved {
 			app.NewResponse(c).Error(errcode.ErrToken)
 			c.Abort()
@@ -35,9 +46,22 @@ func AuthUser() gin.HandlerFunc {
 	}
 }
 
+// AuthAdmin 管理后台接口鉴权, 需要放在AuthUser之后
+func (a *Auth) AuthAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(a.adminUserIDs, c.GetInt64("user_id")) {
+			app.NewResponse(c).Error(errcode.ErrForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // VerifyAccessToken 校验token合法
-func VerifyAccessToken(ctx context.Context, accessToken string) (resp *do.TokenVerify, err error) {
-	tokenInfo, err := cache.GetAccessToken(ctx, accessToken)
+func (a *Auth) VerifyAccessToken(ctx context.Context, accessToken string) (resp *do.TokenVerify, err error) {
+	tokenInfo, err := a.cache.GetAccessToken(ctx, accessToken)
 	if err != nil {
 		logger.New(ctx).Error("GetAccessToken err", "err", err)
 		return nil, err
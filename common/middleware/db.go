@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/dal/dao"
+)
+
+// ReadYourWrites 请求内发生写操作后, 后续的读都走主库, 避免主从延迟读不到刚写入的数据
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(dao.WithReadYourWrites(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/dal/cache"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotency-Replayed" // 响应是之前保存的结果时为true
+
+	maxIdempotencyKeyLen   = 64
+	defaultIdempotencyTTL  = 24 * time.Hour
+	defaultIdempotencyLock = time.Minute
+)
+
+// idempotencyStore 幂等记录的存储, 由cache.Cache实现
+type idempotencyStore interface {
+	AcquireIdempotencyKey(ctx context.Context, key, fingerprint, token string, lockTTL time.Duration) (*cache.IdempotentRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, key, token string, record *cache.IdempotentRecord, ttl time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, key, token string) error
+}
+
+// Idempotency 接口幂等, 路由按需启用, 防止客户端超时重试导致下单、支付、注册执行两次
+type Idempotency struct {
+	store   idempotencyStore
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func NewIdempotency(cache *cache.Cache, conf *config.Idempotency) *Idempotency {
+	i := &Idempotency{store: cache, ttl: defaultIdempotencyTTL, lockTTL: defaultIdempotencyLock}
+	if conf != nil && conf.TTL > 0 {
+		i.ttl = time.Duration(conf.TTL) * time.Second
+	}
+	if conf != nil && conf.LockTTL > 0 {
+		i.lockTTL = time.Duration(conf.LockTTL) * time.Second
+	}
+
+	return i
+}
+
+// idempotentWriter 响应照常写给客户端, 同时留一份用于保存
+type idempotentWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotentWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotent 请求头带了Idempotency-Key时生效, 没带时按普通请求处理. 需要放在AuthUser之后, key按用户隔离,
+// 注册这类匿名接口按客户端IP隔离, 不同客户端碰巧用了同一个key也不会拿到别人的响应.
+// 同一个key: 处理中的重复请求返回ErrIdempotencyInProgress, 处理完成的直接重放保存的响应,
+// 请求内容不同返回ErrIdempotencyKeyReused. 响应是5xx或429时不保存, 客户端可以用同一个key重试
+func (i *Idempotency) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLen {
+			app.NewResponse(c).Error(errcode.ErrParams)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			app.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := KeyByUser(c) + ":" + c.Request.Method + ":" + c.FullPath() + ":" + idempotencyKey
+		fingerprint := requestFingerprint(c.Request, body)
+		// 处理超过lockTTL时key会被下一次重试占用, 用token保证只完成或释放自己占用的那次
+		token := uuid.NewString()
+		record, err := i.store.AcquireIdempotencyKey(c, key, fingerprint, token, i.lockTTL)
+		if err != nil {
+			// Redis不可用时放行, 支付等关键操作在数据库层还有唯一约束兜底
+			logger.New(c).Warn("idempotency unavailable", "key", idempotencyKey, "error", err)
+			c.Next()
+			return
+		}
+		if record != nil {
+			replayIdempotent(c, record, fingerprint)
+			return
+		}
+
+		w := &idempotentWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = w
+		finished := false
+		defer func() {
+			// 请求已经结束, 用不会被取消的ctx保存结果
+			ctx := context.WithoutCancel(c.Request.Context())
+			status := w.Status()
+			// 没有正常返回说明后面panic了, 这时还没写响应, 不能当成成功保存
+			if !finished || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+				_ = i.store.ReleaseIdempotencyKey(ctx, key, token)
+				return
+			}
+			_ = i.store.CompleteIdempotencyKey(ctx, key, token, &cache.IdempotentRecord{
+				Fingerprint: fingerprint,
+				HTTPStatus:  status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        w.body.Bytes(),
+			}, i.ttl)
+		}()
+		c.Next()
+		finished = true
+	}
+}
+
+func replayIdempotent(c *gin.Context, record *cache.IdempotentRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		app.NewResponse(c).Error(errcode.ErrIdempotencyKeyReused)
+	case record.Status != cache.IdempotencyCompleted:
+		app.NewResponse(c).Error(errcode.ErrIdempotencyInProgress)
+	default:
+		c.Header(HeaderIdempotencyReplayed, "true")
+		c.Data(record.HTTPStatus, record.ContentType, record.Body)
+	}
+	c.Abort()
+}
+
+// requestFingerprint 方法、路径、query和body相同才算同一个请求
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.Path + "?" + req.URL.RawQuery + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
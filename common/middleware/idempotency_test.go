@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/cache"
+)
+
+type memIdempotencyStore struct {
+	records map[string]*cache.IdempotentRecord
+}
+
+func (s *memIdempotencyStore) AcquireIdempotencyKey(_ context.Context, key, fingerprint, _ string, _ time.Duration) (*cache.IdempotentRecord, error) {
+	if record, ok := s.records[key]; ok {
+		return record, nil
+	}
+	s.records[key] = &cache.IdempotentRecord{Fingerprint: fingerprint, Status: cache.IdempotencyProcessing}
+	return nil, nil
+}
+
+func (s *memIdempotencyStore) CompleteIdempotencyKey(_ context.Context, key, _ string, record *cache.IdempotentRecord, _ time.Duration) error {
+	record.Status = cache.IdempotencyCompleted
+	s.records[key] = record
+	return nil
+}
+
+func (s *memIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key, _ string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	store := &memIdempotencyStore{records: make(map[string]*cache.IdempotentRecord)}
+	idem := &Idempotency{store: store, ttl: time.Hour, lockTTL: time.Minute}
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	calls := 0
+	e.POST("/order/create", idem.Idempotent(), app.Wrap(func(c *gin.Context) (any, error) {
+		calls++
+		if c.Query("fail") != "" {
+			return nil, errcode.ErrServer
+		}
+		return gin.H{"order_no": calls}, nil
+	}))
+
+	do := func(key, query, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/order/create"+query, strings.NewReader(body))
+		req.Header.Set(HeaderIdempotencyKey, key)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	first := do("k1", "", `{"sku_id":1}`)
+	replay := do("k1", "", `{"sku_id":1}`)
+	if calls != 1 || replay.Body.String() != first.Body.String() || replay.Header().Get(HeaderIdempotencyReplayed) != "true" {
+		t.Fatalf("want replay without executing again, calls=%d body=%s", calls, replay.Body.String())
+	}
+
+	if w := do("k1", "", `{"sku_id":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with different body: code=%d", w.Code)
+	}
+
+	// 处理中的重复请求
+	store.records["ip:192.0.2.1:POST:/order/create:k2"] = &cache.IdempotentRecord{
+		Fingerprint: requestFingerprint(httptest.NewRequest(http.MethodPost, "/order/create", nil), []byte(`{}`)),
+		Status:      cache.IdempotencyProcessing,
+	}
+	if w := do("k2", "", `{}`); w.Code != http.StatusConflict {
+		t.Errorf("in-flight duplicate: code=%d", w.Code)
+	}
+
+	// 5xx不保存, 同一个key可以重试
+	do("k3", "?fail=1", `{}`)
+	if _, ok := store.records["ip:192.0.2.1:POST:/order/create:k3"]; ok {
+		t.Error("5xx response should release the key")
+	}
+}
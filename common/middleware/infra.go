@@ -1,120 +1,58 @@
 package middleware
 
 import (
-	"bytes"
-	"io"
 	"net"
 	"net/http"
-	"net/http/httputil"
 	"os"
 	"runtime/debug"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/kackerx/go-mall/common/app"
 	"github.com/kackerx/go-mall/common/logger"
-	"github.com/kackerx/go-mall/common/util"
-)
-
-// 定义上下文键
-const (
-	TraceIDKey = "trace_id"
-	SpanIDKey  = "span_id"
-	TracerName = "gmall-service"
+	"github.com/kackerx/go-mall/common/tracing"
 )
 
+// StartTrace 从请求头的traceparent接上游链路, 没有时开启新链路, 为本次请求创建server span.
+// 响应头带回traceparent, 调用方可以用它关联日志
 func StartTrace() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		traceID := c.Request.Header.Get("traceid")
-		pSpanID := c.Request.Header.Get("spanid")
-		// 网关在调用其他服务完成业务逻辑时, 生成自己的spanID, 带上上一个服务的spanid作为pspanid
-		spanID := util.GenerateSpanID(c.Request.RemoteAddr)
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
 
-		if traceID == "" { // traceid为空证明是链路的起始, 设置为此次的spanID
-			traceID = spanID // trace标识整个请求链路, span表示链路中的不同服务
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
 		}
-
-		c.Set("traceid", traceID)
-		c.Set("spanid", spanID)
-		c.Set("pspanid", pSpanID)
-		c.Next()
-	}
-}
-
-// TracingMiddleware Gin的链路追踪中间件
-func TracingMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 获取tracer
-		tracer := otel.Tracer(TracerName)
-
-		// 创建span
-		ctx, span := tracer.Start(c.Request.Context(), c.Request.URL.Path)
+		ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("http.target", c.Request.URL.Path),
+				attribute.String("http.client_ip", c.ClientIP()),
+			),
+		)
 		defer span.End()
 
-		// 获取trace信息
-		traceID := span.SpanContext().TraceID().String()
-		spanID := span.SpanContext().SpanID().String()
-
-		// 将trace信息保存到gin上下文
-		c.Set(TraceIDKey, traceID)
-		c.Set(SpanIDKey, spanID)
-
-		// 将context保存到gin上下文
 		c.Request = c.Request.WithContext(ctx)
-
-		// 处理请求
+		propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
 		c.Next()
-	}
-}
 
-type bodyLogWriter struct {
-	gin.ResponseWriter
-	body *bytes.Buffer
-}
-
-func (w *bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
-}
-
-func LogAccess() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 保存body
-		reqBody, _ := io.ReadAll(c.Request.Body)
-		c.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
-
-		start := time.Now()
-		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
-		c.Writer = blw // 这里wrapper了一层, 让输出先写入到blw的body, 然后再让gin写入到自己的writer, 拿到响应
-		accessLog(c, "access_start", time.Since(start), reqBody, nil)
-		defer func() {
-			accessLog(c, "access_end", time.Since(start), reqBody, blw.body.String())
-		}()
-		c.Next()
-		return
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.status_code", status), attribute.Int("app.errcode", app.ResponseCode(c)))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	}
 }
 
-func accessLog(c *gin.Context, accessType string, dur time.Duration, body []byte, out any) {
-	req := c.Request
-	bodyStr := string(body)
-	query := req.URL.RawQuery
-	path := req.URL.Path
-	// todo: token记录
-	logger.New(c).Info("AccessLog",
-		"type", accessType,
-		"ip", c.ClientIP(),
-		"method", req.Method,
-		"path", path,
-		"query", query,
-		"body", bodyStr,
-		"output", out,
-		"time", int64(dur/time.Millisecond),
-	)
-}
-
 // GinPanicRecovery 自定义gin recover输出
 func GinPanicRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -131,16 +69,24 @@ func GinPanicRecovery() gin.HandlerFunc {
 					}
 				}
 
-				httpRequest, _ := httputil.DumpRequest(c.Request, false)
+				// 请求头里有登录态, 和访问日志一样打码后再记录
+				opt := loadAccessLogOption()
+				request := []any{
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+					"query", opt.redact(c.Request.URL.RawQuery),
+					"headers", opt.redactedHeaders(c.Request.Header),
+					"error", err,
+				}
 				if brokenPipe {
-					logger.New(c).Error("http request broken pipe", "path", c.Request.URL.Path, "error", err, "request", string(httpRequest))
+					logger.New(c).Error("http request broken pipe", request...)
 					// If the connection is dead, we can't write a status to it.
 					c.Error(err.(error)) // nolint: errcheck
 					c.Abort()
 					return
 				}
 
-				logger.New(c).Error("http_request_panic", "path", c.Request.URL.Path, "error", err, "request", string(httpRequest), "stack", string(debug.Stack()))
+				logger.New(c).Error("http_request_panic", append(request, "stack", string(debug.Stack()))...)
 
 				c.AbortWithError(http.StatusInternalServerError, err.(error))
 			}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/tracing"
+	"github.com/kackerx/go-mall/config"
+)
+
+func TestStartTracePropagation(t *testing.T) {
+	shutdown, err := tracing.Init(context.Background(), &config.Tracing{Exporter: tracing.ExporterNone, SampleRatio: 1}, "go-mall-test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(StartTrace())
+	e.GET("/ping", func(c *gin.Context) {
+		app.NewResponse(c).SuccessOK()
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	var body struct {
+		RequestID string `json:"request_id"`
+	}
+	if err = json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RequestID != traceID {
+		t.Errorf("request_id = %s, want upstream trace id %s", body.RequestID, traceID)
+	}
+	if tp := w.Header().Get("traceparent"); !strings.HasPrefix(tp, "00-"+traceID+"-") {
+		t.Errorf("response traceparent = %q, want same trace id", tp)
+	}
+
+	// 没有上游traceparent时开启新链路
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if err = json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.RequestID) != 32 || body.RequestID == traceID {
+		t.Errorf("request_id = %q, want new trace id", body.RequestID)
+	}
+}
+
+func TestGinPanicRecoveryRedactsHeaders(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	logger.SetDefault(zap.New(core))
+	defer logger.SetDefault(zap.NewNop())
+	SetAccessLogOption(nil)
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(GinPanicRecovery())
+	e.GET("/boom", func(c *gin.Context) { panic(errors.New("boom")) })
+
+	req := httptest.NewRequest(http.MethodGet, "/boom?password=123456", nil)
+	req.Header.Set("gomall-token", "0123456789abcdef")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("code = %d", w.Code)
+	}
+
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %v", entries)
+	}
+	fields := entries[0].ContextMap()
+	headers, _ := fields["headers"].(map[string]string)
+	if headers["Gomall-Token"] != "0123***cdef" || fields["query"] != "password=***" || fields["method"] != http.MethodGet {
+		t.Errorf("panic log fields: %v", fields)
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/metrics"
+)
+
+// Metrics 按路由模板和业务错误码统计请求数和耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		begin := time.Now()
+		c.Next()
+
+		metrics.ObserveHTTP(c.Request.Method, c.FullPath(), c.Writer.Status(), app.ResponseCode(c), time.Since(begin))
+	}
+}
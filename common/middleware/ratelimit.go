@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/library/ratelimit"
+)
+
+// 限流计数的维度
+const (
+	RateLimitKeyIP   = "ip"
+	RateLimitKeyUser = "user"
+)
+
+// KeyFunc 从请求里取限流计数的key, 返回空串时不限流
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP 按客户端IP计数
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按登录用户计数, 需要放在AuthUser之后, 未登录时退化为按IP
+func KeyByUser(c *gin.Context) string {
+	if userID := c.GetInt64("user_id"); userID > 0 {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+	return KeyByIP(c)
+}
+
+type rateLimitRule struct {
+	rule    ratelimit.Rule
+	keyFunc KeyFunc
+}
+
+// RateLimiter 按路由分组限流, 规则来自配置的rate_limit.groups, 每个请求取当前的规则, 热更新后立即生效
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	rules   atomic.Pointer[map[string]*rateLimitRule]
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, groups map[string]*config.RateLimitRule) *RateLimiter {
+	r := &RateLimiter{limiter: limiter}
+	r.SetRules(groups)
+	return r
+}
+
+// SetRules 整体替换分组规则, 配置热更新时调用. 已有计数不清零, 按新规则继续判定
+func (r *RateLimiter) SetRules(groups map[string]*config.RateLimitRule) {
+	rules := make(map[string]*rateLimitRule, len(groups))
+	for name, g := range groups {
+		rule := &rateLimitRule{
+			rule: ratelimit.Rule{
+				Algorithm: g.Algorithm,
+				Limit:     g.Limit,
+				Window:    time.Duration(g.Window) * time.Second,
+				Burst:     g.Burst,
+			},
+			keyFunc: KeyByIP,
+		}
+		if g.Key == RateLimitKeyUser {
+			rule.keyFunc = KeyByUser
+		}
+		rules[name] = rule
+	}
+
+	r.rules.Store(&rules)
+}
+
+// Limit 按分组配置的key限流, 分组没有配置规则时不限流
+func (r *RateLimiter) Limit(group string) gin.HandlerFunc {
+	return r.LimitBy(group, nil)
+}
+
+// LimitBy 用自定义的keyFunc限流, 如按手机号、设备号; keyFunc为nil时用分组配置的key
+func (r *RateLimiter) LimitBy(group string, keyFunc KeyFunc) gin.HandlerFunc {
+	if r == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		rule := (*r.rules.Load())[group]
+		if rule == nil {
+			c.Next()
+			return
+		}
+		byKey := keyFunc
+		if byKey == nil {
+			byKey = rule.keyFunc
+		}
+
+		key := byKey(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		res, err := r.limiter.Allow(c, group+":"+key, rule.rule)
+		if err != nil {
+			// 限流存储不可用时放行, 不能因为限流把业务整体拖垮
+			logger.New(c).Warn("rate limit unavailable", "group", group, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", ceilSeconds(res.ResetAfter))
+		if !res.Allowed {
+			c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+			app.NewResponse(c).Error(errcode.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds 响应头里的时间单位是秒, 向上取整避免客户端提前重试
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
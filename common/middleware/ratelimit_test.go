@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/library/ratelimit"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewMemoryLimiter(), map[string]*config.RateLimitRule{
+		"order": {Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 2, Window: 60, Key: RateLimitKeyUser},
+	})
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	setUser := func(c *gin.Context) {
+		c.Set("user_id", int64(1))
+	}
+	ok := func(c *gin.Context) { app.NewResponse(c).SuccessOK() }
+	e.GET("/order", setUser, limiter.Limit("order"), ok)
+	e.GET("/free", limiter.Limit("unknown"), ok)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/order", nil))
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: code=%d headers=%v", i, w.Code, w.Header())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/order", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("code = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("missing rate limit headers: %v", w.Header())
+	}
+
+	// 没有配置规则的分组不限流
+	for i := 0; i < 5; i++ {
+		w = httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/free", nil))
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("unconfigured group limited: code=%d", w.Code)
+		}
+	}
+}
+
+func TestRateLimiterSetRules(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewMemoryLimiter(), nil)
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.GET("/order", limiter.Limit("order"), func(c *gin.Context) { app.NewResponse(c).SuccessOK() })
+	get := func() int {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/order", nil))
+		return w.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("code = %d before rules configured", code)
+	}
+
+	// 路由注册之后替换规则也要生效
+	limiter.SetRules(map[string]*config.RateLimitRule{
+		"order": {Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 1, Window: 60},
+	})
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("first request code = %d", code)
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Fatalf("second request code = %d, want 429", code)
+	}
+
+	limiter.SetRules(nil)
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("code = %d after rules removed", code)
+	}
+}
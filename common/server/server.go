@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/config"
+)
+
+const (
+	defaultAddr            = ":9999"
+	defaultShutdownTimeout = 15 * time.Second
+)
+
+// shutdownHook 退出时按注册顺序执行的清理动作
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Server HTTP服务的生命周期: 启动监听, 收到SIGINT/SIGTERM后先把就绪状态置为false, 等drainDelay让负载均衡摘掉实例,
+// 再停止接收新连接、等进行中的请求处理完, 最后按注册顺序停后台任务、关连接池
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	ready           atomic.Bool
+	addr            string // 实际监听的地址, Ready之后有效
+	hooks           []shutdownHook
+}
+
+func New(cfg *config.Server, handler http.Handler) *Server {
+	if cfg == nil {
+		cfg = new(config.Server)
+	}
+
+	addr := cfg.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	shutdownTimeout := time.Duration(cfg.ShutdownTimeout) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         addr,
+			Handler:      handler,
+			ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
+			IdleTimeout:  time.Duration(cfg.IdleTimeout) * time.Second,
+		},
+		shutdownTimeout: shutdownTimeout,
+		drainDelay:      time.Duration(cfg.DrainDelay) * time.Second,
+	}
+}
+
+// Ready 服务是否可以接收新流量, 开始排空后返回false
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Addr 实际监听的地址, 配置的端口为0时由系统分配, Ready返回true之后才有效
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// OnShutdown 注册退出时的清理动作, HTTP请求排空后按注册顺序执行
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run 启动服务并阻塞, 直到收到退出信号或ctx取消后完成优雅退出, 监听失败时直接返回错误
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 端口监听成功后才置为就绪, 避免健康检查通过时还连不上
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		s.runHooks()
+		return err
+	}
+	s.addr = ln.Addr().String()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.Serve(ln)
+	}()
+	s.ready.Store(true)
+	logger.New(ctx).Info("http server started", "addr", s.addr)
+
+	select {
+	case err = <-serveErr:
+		s.ready.Store(false)
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.runHooks()
+			return err
+		}
+	case <-ctx.Done():
+		s.ready.Store(false)
+		logger.New(ctx).Info("http server draining", "delay", s.drainDelay.String(), "timeout", s.shutdownTimeout.String())
+		time.Sleep(s.drainDelay)
+	}
+
+	return s.shutdown()
+}
+
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		logger.New(ctx).Error("http server shutdown failed", "err", err)
+	}
+
+	return errors.Join(err, s.runHooks())
+}
+
+// runHooks 单个清理动作失败不影响后续的动作
+func (s *Server) runHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range s.hooks {
+		if err := hook.fn(ctx); err != nil {
+			logger.New(ctx).Error("shutdown hook failed", "hook", hook.name, "err", err)
+			errs = append(errs, err)
+		}
+	}
+	logger.New(ctx).Info("http server stopped")
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kackerx/go-mall/config"
+)
+
+func TestServerGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := New(&config.Server{Addr: "127.0.0.1:0", ShutdownTimeout: 5}, handler)
+	var hooks []string
+	srv.OnShutdown("workers", func(ctx context.Context) error {
+		hooks = append(hooks, "workers")
+		return nil
+	})
+	srv.OnShutdown("mysql", func(ctx context.Context) error {
+		hooks = append(hooks, "mysql")
+		return errors.New("close failed")
+	})
+	srv.OnShutdown("redis", func(ctx context.Context) error {
+		hooks = append(hooks, "redis")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.Run(ctx)
+	}()
+
+	// 就绪时端口已经在监听, 请求不需要重试
+	for !srv.Ready() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	respCode := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + srv.Addr() + "/")
+		if err != nil {
+			respCode <- 0
+			return
+		}
+		resp.Body.Close()
+		respCode <- resp.StatusCode
+	}()
+
+	<-started
+	if !srv.Ready() {
+		t.Fatal("server should be ready while serving")
+	}
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if srv.Ready() {
+		t.Fatal("server should not be ready after draining begins")
+	}
+
+	// 排空期间进行中的请求要能正常完成
+	close(release)
+	if code := <-respCode; code != http.StatusOK {
+		t.Fatalf("in-flight request got %d, want 200", code)
+	}
+
+	err := <-runErr
+	if err == nil || err.Error() != "close failed" {
+		t.Fatalf("Run err = %v, want hook error", err)
+	}
+	if len(hooks) != 3 || hooks[0] != "workers" || hooks[1] != "mysql" || hooks[2] != "redis" {
+		t.Fatalf("hooks = %v, want [workers mysql redis]", hooks)
+	}
+}
+
+func TestServerDrainDelay(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := New(&config.Server{Addr: "127.0.0.1:0", DrainDelay: 1}, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.Run(ctx)
+	}()
+	for !srv.Ready() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if srv.Ready() {
+		t.Fatal("server should not be ready after draining begins")
+	}
+	// 负载均衡摘掉实例前还会有新请求进来, 等待期间要正常处理
+	resp, err := http.Get("http://" + srv.Addr() + "/")
+	if err != nil {
+		t.Fatalf("request during drain delay failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if err = <-runErr; err != nil {
+		t.Fatalf("Run err = %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cursorSignLen 签名截取的字节数, 游标只防篡改, 不需要完整的32字节
+const cursorSignLen = 16
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorSecret 没有配置时为nil, 用randomCursorSecret
+var cursorSecret atomic.Pointer[[]byte]
+
+// randomCursorSecret 没有配置密钥时每个进程随机生成一次, 游标在重启或换实例后失效, 生产环境要配置固定的密钥
+var randomCursorSecret = sync.OnceValue(func() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+})
+
+// SetCursorSecret 设置游标的签名密钥, 多实例部署时要一致, 为空时保留随机密钥
+func SetCursorSecret(secret string) {
+	if secret == "" {
+		return
+	}
+
+	s := []byte(secret)
+	cursorSecret.Store(&s)
+}
+
+// Cursor keyset分页的游标, 记录上一页最后一行的排序键和ID, 下一页从它之后开始.
+// 排序键按列的类型分开存, 比较时参数和列的类型一致, 不会发生隐式转换; 只按id排序时排序键都为空
+type Cursor struct {
+	ID   int64      `json:"i"`
+	Int  *int64     `json:"n,omitempty"`
+	Time *time.Time `json:"t,omitempty"`
+}
+
+// IntCursor 按整数列排序的游标
+func IntCursor(key, id int64) *Cursor {
+	return &Cursor{ID: id, Int: &key}
+}
+
+// TimeCursor 按时间列排序的游标
+func TimeCursor(key time.Time, id int64) *Cursor {
+	return &Cursor{ID: id, Time: &key}
+}
+
+// SortKey 排序键, 只按id排序的游标返回nil
+func (c *Cursor) SortKey() any {
+	switch {
+	case c.Int != nil:
+		return *c.Int
+	case c.Time != nil:
+		return *c.Time
+	}
+	return nil
+}
+
+// cursorPayload 签名的内容, scope绑定生成游标的接口和筛选条件
+type cursorPayload struct {
+	Cursor
+	Scope string `json:"s,omitempty"`
+}
+
+// EncodeCursor 编码为不透明的字符串, 格式为base64(json).base64(签名), 客户端只能原样传回.
+// scope标识生成游标的列表, 解码时要传同样的scope
+func EncodeCursor(c *Cursor, scope string) string {
+	payload, _ := json.Marshal(&cursorPayload{Cursor: *c, Scope: scope})
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(signCursor(payload))
+}
+
+// DecodeCursor 校验签名并解码, 被篡改、格式不对或是别的列表生成的游标时返回ErrInvalidCursor
+func DecodeCursor(s, scope string) (*Cursor, error) {
+	enc := base64.RawURLEncoding
+	payloadStr, signStr, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(payloadStr)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sign, err := enc.DecodeString(signStr)
+	if err != nil || !hmac.Equal(sign, signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	p := new(cursorPayload)
+	if err = json.Unmarshal(payload, p); err != nil || p.Scope != scope {
+		return nil, ErrInvalidCursor
+	}
+
+	return &p.Cursor, nil
+}
+
+func signCursor(payload []byte) []byte {
+	var secret []byte
+	if s := cursorSecret.Load(); s != nil {
+		secret = *s
+	} else {
+		secret = randomCursorSecret()
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)[:cursorSignLen]
+}
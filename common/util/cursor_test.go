@@ -0,0 +1,50 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	SetCursorSecret("test-secret")
+
+	s := EncodeCursor(&Cursor{ID: 42}, "reviews")
+	c, err := DecodeCursor(s, "reviews")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != 42 {
+		t.Errorf("decoded %+v", c)
+	}
+
+	// 排序键按原来的类型解码
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123000000, time.UTC)
+	c, err = DecodeCursor(EncodeCursor(TimeCursor(at, 7), "reviews"), "reviews")
+	if err != nil || c.ID != 7 || c.SortKey() != any(at) {
+		t.Errorf("decoded %+v, err=%v", c, err)
+	}
+	if c, _ = DecodeCursor(EncodeCursor(IntCursor(5, 7), "reviews"), "reviews"); c.SortKey() != any(int64(5)) {
+		t.Errorf("int sort key = %v", c.SortKey())
+	}
+
+	// 其他列表生成的游标不能混用
+	if _, err = DecodeCursor(s, "coupons"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor from another scope accepted")
+	}
+
+	payload, sign, _ := strings.Cut(EncodeCursor(&Cursor{ID: 1}, "reviews"), ".")
+	tampered := []string{"", "abc", payload, payload + ".", strings.Split(s, ".")[0] + "." + sign}
+	for _, s := range tampered {
+		if _, err = DecodeCursor(s, "reviews"); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) err = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+
+	// 换了密钥之前的游标失效
+	SetCursorSecret("other-secret")
+	if _, err = DecodeCursor(s, "reviews"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor signed by old secret accepted")
+	}
+}
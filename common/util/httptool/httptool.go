@@ -9,9 +9,15 @@ import (
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kackerx/go-mall/common/errcode"
 	"github.com/kackerx/go-mall/common/logger"
-	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/common/tracing"
 )
 
 func Request(method, url string, options ...Option) (httpStatusCode int, resp []byte, err error) {
@@ -25,10 +31,6 @@ func Request(method, url string, options ...Option) (httpStatusCode int, resp []
 		opt(reqOption)
 	}
 
-	traceID, spanID, _ := util.GetTraceInfoFromCtx(reqOption.ctx)
-	reqOption.headers["traceid"] = traceID
-	reqOption.headers["spanid"] = spanID
-
 	logger := logger.New(reqOption.ctx)
 	defer func() {
 		if err != nil {
@@ -36,18 +38,29 @@ func Request(method, url string, options ...Option) (httpStatusCode int, resp []
 		}
 	}()
 
-	req, err := http.NewRequest(method, url, bytes.NewReader(reqOption.data))
+	ctx, cancel := context.WithTimeout(reqOption.ctx, reqOption.timeout)
+	defer cancel()
+	ctx, span := tracing.Tracer().Start(ctx, "HTTP "+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.method", method), attribute.String("http.url", url)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqOption.data))
 	if err != nil {
 		return
 	}
-
-	reqOption.ctx, _ = context.WithTimeout(reqOption.ctx, reqOption.timeout)
-	req.WithContext(reqOption.ctx)
 	defer req.Body.Close()
 
 	for k, v := range reqOption.headers {
 		req.Header.Add(k, v)
 	}
+	// 按W3C标准把traceparent传给下游
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// 发起请求
 	client := http.Client{Timeout: reqOption.timeout}
@@ -44,3 +44,16 @@ func MaskLoginName(loginName string) string {
 
 	return MaskPhone(loginName)
 }
+
+// MaskNickname 公开展示的昵称脱敏, 保留首尾各一个字符
+func MaskNickname(nickname string) string {
+	runeNickname := []rune(nickname)
+	switch len(runeNickname) {
+	case 0:
+		return ""
+	case 1:
+		return nickname + "*"
+	default:
+		return MaskRealName(nickname)
+	}
+}
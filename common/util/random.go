@@ -2,12 +2,14 @@ package util
 
 import (
 	"math/rand"
+	"strconv"
 	"time"
 )
 
 type (
 	Random struct {
 		charset Charset
+		rnd     *rand.Rand // 为nil时使用math/rand的全局源, 并发安全; 固定种子的实例不能跨goroutine共用
 	}
 
 	Charset string
@@ -31,6 +33,14 @@ func NewRandom() *Random {
 	}
 }
 
+// NewSeededRandom 固定种子的随机数生成器, 相同种子按相同顺序调用得到相同结果, 用于造数据和测试复现
+func NewSeededRandom(seed int64) *Random {
+	return &Random{
+		charset: Alphanumeric,
+		rnd:     rand.New(rand.NewSource(seed)),
+	}
+}
+
 func (r *Random) SetCharset(c Charset) {
 	r.charset = c
 }
@@ -38,11 +48,92 @@ func (r *Random) SetCharset(c Charset) {
 func (r *Random) String(length uint8) string {
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = r.charset[rand.Int63()%int64(len(r.charset))]
+		b[i] = r.charset[r.Int63n(int64(len(r.charset)))]
 	}
 	return string(b)
 }
 
+// Int63n 返回[0, n)之间的随机数, n<=0时panic
+func (r *Random) Int63n(n int64) int64 {
+	if r.rnd == nil {
+		return rand.Int63n(n)
+	}
+	return r.rnd.Int63n(n)
+}
+
+// Intn 返回[0, n)之间的随机数, n<=0时panic
+func (r *Random) Intn(n int) int {
+	return int(r.Int63n(int64(n)))
+}
+
+// Between 返回[min, max]之间的随机数
+func (r *Random) Between(min, max int64) int64 {
+	if max <= min {
+		return min
+	}
+	return min + r.Int63n(max-min+1)
+}
+
+// Chance 以percent%的概率返回true
+func (r *Random) Chance(percent int) bool {
+	return r.Intn(100) < percent
+}
+
+// Pick 从items中随机选一个, items不能为空
+func Pick[T any](r *Random, items []T) T {
+	return items[r.Intn(len(items))]
+}
+
+var (
+	familyNames = []string{"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱", "胡", "郭", "何", "林", "高", "罗"}
+	givenNames  = []string{"伟", "芳", "娜", "敏", "静", "磊", "洋", "勇", "艳", "杰", "涛", "明", "超", "霞", "平", "刚", "桂英", "秀兰", "建华", "晓东", "子涵", "浩然", "雨桐", "思远"}
+	phonePrefix = []string{"130", "131", "132", "135", "136", "137", "138", "139", "150", "151", "158", "159", "176", "177", "186", "187", "188", "189"}
+
+	regions = []struct {
+		Province  string
+		City      string
+		Districts []string
+	}{
+		{"北京市", "北京市", []string{"朝阳区", "海淀区", "东城区", "西城区", "丰台区"}},
+		{"上海市", "上海市", []string{"浦东新区", "徐汇区", "静安区", "黄浦区", "闵行区"}},
+		{"广东省", "广州市", []string{"天河区", "越秀区", "海珠区", "番禺区"}},
+		{"广东省", "深圳市", []string{"南山区", "福田区", "罗湖区", "宝安区"}},
+		{"浙江省", "杭州市", []string{"西湖区", "上城区", "拱墅区", "滨江区"}},
+		{"四川省", "成都市", []string{"武侯区", "锦江区", "青羊区", "高新区"}},
+		{"湖北省", "武汉市", []string{"江汉区", "武昌区", "洪山区", "汉阳区"}},
+	}
+	streets = []string{"人民路", "解放路", "中山路", "建设路", "和平路", "新华路", "文化路", "科技路"}
+)
+
+// ChineseName 随机中文姓名
+func (r *Random) ChineseName() string {
+	return Pick(r, familyNames) + Pick(r, givenNames)
+}
+
+// Phone 随机11位手机号
+func (r *Random) Phone() string {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = Numeric[r.Intn(len(Numeric))]
+	}
+	return Pick(r, phonePrefix) + string(b)
+}
+
+// Region 随机省市区
+func (r *Random) Region() (province, city, district string) {
+	region := Pick(r, regions)
+	return region.Province, region.City, Pick(r, region.Districts)
+}
+
+// Street 随机街道门牌
+func (r *Random) Street() string {
+	return Pick(r, streets) + itoa(r.Between(1, 999)) + "号" + itoa(r.Between(1, 30)) + "栋" + itoa(r.Between(101, 2808)) + "室"
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
 func SetCharset(c Charset) {
 	globalRandom.SetCharset(c)
 }
@@ -56,3 +147,8 @@ func RandNumStr(len uint8) string {
 	r.SetCharset(Numeric)
 	return r.String(len)
 }
+
+// GenSerialNo 生成业务单号, 如订单号, 支付单号: 前缀 + 秒级时间 + 6位随机数字
+func GenSerialNo(prefix string) string {
+	return prefix + time.Now().Format("20060102150405") + RandNumStr(6)
+}
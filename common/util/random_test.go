@@ -0,0 +1,36 @@
+package util
+
+import "testing"
+
+func TestSeededRandomReproducible(t *testing.T) {
+	gen := func(seed int64) []string {
+		r := NewSeededRandom(seed)
+		province, city, district := r.Region()
+		return []string{r.String(12), r.ChineseName(), r.Phone(), province + city + district, r.Street(), itoa(r.Between(10, 20))}
+	}
+
+	a, b := gen(42), gen(42)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed differs at %d: %q vs %q", i, a[i], b[i])
+		}
+	}
+	if c := gen(43); c[0] == a[0] && c[1] == a[1] && c[2] == a[2] {
+		t.Errorf("different seeds should differ, got %v", c)
+	}
+	if len(a[2]) != 11 {
+		t.Errorf("phone = %q, want 11 digits", a[2])
+	}
+}
+
+func TestBetween(t *testing.T) {
+	r := NewSeededRandom(1)
+	for i := 0; i < 1000; i++ {
+		if n := r.Between(3, 5); n < 3 || n > 5 {
+			t.Fatalf("Between(3, 5) = %d", n)
+		}
+	}
+	if n := r.Between(7, 7); n != 7 {
+		t.Errorf("Between(7, 7) = %d", n)
+	}
+}
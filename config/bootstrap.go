@@ -1,28 +1,121 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
-const ConfDir = "config/"
+const (
+	ConfDir = "config/"
 
-func init() {
-	env := os.Getenv("ENV")
-	vp := viper.New()
+	// EnvPrefix 环境变量覆盖配置的前缀, 配置路径里的.换成_, 如GOMALL_DB_MASTER_DSN
+	EnvPrefix = "GOMALL"
+)
+
+// DefaultPath 按环境变量ENV选择配置文件, 没有设置时使用app.yaml
+func DefaultPath() string {
+	if env := os.Getenv("ENV"); env != "" {
+		return ConfDir + "app." + env + ".yaml"
+	}
 
-	configFilePath := ConfDir + "app.yaml"
-	if env != "" {
-		configFilePath = ConfDir + "app." + env + ".yaml"
+	return ConfDir + "app.yaml"
+}
+
+// Load 读取配置文件, 用环境变量覆盖后校验
+func Load(path string) (*Config, error) {
+	vp, err := newViper(path)
+	if err != nil {
+		return nil, err
 	}
 
-	vp.SetConfigFile(configFilePath)
+	return decode(vp)
+}
+
+func newViper(path string) (*viper.Viper, error) {
+	vp := viper.New()
+	vp.SetConfigFile(path)
+	vp.SetEnvPrefix(EnvPrefix)
+	vp.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	// 配置文件里没写的项(比如DSN这类密钥)也要能从环境变量读到, 需要把所有配置项都绑定一遍
+	bindEnvs(vp, reflect.TypeOf(Config{}), "")
+
 	if err := vp.ReadInConfig(); err != nil {
-		panic(err)
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	return vp, nil
+}
+
+func decode(vp *viper.Viper) (*Config, error) {
+	conf := new(Config)
+	if err := vp.Unmarshal(conf); err != nil {
+		return nil, fmt.Errorf("decode config %s: %w", vp.ConfigFileUsed(), err)
+	}
+	if err := validate(conf); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", vp.ConfigFileUsed(), err)
 	}
 
-	if err := vp.Unmarshal(&Conf); err != nil {
-		panic(err)
+	return conf, nil
+}
+
+func bindEnvs(vp *viper.Viper, t reflect.Type, prefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + configKey(field)
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			bindEnvs(vp, ft, key+".")
+			continue
+		}
+
+		_ = vp.BindEnv(key)
 	}
 }
+
+// configKey 配置项在文件里的名字, 没有mapstructure标签时viper按字段名小写匹配
+func configKey(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ","); name != "" {
+		return name
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+var configValidator = func() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(configKey)
+	return v
+}()
+
+// validate 校验失败时每个字段一行, 写明配置路径和对应的环境变量, 方便定位
+func validate(conf *Config) error {
+	err := configValidator.Struct(conf)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	msgs := make([]string, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		// Namespace形如Config.db.master.dsn, 去掉最外层的类型名
+		_, key, _ := strings.Cut(fe.Namespace(), ".")
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		envKey := EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		msgs = append(msgs, fmt.Sprintf("%s (env %s) failed on '%s', got %v", key, envKey, rule, fe.Value()))
+	}
+
+	return errors.New(strings.Join(msgs, "; "))
+}
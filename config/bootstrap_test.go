@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testYAML = `app:
+  env: dev
+  name: go-mall
+  log:
+    path: /tmp/go-mall-test.log
+    level: info
+  pagination:
+    default_size: 20
+    max_size: 100
+db:
+  master:
+    dsn: ""
+  slave:
+    dsn: ""
+redis:
+  addr: 127.0.0.1:6379
+flash_sale:
+  order_workers: 2
+  user_qps: 5
+rate_limit:
+  backend: memory
+  groups:
+    order:
+      algorithm: token_bucket
+      limit: 10
+      window: 1
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadEnvOverride(t *testing.T) {
+	t.Setenv("GOMALL_DB_MASTER_DSN", "master-dsn")
+	t.Setenv("GOMALL_DB_SLAVE_DSN", "slave-dsn")
+	t.Setenv("GOMALL_REDIS_PASSWORD", "secret")
+	t.Setenv("GOMALL_APP_PAGINATION_MAX_SIZE", "50")
+
+	conf, err := Load(writeConfig(t, testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.DB.Master.Dsn != "master-dsn" || conf.DB.Slave.Dsn != "slave-dsn" {
+		t.Errorf("dsn = %q, %q", conf.DB.Master.Dsn, conf.DB.Slave.Dsn)
+	}
+	// 配置文件里没有的项也能从环境变量读到
+	if conf.Redis.Password != "secret" {
+		t.Errorf("redis password = %q", conf.Redis.Password)
+	}
+	if conf.App.Pagination.MaxSize != 50 {
+		t.Errorf("pagination max_size = %d, want 50", conf.App.Pagination.MaxSize)
+	}
+}
+
+func TestLoadValidate(t *testing.T) {
+	t.Setenv("GOMALL_DB_SLAVE_DSN", "slave-dsn")
+	t.Setenv("GOMALL_APP_ENV", "staging")
+
+	_, err := Load(writeConfig(t, testYAML))
+	if err == nil {
+		t.Fatal("want validation error")
+	}
+	for _, want := range []string{"db.master.dsn (env GOMALL_DB_MASTER_DSN) failed on 'required'", "app.env"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want contains %q", err, want)
+		}
+	}
+}
+
+func TestReloaderSafeKeys(t *testing.T) {
+	t.Setenv("GOMALL_DB_MASTER_DSN", "master-dsn")
+	t.Setenv("GOMALL_DB_SLAVE_DSN", "slave-dsn")
+	path := writeConfig(t, testYAML)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReloader(path, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan *Config, 1)
+	r.Subscribe(func(c *Config) { changed <- c })
+	r.Start()
+
+	next := strings.NewReplacer(
+		"level: info", "level: warn",
+		"max_size: 100", "max_size: 30",
+		"user_qps: 5", "user_qps: 9",
+		"order_workers: 2", "order_workers: 8",
+		"127.0.0.1:6379", "10.0.0.1:6379",
+		"limit: 10", "limit: 3",
+		"backend: memory", "backend: redis",
+	).Replace(testYAML)
+	if err = os.WriteFile(path, []byte(next), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case c := <-changed:
+		if c.App.Log.Level != "warn" || c.App.Pagination.MaxSize != 30 || c.FlashSale.UserQPS != 9 {
+			t.Errorf("safe keys not applied: level=%s max_size=%d user_qps=%d", c.App.Log.Level, c.App.Pagination.MaxSize, c.FlashSale.UserQPS)
+		}
+		if c.RateLimit.Groups["order"].Limit != 3 {
+			t.Errorf("rate limit rules not applied: limit=%d", c.RateLimit.Groups["order"].Limit)
+		}
+		// 需要重启才能生效的配置保持不变
+		if c.FlashSale.OrderWorkers != 2 || c.Redis.Addr != "127.0.0.1:6379" || c.RateLimit.Backend != "memory" {
+			t.Errorf("unsafe keys changed: order_workers=%d redis=%s rate_limit.backend=%s", c.FlashSale.OrderWorkers, c.Redis.Addr, c.RateLimit.Backend)
+		}
+		if r.Current() != c {
+			t.Error("Current() should return reloaded config")
+		}
+		if conf.App.Log.Level != "info" {
+			t.Error("original config should not be modified")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no reload notification")
+	}
+}
+
+// 仓库里各环境的配置文件在密钥通过环境变量传入后都要能通过校验
+func TestLoadEnvFiles(t *testing.T) {
+	t.Setenv("GOMALL_DB_MASTER_DSN", "master-dsn")
+	t.Setenv("GOMALL_DB_SLAVE_DSN", "slave-dsn")
+	t.Setenv("GOMALL_PAYMENT_MOCK_SECRET", "mock-secret")
+
+	for _, env := range []string{"dev", "test", "prod"} {
+		conf, err := Load("app." + env + ".yaml")
+		if err != nil {
+			t.Errorf("load %s config: %v", env, err)
+			continue
+		}
+		if conf.App.Env != env {
+			t.Errorf("app.env = %s, want %s", conf.App.Env, env)
+		}
+	}
+}
@@ -1,49 +1,144 @@
 package config
 
-var (
-	Conf *Config
-)
-
+// Config 应用配置, validate标签在Load时校验, 环境变量GOMALL_<路径>可以覆盖任意配置项,
+// 如GOMALL_DB_MASTER_DSN覆盖db.master.dsn
 type Config struct {
-	App   *App
-	DB    *DB
-	Redis *Redis
+	App         *App         `validate:"required"`
+	DB          *DB          `validate:"required"`
+	Redis       *Redis       `validate:"required"`
+	Payment     *Payment     `validate:"omitempty"`
+	FlashSale   *FlashSale   `mapstructure:"flash_sale" validate:"omitempty"`
+	Tracing     *Tracing     `mapstructure:"tracing" validate:"omitempty"`
+	Outbox      *Outbox      `mapstructure:"outbox" validate:"omitempty"`
+	RateLimit   *RateLimit   `mapstructure:"rate_limit" validate:"omitempty"`
+	Idempotency *Idempotency `mapstructure:"idempotency" validate:"omitempty"`
 }
 
 type Redis struct {
-	Addr     string `mapstructure:"addr"`
-	Password string `mapstructure:"password"`
-	PoolSize int    `mapstructure:"pool_size"`
-	Db       int    `mapstructure:"db"`
+	Addr     string `mapstructure:"addr" validate:"required"`
+	Password string `mapstructure:"password"` // 不要写在配置文件里, 用GOMALL_REDIS_PASSWORD传入
+	PoolSize int    `mapstructure:"pool_size" validate:"gte=0"`
+	Db       int    `mapstructure:"db" validate:"gte=0"`
 }
 
 type App struct {
-	Name       string `mapstructure:"name"`
-	Env        string `mapstructure:"env"`
-	Log        *Log
-	Pagination *Pagination
+	Name         string  `mapstructure:"name" validate:"required"`
+	Env          string  `mapstructure:"env" validate:"oneof=dev test prod"`
+	AdminUserIDs []int64 `mapstructure:"admin_user_ids"` // 可以访问管理后台接口的用户
+	CursorSecret string  `mapstructure:"cursor_secret"`  // 分页游标的签名密钥, 多实例要一致; 不要写在配置文件里, 用GOMALL_APP_CURSOR_SECRET传入
+	Log          *Log    `validate:"required"`
+	Pagination   *Pagination
+	Server       *Server
+}
+
+// Server HTTP服务的监听地址和超时, 时间单位都是秒
+type Server struct {
+	Addr            string `mapstructure:"addr"`
+	ReadTimeout     int    `mapstructure:"read_timeout" validate:"gte=0"`
+	WriteTimeout    int    `mapstructure:"write_timeout" validate:"gte=0"`
+	IdleTimeout     int    `mapstructure:"idle_timeout" validate:"gte=0"`
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout" validate:"gte=0"` // 收到退出信号后等待进行中请求的最长时间
+	DrainDelay      int    `mapstructure:"drain_delay" validate:"gte=0"`      // 就绪检查开始失败后继续接收请求的时间, 留给负载均衡摘掉实例
 }
 
 type Pagination struct {
-	DefaultSize int `mapstructure:"default_size"`
-	MaxSize     int `mapstructure:"max_size"`
+	DefaultSize int `mapstructure:"default_size" validate:"gt=0"`
+	MaxSize     int `mapstructure:"max_size" validate:"gtefield=DefaultSize"`
 }
 
 type Log struct {
-	Path    string `mapstructure:"path"`
-	MaxSize int    `mapstructure:"max_size"`
-	MaxAge  int    `mapstructure:"max_age"`
+	Path    string `mapstructure:"path" validate:"required"`
+	Level   string `mapstructure:"level" validate:"omitempty,oneof=debug info warn error"` // 为空时按环境决定, 开发环境debug, 其他info
+	MaxSize int    `mapstructure:"max_size" validate:"gte=0"`
+	MaxAge  int    `mapstructure:"max_age" validate:"gte=0"`
+	// ErrorStack 错误日志是否带完整调用栈, 每次创建错误都要采集调用栈, 生产环境排查问题时再打开
+	ErrorStack bool `mapstructure:"error_stack"`
+	// Access 访问日志的脱敏、截断和采样, 不配置时使用默认值
+	Access *AccessLog `mapstructure:"access" validate:"omitempty"`
+}
+
+type AccessLog struct {
+	MaxBodySize   int               `mapstructure:"max_body_size" validate:"gte=0"`     // 请求和响应body最多记录的字节数, 超出截断; 0表示不记录body
+	RedactFields  []string          `mapstructure:"redact_fields"`                      // 打码的JSON字段、表单和query参数, 不区分大小写, 任意层级都生效
+	Headers       []string          `mapstructure:"headers"`                            // 记录的请求头
+	RedactHeaders []string          `mapstructure:"redact_headers"`                     // 记录时打码的请求头, 只保留首尾各4位
+	SampleRate    float64           `mapstructure:"sample_rate" validate:"gte=0,lte=1"` // 成功请求的采样比例, 状态码>=400的请求总是记录
+	Routes        []*AccessLogRoute `mapstructure:"routes" validate:"dive,required"`    // 按路由覆盖采样比例
+}
+
+type AccessLogRoute struct {
+	Path       string  `mapstructure:"path" validate:"required"` // 注册时的路由, 如/commodity/list、/payment/notify/:channel
+	SampleRate float64 `mapstructure:"sample_rate" validate:"gte=0,lte=1"`
 }
 
 type DB struct {
 	Type   string           `mapstructure:"type"`
-	Master *DBConnectOption `mapstructure:"master"`
-	Slave  *DBConnectOption `mapstructure:"slave"`
+	Master *DBConnectOption `mapstructure:"master" validate:"required"`
+	Slave  *DBConnectOption `mapstructure:"slave" validate:"required"`
+	// Replicas 除slave外的其他从库, 读请求在所有健康的从库间轮询
+	Replicas []*DBConnectOption `mapstructure:"replicas" validate:"dive,required"`
+	// HealthCheckInterval 从库探活间隔, 单位秒, 探活失败的从库暂时不参与读请求
+	HealthCheckInterval int `mapstructure:"health_check_interval" validate:"gte=0"`
 }
 
 type DBConnectOption struct {
-	Dsn         string `mapstructure:"dsn"`
-	MaxOpen     int    `mapstructure:"max_open"`
-	MaxIdle     int    `mapstructure:"max_idle"`
-	MaxLiftTime int    `mapstructure:"max_lift_time"`
+	Dsn         string `mapstructure:"dsn" validate:"required"` // 含密码, 不要写在配置文件里, 用GOMALL_DB_MASTER_DSN / GOMALL_DB_SLAVE_DSN传入
+	MaxOpen     int    `mapstructure:"max_open" validate:"gte=0"`
+	MaxIdle     int    `mapstructure:"max_idle" validate:"gte=0"`
+	MaxLiftTime int    `mapstructure:"max_lift_time" validate:"gte=0"`
+}
+
+type Payment struct {
+	NotifyBaseURL string       `mapstructure:"notify_base_url" validate:"omitempty,url"` // 支付回调地址的域名部分, 回调路径为/payment/notify/:channel
+	Mock          *PaymentMock `mapstructure:"mock"`
+}
+
+type PaymentMock struct {
+	Enable      bool   `mapstructure:"enable"`
+	Secret      string `mapstructure:"secret" validate:"required_if=Enable true"`
+	Scenario    string `mapstructure:"scenario" validate:"omitempty,oneof=success fail timeout duplicate"` // success, fail, timeout, duplicate
+	NotifyDelay int    `mapstructure:"notify_delay" validate:"gte=0"`                                      // 模拟用户支付耗时, 单位毫秒
+}
+
+type FlashSale struct {
+	OrderWorkers int `mapstructure:"order_workers" validate:"gte=0"` // 异步创建秒杀订单的消费者数量
+	UserQPS      int `mapstructure:"user_qps" validate:"gte=0"`      // 单个用户每秒最多请求令牌和抢购的次数, 超过视为刷单
+}
+
+type Tracing struct {
+	Exporter    string  `mapstructure:"exporter" validate:"omitempty,oneof=otlp stdout none"` // otlp, stdout, none; none时仍然生成traceid, 只是不上报
+	Endpoint    string  `mapstructure:"endpoint" validate:"required_if=Exporter otlp"`        // otlp http接收地址, 如127.0.0.1:4318
+	Insecure    bool    `mapstructure:"insecure"`                                             // otlp不使用https
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"gte=0,lte=1"`                  // 链路起点的采样比例, 上游传了traceparent时跟随上游的采样结果
+}
+
+// Outbox 领域事件中继, 从outbox表轮询待发布事件投递出去
+type Outbox struct {
+	Publisher    string `mapstructure:"publisher" validate:"omitempty,oneof=inprocess redis"` // inprocess: 进程内分发; redis: 写入Redis Stream
+	StreamPrefix string `mapstructure:"stream_prefix"`                                        // redis时stream key的前缀, stream为前缀+topic
+	PollInterval int    `mapstructure:"poll_interval" validate:"gte=0"`                       // 轮询间隔, 单位毫秒
+	BatchSize    int    `mapstructure:"batch_size" validate:"gte=0"`                          // 每次最多投递的事件数
+	MaxAttempts  int    `mapstructure:"max_attempts" validate:"gte=0"`                        // 超过后标记为dead, 不再重试
+	LeaseTimeout int    `mapstructure:"lease_timeout" validate:"gte=0"`                       // 认领一批事件后多久没写回结果可以被其他实例重新认领, 单位秒
+}
+
+// RateLimit 接口限流, 规则按路由分组配置, 没有配置的分组不限流
+type RateLimit struct {
+	Backend   string                    `mapstructure:"backend" validate:"omitempty,oneof=memory redis"` // memory: 单实例进程内计数; redis: 多实例共享计数
+	KeyPrefix string                    `mapstructure:"key_prefix"`                                      // redis时计数key的前缀
+	Groups    map[string]*RateLimitRule `mapstructure:"groups" validate:"dive,required"`                 // 分组名 -> 规则, 分组名和路由注册时的名字对应
+}
+
+type RateLimitRule struct {
+	Algorithm string `mapstructure:"algorithm" validate:"oneof=token_bucket sliding_window"` // token_bucket, sliding_window
+	Limit     int    `mapstructure:"limit" validate:"gt=0"`                                  // window内允许的请求数
+	Window    int    `mapstructure:"window" validate:"gt=0"`                                 // 单位秒
+	Burst     int    `mapstructure:"burst" validate:"gte=0"`                                 // 令牌桶容量, 为0时等于limit
+	Key       string `mapstructure:"key" validate:"omitempty,oneof=ip user"`                 // 按什么计数, ip或user, 默认ip; user未登录时退化为ip
+}
+
+// Idempotency 接口幂等, 客户端用Idempotency-Key请求头标识同一次操作, 重试时保持不变
+type Idempotency struct {
+	TTL     int `mapstructure:"ttl" validate:"gte=0"`      // 处理完成的响应保留多久, 期间的重试直接返回这份响应, 单位秒
+	LockTTL int `mapstructure:"lock_ttl" validate:"gte=0"` // 处理中状态最长保留多久, 进程崩溃没来得及释放时到期自动释放, 单位秒
 }
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Reloader 监听配置文件变更, 只把可以安全热更新的配置项应用到当前配置上.
+// 连接串、监听地址这类需要重建资源的配置改了也不会生效, 必须重启
+type Reloader struct {
+	vp      *viper.Viper
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(*Config)
+}
+
+// NewReloader conf是启动时Load得到的配置, 热更新在它的副本上进行
+func NewReloader(path string, conf *Config) (*Reloader, error) {
+	vp, err := newViper(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{vp: vp}
+	r.current.Store(conf)
+	return r, nil
+}
+
+// Current 返回最近一次生效的配置, 不要修改返回值
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Subscribe 注册配置变更回调, 回调在watcher的goroutine里串行执行
+func (r *Reloader) Subscribe(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Start 开始监听配置文件, 新配置解析或校验失败时保留旧配置
+func (r *Reloader) Start() {
+	r.vp.OnConfigChange(func(e fsnotify.Event) {
+		if err := r.reload(); err != nil {
+			// 日志组件本身依赖配置, 这里用标准库输出
+			log.Printf("config reload %s failed, keep current config: %v", e.Name, err)
+		}
+	})
+	r.vp.WatchConfig()
+}
+
+func (r *Reloader) reload() error {
+	next, err := decode(r.vp)
+	if err != nil {
+		return err
+	}
+
+	conf := applySafeKeys(r.current.Load(), next)
+	r.current.Store(conf)
+
+	r.mu.Lock()
+	subscribers := append([]func(*Config){}, r.subscribers...)
+	r.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(conf)
+	}
+
+	return nil
+}
+
+// applySafeKeys 复制一份当前配置, 只替换日志级别、错误调用栈开关、访问日志选项、分页参数、秒杀限流阈值和接口限流规则.
+// 限流的存储后端和key前缀需要重启
+func applySafeKeys(cur, next *Config) *Config {
+	conf := *cur
+
+	app := *cur.App
+	log := *cur.App.Log
+	log.Level = next.App.Log.Level
+	log.ErrorStack = next.App.Log.ErrorStack
+	log.Access = next.App.Log.Access
+	app.Log = &log
+	if next.App.Pagination != nil {
+		pagination := *next.App.Pagination
+		app.Pagination = &pagination
+	}
+	conf.App = &app
+
+	if cur.FlashSale != nil && next.FlashSale != nil {
+		flashSale := *cur.FlashSale
+		flashSale.UserQPS = next.FlashSale.UserQPS
+		conf.FlashSale = &flashSale
+	}
+
+	if next.RateLimit != nil {
+		var rateLimit RateLimit
+		if cur.RateLimit != nil {
+			rateLimit = *cur.RateLimit
+		}
+		rateLimit.Groups = next.RateLimit.Groups
+		conf.RateLimit = &rateLimit
+	}
+
+	return &conf
+}
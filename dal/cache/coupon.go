@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/logger"
+)
+
+// ClaimCoupon 的返回结果
+const (
+	CouponClaimOK             = 0
+	CouponClaimOutOfStock     = 1
+	CouponClaimLimitExceeded  = 2
+	CouponClaimStockNotWarmed = -1 // 库存还没加载到Redis, 需要先WarmCouponStock
+	CouponClaimUserNotWarmed  = -2 // 用户已领数量还没加载到Redis, 需要先WarmCouponUserClaimed
+)
+
+// couponClaimScript 原子地校验库存和用户领取上限并扣减, KEYS: 库存, 用户已领数量; ARGV: 每人限领数量
+var couponClaimScript = redis.NewScript(`
+local stock = redis.call('GET', KEYS[1])
+if not stock then
+	return -1
+end
+local claimed = redis.call('GET', KEYS[2])
+if not claimed then
+	return -2
+end
+if tonumber(claimed) >= tonumber(ARGV[1]) then
+	return 2
+end
+if tonumber(stock) <= 0 then
+	return 1
+end
+redis.call('DECR', KEYS[1])
+redis.call('INCR', KEYS[2])
+return 0
+`)
+
+// ClaimCoupon 在Redis中扣减优惠券库存并累加用户已领数量, 写库失败时要调用RevertCouponClaim回补
+func (c *Cache) ClaimCoupon(ctx context.Context, templateID, userID, perUserLimit int64) (int64, error) {
+	keys := []string{
+		fmt.Sprintf(enum.RedisKeyCouponStock, templateID),
+		fmt.Sprintf(enum.RedisKeyCouponUserClaimed, templateID, userID),
+	}
+	res, err := couponClaimScript.Run(ctx, c.rdb, keys, perUserLimit).Int64()
+	if err != nil {
+		logger.New(ctx).Error("redis claim coupon error", "err", err)
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// WarmCouponStock 把数据库中的剩余库存加载到Redis, 已存在时不覆盖, 过期时间为领取结束之后
+func (c *Cache) WarmCouponStock(ctx context.Context, templateID, stock int64, expireAt time.Time) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyCouponStock, templateID)
+	if err := c.rdb.SetNX(ctx, redisKey, stock, time.Until(expireAt)).Err(); err != nil {
+		logger.New(ctx).Error("redis warm coupon stock error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// WarmCouponUserClaimed 把数据库中用户已领取的数量加载到Redis, 已存在时不覆盖
+func (c *Cache) WarmCouponUserClaimed(ctx context.Context, templateID, userID, claimed int64, expireAt time.Time) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyCouponUserClaimed, templateID, userID)
+	if err := c.rdb.SetNX(ctx, redisKey, claimed, time.Until(expireAt)).Err(); err != nil {
+		logger.New(ctx).Error("redis warm coupon user claimed error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevertCouponClaim 领取写库失败后回补Redis中扣掉的库存和用户已领数量
+func (c *Cache) RevertCouponClaim(ctx context.Context, templateID, userID int64) error {
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, fmt.Sprintf(enum.RedisKeyCouponStock, templateID))
+		pipe.Decr(ctx, fmt.Sprintf(enum.RedisKeyCouponUserClaimed, templateID, userID))
+		return nil
+	})
+	if err != nil {
+		logger.New(ctx).Error("redis revert coupon claim error", "err", err)
+		return err
+	}
+
+	return nil
+}
@@ -10,10 +10,10 @@ import (
 	"github.com/kackerx/go-mall/logic/do"
 )
 
-func SetDemoOrder(ctx context.Context, demoOrder *do.DemoOrder) error {
+func (c *Cache) SetDemoOrder(ctx context.Context, demoOrder *do.DemoOrder) error {
 	bs, _ := json.Marshal(demoOrder)
 	redisKey := fmt.Sprintf(enum.RedisKeyDemoOrderDetail, demoOrder.Code)
-	_, err := Redis().Set(ctx, redisKey, bs, 0).Result()
+	_, err := c.rdb.Set(ctx, redisKey, bs, 0).Result()
 	if err != nil {
 		// ? redis没有gorm的logger接口, 所以在操作处自己打日志
 		logger.New(ctx).Error("redis set error", "err", err)
@@ -23,9 +23,9 @@ func SetDemoOrder(ctx context.Context, demoOrder *do.DemoOrder) error {
 	return nil
 }
 
-func GetDemoOrder(ctx context.Context, code string) (*do.DemoOrder, error) {
+func (c *Cache) GetDemoOrder(ctx context.Context, code string) (*do.DemoOrder, error) {
 	redisKey := fmt.Sprintf(enum.RedisKeyDemoOrderDetail, code)
-	bs, err := Redis().Get(ctx, redisKey).Bytes()
+	bs, err := c.rdb.Get(ctx, redisKey).Bytes()
 	if err != nil {
 		logger.New(ctx).Error("redis set error", "err", err)
 		return nil, err
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/logger"
+)
+
+// incrIfExistsScript 计数存在时才增减, 不存在时等下次读取从数据库加载, KEYS: 计数; ARGV: 增量
+var incrIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('INCRBY', KEYS[1], ARGV[1])
+return 1
+`)
+
+// GetFavoriteCount 商品的收藏数, 缓存中没有时返回ok=false
+func (c *Cache) GetFavoriteCount(ctx context.Context, commodityID int64) (count int64, ok bool, err error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyFavoriteCount, commodityID)
+	count, err = c.rdb.Get(ctx, redisKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		logger.New(ctx).Error("redis get favorite count error", "err", err)
+		return 0, false, err
+	}
+
+	return count, true, nil
+}
+
+func (c *Cache) SetFavoriteCount(ctx context.Context, commodityID, count int64) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyFavoriteCount, commodityID)
+	if err := c.rdb.Set(ctx, redisKey, count, enum.FavoriteCountTTL).Err(); err != nil {
+		logger.New(ctx).Error("redis set favorite count error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// IncrFavoriteCount 收藏时delta为1, 取消收藏时为-1
+func (c *Cache) IncrFavoriteCount(ctx context.Context, commodityID, delta int64) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyFavoriteCount, commodityID)
+	if err := incrIfExistsScript.Run(ctx, c.rdb, []string{redisKey}, delta).Err(); err != nil {
+		logger.New(ctx).Error("redis incr favorite count error", "err", err)
+		return err
+	}
+
+	return nil
+}
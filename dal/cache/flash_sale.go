@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// GrabFlashSale 的返回结果
+const (
+	FlashSaleGrabOK        = 0
+	FlashSaleGrabSoldOut   = 1
+	FlashSaleGrabDuplicate = 2
+	FlashSaleGrabNotWarmed = -1
+)
+
+// flashSaleGrabScript 原子地完成: 校验库存和是否已抢过, 扣库存, 记录用户, 写排队结果, 投递到下单队列.
+// KEYS: 库存, 已抢用户集合, 抢购结果, 下单队列; ARGV: 用户ID, 队列消息, 结果过期时间戳
+var flashSaleGrabScript = redis.NewScript(`
+local stock = redis.call('GET', KEYS[1])
+if not stock then
+	return -1
+end
+if redis.call('SISMEMBER', KEYS[2], ARGV[1]) == 1 then
+	return 2
+end
+if tonumber(stock) <= 0 then
+	return 1
+end
+redis.call('DECR', KEYS[1])
+redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('EXPIREAT', KEYS[2], ARGV[3])
+redis.call('HSET', KEYS[3], 'status', 'queued')
+redis.call('EXPIREAT', KEYS[3], ARGV[3])
+redis.call('LPUSH', KEYS[4], ARGV[2])
+return 0
+`)
+
+// SetFlashSale 缓存秒杀活动信息, 抢购链路只读缓存
+func (c *Cache) SetFlashSale(ctx context.Context, flashSale *do.FlashSale) error {
+	bs, _ := json.Marshal(flashSale)
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleInfo, flashSale.ID)
+	if err := c.rdb.Set(ctx, redisKey, bs, time.Until(flashSale.EndAt.Add(enum.FlashSaleResultTTL))).Err(); err != nil {
+		logger.New(ctx).Error("redis set flash sale error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetFlashSale 活动不在缓存中时返回nil
+func (c *Cache) GetFlashSale(ctx context.Context, flashSaleID int64) (*do.FlashSale, error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleInfo, flashSaleID)
+	bs, err := c.rdb.Get(ctx, redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.New(ctx).Error("redis get flash sale error", "err", err)
+		return nil, err
+	}
+
+	flashSale := new(do.FlashSale)
+	if err = json.Unmarshal(bs, flashSale); err != nil {
+		return nil, err
+	}
+
+	return flashSale, nil
+}
+
+// WarmFlashSaleStock 预热秒杀库存, 已存在时不覆盖
+func (c *Cache) WarmFlashSaleStock(ctx context.Context, flashSale *do.FlashSale, stock int64) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleStock, flashSale.ID)
+	if err := c.rdb.SetNX(ctx, redisKey, stock, time.Until(flashSale.EndAt.Add(enum.FlashSaleResultTTL))).Err(); err != nil {
+		logger.New(ctx).Error("redis warm flash sale stock error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// GrabFlashSale 扣减秒杀库存并把抢购请求放入下单队列
+func (c *Cache) GrabFlashSale(ctx context.Context, flashSale *do.FlashSale, grab *do.FlashSaleGrab) (int64, error) {
+	keys := []string{
+		fmt.Sprintf(enum.RedisKeyFlashSaleStock, flashSale.ID),
+		fmt.Sprintf(enum.RedisKeyFlashSaleUsers, flashSale.ID),
+		fmt.Sprintf(enum.RedisKeyFlashSaleResult, flashSale.ID, grab.UserID),
+		enum.RedisKeyFlashSaleQueue,
+	}
+	msg, _ := json.Marshal(grab)
+	expireAt := flashSale.EndAt.Add(enum.FlashSaleResultTTL).Unix()
+
+	res, err := flashSaleGrabScript.Run(ctx, c.rdb, keys, grab.UserID, msg, expireAt).Int64()
+	if err != nil {
+		logger.New(ctx).Error("redis grab flash sale error", "err", err)
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// PopFlashSaleGrab 阻塞地从下单队列取出一个抢购请求, 超时没有请求时返回nil.
+// 取出的请求原子地移入处理中列表, 处理完必须调用CompleteFlashSaleGrab或RevertFlashSaleGrab确认,
+// 消费者在确认前崩溃时由RequeueStaleFlashSaleGrabs放回队列
+func (c *Cache) PopFlashSaleGrab(ctx context.Context, timeout time.Duration) (*do.FlashSaleGrab, error) {
+	msg, err := c.rdb.BLMove(ctx, enum.RedisKeyFlashSaleQueue, enum.RedisKeyFlashSaleRunning, "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	grab := new(do.FlashSaleGrab)
+	if err = json.Unmarshal([]byte(msg), grab); err != nil {
+		// 解析不了的消息重试也没用, 直接丢弃
+		c.rdb.LRem(ctx, enum.RedisKeyFlashSaleRunning, 1, msg)
+		return nil, err
+	}
+	if err = c.rdb.HSet(ctx, enum.RedisKeyFlashSaleRunningAt, msg, time.Now().Unix()).Err(); err != nil {
+		logger.New(ctx).Error("redis mark flash sale grab running error", "err", err)
+	}
+
+	return grab, nil
+}
+
+// flashSaleAckScript 把抢购请求移出处理中列表并写抢购结果, 下单失败时同时退回库存、允许用户重新抢购.
+// 请求已不在处理中列表(被当作超时重新入队)时不退库存, 交给重新取出它的消费者处理.
+// KEYS: 处理中列表, 取出时间, 抢购结果, 库存, 已抢用户集合; ARGV: 队列消息, 是否退回, 用户ID, 结果字段...
+var flashSaleAckScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+if ARGV[2] == '1' then
+	if removed == 0 then
+		return 0
+	end
+	redis.call('INCR', KEYS[4])
+	redis.call('SREM', KEYS[5], ARGV[3])
+end
+redis.call('HSET', KEYS[3], unpack(ARGV, 4))
+return removed
+`)
+
+// CompleteFlashSaleGrab 订单已创建, 写入抢购结果并确认请求
+func (c *Cache) CompleteFlashSaleGrab(ctx context.Context, grab *do.FlashSaleGrab, result *do.FlashSaleResult) error {
+	return c.ackFlashSaleGrab(ctx, grab, result, false)
+}
+
+// RevertFlashSaleGrab 创建订单失败, 退回库存并允许用户重新抢购, 写入失败结果并确认请求
+func (c *Cache) RevertFlashSaleGrab(ctx context.Context, grab *do.FlashSaleGrab, result *do.FlashSaleResult) error {
+	return c.ackFlashSaleGrab(ctx, grab, result, true)
+}
+
+func (c *Cache) ackFlashSaleGrab(ctx context.Context, grab *do.FlashSaleGrab, result *do.FlashSaleResult, revert bool) error {
+	keys := []string{
+		enum.RedisKeyFlashSaleRunning,
+		enum.RedisKeyFlashSaleRunningAt,
+		fmt.Sprintf(enum.RedisKeyFlashSaleResult, grab.FlashSaleID, grab.UserID),
+		fmt.Sprintf(enum.RedisKeyFlashSaleStock, grab.FlashSaleID),
+		fmt.Sprintf(enum.RedisKeyFlashSaleUsers, grab.FlashSaleID),
+	}
+	// 和GrabFlashSale入队时用同一个结构体序列化, 得到的消息完全一致, LREM才能匹配上
+	msg, _ := json.Marshal(grab)
+	args := []any{msg, 0, grab.UserID, "status", result.Status, "order_no", result.OrderNo, "fail_code", result.FailCode}
+	if revert {
+		args[1] = 1
+	}
+
+	if err := flashSaleAckScript.Run(ctx, c.rdb, keys, args...).Err(); err != nil {
+		logger.New(ctx).Error("redis ack flash sale grab error", "err", err, "revert", revert)
+		return err
+	}
+
+	return nil
+}
+
+// flashSaleRequeueScript 把取出时间早于deadline的处理中请求放回队列出队的一端, 优先被重新处理.
+// 取出后还没来得及记录时间就崩溃的请求按抢购时间算. KEYS: 处理中列表, 取出时间, 下单队列; ARGV: deadline
+var flashSaleRequeueScript = redis.NewScript(`
+local requeued = 0
+for _, msg in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local at = tonumber(redis.call('HGET', KEYS[2], msg))
+	if not at then
+		at = cjson.decode(msg)['grabbed_at']
+	end
+	if at < tonumber(ARGV[1]) then
+		redis.call('LREM', KEYS[1], 1, msg)
+		redis.call('HDEL', KEYS[2], msg)
+		redis.call('RPUSH', KEYS[3], msg)
+		requeued = requeued + 1
+	end
+end
+return requeued
+`)
+
+// RequeueStaleFlashSaleGrabs 把取出后超过staleAfter还没确认的抢购请求放回下单队列, 返回放回的个数.
+// 请求可能被重复处理, 创建订单按活动和用户幂等
+func (c *Cache) RequeueStaleFlashSaleGrabs(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	keys := []string{enum.RedisKeyFlashSaleRunning, enum.RedisKeyFlashSaleRunningAt, enum.RedisKeyFlashSaleQueue}
+	n, err := flashSaleRequeueScript.Run(ctx, c.rdb, keys, time.Now().Add(-staleAfter).Unix()).Int64()
+	if err != nil {
+		logger.New(ctx).Error("redis requeue flash sale grabs error", "err", err)
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// GetFlashSaleStock 秒杀剩余库存, 没有预热或已过期时返回false
+func (c *Cache) GetFlashSaleStock(ctx context.Context, flashSaleID int64) (int64, bool, error) {
+	stock, err := c.rdb.Get(ctx, fmt.Sprintf(enum.RedisKeyFlashSaleStock, flashSaleID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		logger.New(ctx).Error("redis get flash sale stock error", "err", err)
+		return 0, false, err
+	}
+
+	return stock, true, nil
+}
+
+// GetFlashSaleResult 没有抢购记录时返回nil
+func (c *Cache) GetFlashSaleResult(ctx context.Context, flashSaleID, userID int64) (*do.FlashSaleResult, error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleResult, flashSaleID, userID)
+	res := c.rdb.HGetAll(ctx, redisKey)
+	if err := res.Err(); err != nil {
+		logger.New(ctx).Error("redis get flash sale result error", "err", err)
+		return nil, err
+	}
+	if len(res.Val()) == 0 {
+		return nil, nil
+	}
+
+	result := new(do.FlashSaleResult)
+	if err := res.Scan(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetFlashSaleToken 下发一次性的抢购令牌, 抢购接口凭令牌调用, 提前编写脚本直接请求抢购接口的请求会被拦下
+func (c *Cache) SetFlashSaleToken(ctx context.Context, flashSaleID, userID int64, token string) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleToken, flashSaleID, userID)
+	if err := c.rdb.Set(ctx, redisKey, token, enum.FlashSaleTokenTTL).Err(); err != nil {
+		logger.New(ctx).Error("redis set flash sale token error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeFlashSaleToken 校验并作废抢购令牌, 令牌只能使用一次
+func (c *Cache) ConsumeFlashSaleToken(ctx context.Context, flashSaleID, userID int64, token string) (bool, error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleToken, flashSaleID, userID)
+	saved, err := c.rdb.GetDel(ctx, redisKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		logger.New(ctx).Error("redis consume flash sale token error", "err", err)
+		return false, err
+	}
+
+	return saved == token, nil
+}
+
+// AllowFlashSaleRequest 按秒统计用户的请求次数, 超过qps返回false
+func (c *Cache) AllowFlashSaleRequest(ctx context.Context, userID int64, qps int) (bool, error) {
+	now := time.Now().Unix()
+	redisKey := fmt.Sprintf(enum.RedisKeyFlashSaleRateLimit, userID, now)
+
+	pipe := c.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, redisKey)
+	pipe.Expire(ctx, redisKey, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.New(ctx).Error("redis flash sale rate limit error", "err", err)
+		return false, err
+	}
+
+	return incr.Val() <= int64(qps), nil
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+func newTestCache(t *testing.T) (*Cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb), mr
+}
+
+func warmTestFlashSale(t *testing.T, c *Cache, stock int64) *do.FlashSale {
+	t.Helper()
+	flashSale := &do.FlashSale{ID: 1, EndAt: time.Now().Add(time.Hour)}
+	if err := c.WarmFlashSaleStock(context.Background(), flashSale, stock); err != nil {
+		t.Fatal(err)
+	}
+	return flashSale
+}
+
+func TestGrabFlashSaleConcurrent(t *testing.T) {
+	c, _ := newTestCache(t)
+	ctx := context.Background()
+	const stock, users, triesPerUser = 10, 30, 3
+	flashSale := warmTestFlashSale(t, c, stock)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		okByID = make(map[int64]int)
+	)
+	for userID := int64(1); userID <= users; userID++ {
+		for range triesPerUser {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := c.GrabFlashSale(ctx, flashSale, &do.FlashSaleGrab{FlashSaleID: flashSale.ID, UserID: userID, GrabbedAt: time.Now().Unix()})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if res == FlashSaleGrabOK {
+					mu.Lock()
+					okByID[userID]++
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	total := 0
+	for userID, n := range okByID {
+		if n > 1 {
+			t.Errorf("user %d grabbed %d times", userID, n)
+		}
+		total += n
+	}
+	if total != stock {
+		t.Errorf("total grabs = %d, want %d", total, stock)
+	}
+	if left, _, _ := c.GetFlashSaleStock(ctx, flashSale.ID); left != 0 {
+		t.Errorf("stock left = %d, want 0", left)
+	}
+}
+
+func TestFlashSaleGrabAckAndRequeue(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+	flashSale := warmTestFlashSale(t, c, 2)
+
+	for userID := int64(1); userID <= 2; userID++ {
+		grab := &do.FlashSaleGrab{FlashSaleID: flashSale.ID, UserID: userID, GrabbedAt: time.Now().Unix()}
+		if res, err := c.GrabFlashSale(ctx, flashSale, grab); err != nil || res != FlashSaleGrabOK {
+			t.Fatalf("grab user %d = %d, %v", userID, res, err)
+		}
+	}
+
+	// 第一个请求下单失败: 退回库存, 用户可以重新抢
+	first, err := c.PopFlashSaleGrab(ctx, time.Second)
+	if err != nil || first == nil {
+		t.Fatalf("pop = %+v, %v", first, err)
+	}
+	failed := &do.FlashSaleResult{Status: enum.FlashSaleResultFailed, FailCode: 10000710}
+	if err = c.RevertFlashSaleGrab(ctx, first, failed); err != nil {
+		t.Fatal(err)
+	}
+	// 重复确认不会多退库存
+	if err = c.RevertFlashSaleGrab(ctx, first, failed); err != nil {
+		t.Fatal(err)
+	}
+	if left, _, _ := c.GetFlashSaleStock(ctx, flashSale.ID); left != 1 {
+		t.Errorf("stock after revert = %d, want 1", left)
+	}
+	result, _ := c.GetFlashSaleResult(ctx, flashSale.ID, first.UserID)
+	if result == nil || result.Status != enum.FlashSaleResultFailed || result.FailCode != 10000710 {
+		t.Errorf("result after revert = %+v", result)
+	}
+
+	// 第二个请求取出后消费者崩溃, 超时后放回队列被重新取出
+	second, err := c.PopFlashSaleGrab(ctx, time.Second)
+	if err != nil || second == nil {
+		t.Fatalf("pop = %+v, %v", second, err)
+	}
+	if n, _ := c.RequeueStaleFlashSaleGrabs(ctx, time.Minute); n != 0 {
+		t.Errorf("requeued fresh grabs = %d, want 0", n)
+	}
+	running, _ := mr.HKeys(enum.RedisKeyFlashSaleRunningAt)
+	mr.HSet(enum.RedisKeyFlashSaleRunningAt, running[0], "0")
+	if n, _ := c.RequeueStaleFlashSaleGrabs(ctx, time.Minute); n != 1 {
+		t.Errorf("requeued stale grabs = %d, want 1", n)
+	}
+	again, err := c.PopFlashSaleGrab(ctx, time.Second)
+	if err != nil || again == nil || *again != *second {
+		t.Fatalf("pop requeued = %+v, %v, want %+v", again, err, second)
+	}
+
+	if err = c.CompleteFlashSaleGrab(ctx, again, &do.FlashSaleResult{Status: enum.FlashSaleResultSuccess, OrderNo: "O1"}); err != nil {
+		t.Fatal(err)
+	}
+	if running, _ = mr.List(enum.RedisKeyFlashSaleRunning); len(running) != 0 {
+		t.Errorf("running list = %v, want empty", running)
+	}
+	result, _ = c.GetFlashSaleResult(ctx, flashSale.ID, again.UserID)
+	if result == nil || result.Status != enum.FlashSaleResultSuccess || result.OrderNo != "O1" {
+		t.Errorf("result after complete = %+v", result)
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// AddBrowseRecord 记录浏览, 同一商品只保留最近一次. 写入时顺带清理超出条数上限和保留时长的记录
+func (c *Cache) AddBrowseRecord(ctx context.Context, userID, commodityID int64, viewedAt time.Time) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyBrowseHistory, userID)
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, redisKey, redis.Z{Score: float64(viewedAt.UnixMilli()), Member: commodityID})
+		pipe.ZRemRangeByRank(ctx, redisKey, 0, -enum.BrowseHistoryMax-1)
+		pipe.ZRemRangeByScore(ctx, redisKey, "-inf", browseHistoryExpiredScore(viewedAt))
+		pipe.Expire(ctx, redisKey, enum.BrowseHistoryKeep)
+		return nil
+	})
+	if err != nil {
+		logger.New(ctx).Error("redis add browse record error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// ListBrowseRecords 按浏览时间倒序分页返回浏览记录和总数, 读取前先清理超出保留时长的记录
+func (c *Cache) ListBrowseRecords(ctx context.Context, userID int64, offset, limit int) ([]*do.BrowseRecord, int64, error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyBrowseHistory, userID)
+	var rangeCmd *redis.ZSliceCmd
+	var countCmd *redis.IntCmd
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, redisKey, "-inf", browseHistoryExpiredScore(time.Now()))
+		countCmd = pipe.ZCard(ctx, redisKey)
+		rangeCmd = pipe.ZRevRangeWithScores(ctx, redisKey, int64(offset), int64(offset+limit-1))
+		return nil
+	})
+	if err != nil {
+		logger.New(ctx).Error("redis list browse records error", "err", err)
+		return nil, 0, err
+	}
+
+	records := make([]*do.BrowseRecord, 0, len(rangeCmd.Val()))
+	for _, z := range rangeCmd.Val() {
+		commodityID, _ := strconv.ParseInt(z.Member.(string), 10, 64)
+		records = append(records, &do.BrowseRecord{
+			CommodityID: commodityID,
+			ViewedAt:    time.UnixMilli(int64(z.Score)),
+		})
+	}
+
+	return records, countCmd.Val(), nil
+}
+
+func (c *Cache) ClearBrowseRecords(ctx context.Context, userID int64) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyBrowseHistory, userID)
+	if err := c.rdb.Del(ctx, redisKey).Err(); err != nil {
+		logger.New(ctx).Error("redis clear browse records error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func browseHistoryExpiredScore(now time.Time) string {
+	return "(" + strconv.FormatInt(now.Add(-enum.BrowseHistoryKeep).UnixMilli(), 10)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+)
+
+func TestBrowseHistory(t *testing.T) {
+	c, _ := newTestCache(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// 同一商品只保留最近一次浏览
+	for i, commodityID := range []int64{1, 2, 1} {
+		if err := c.AddBrowseRecord(ctx, 7, commodityID, now.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, total, err := c.ListBrowseRecords(ctx, 7, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(records) != 2 || records[0].CommodityID != 1 || records[1].CommodityID != 2 {
+		t.Fatalf("records = %+v, total = %d, want 1 then 2", records, total)
+	}
+	if !records[0].ViewedAt.Equal(now.Add(2 * time.Second).Truncate(time.Millisecond)) {
+		t.Errorf("viewed at = %v, want latest view", records[0].ViewedAt)
+	}
+
+	// 超出条数上限时丢掉最早的记录
+	for i := range enum.BrowseHistoryMax + 5 {
+		if err = c.AddBrowseRecord(ctx, 8, int64(i+1), now.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, total, err = c.ListBrowseRecords(ctx, 8, enum.BrowseHistoryMax-1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != enum.BrowseHistoryMax || len(records) != 1 || records[0].CommodityID != 6 {
+		t.Errorf("oldest kept = %+v, total = %d, want commodity 6 and %d records", records, total, enum.BrowseHistoryMax)
+	}
+
+	// 超出保留时长的记录在写入和读取时清理
+	if err = c.AddBrowseRecord(ctx, 9, 1, now.Add(-enum.BrowseHistoryKeep-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.AddBrowseRecord(ctx, 9, 2, now); err != nil {
+		t.Fatal(err)
+	}
+	records, total, err = c.ListBrowseRecords(ctx, 9, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(records) != 1 || records[0].CommodityID != 2 {
+		t.Errorf("records = %+v, total = %d, want only commodity 2", records, total)
+	}
+
+	if err = c.ClearBrowseRecords(ctx, 9); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ = c.ListBrowseRecords(ctx, 9, 0, 10); total != 0 {
+		t.Errorf("total after clear = %d", total)
+	}
+}
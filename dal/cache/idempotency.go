@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/logger"
+)
+
+// 幂等记录的状态
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotentRecord 一个Idempotency-Key对应的请求指纹、处理状态和处理完成后的响应
+type IdempotentRecord struct {
+	Fingerprint string
+	Status      string
+	HTTPStatus  int
+	ContentType string
+	Body        []byte
+}
+
+// idempotencyAcquireScript key不存在时写入处理中状态和本次处理的token并返回空, 已存在时返回已有记录.
+// KEYS: 幂等记录; ARGV: 请求指纹, token, 处理中状态的过期毫秒数
+var idempotencyAcquireScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[1], 'fingerprint', ARGV[1], 'status', 'processing', 'token', ARGV[2])
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	return {}
+end
+return redis.call('HMGET', KEYS[1], 'fingerprint', 'status', 'code', 'content_type', 'body')
+`)
+
+// idempotencyCompleteScript token一致时保存处理结果. 处理超过lockTTL时key已被释放或被下一次重试占用, 不能覆盖.
+// KEYS: 幂等记录; ARGV: token, HTTP状态码, Content-Type, 响应body, 保留毫秒数
+var idempotencyCompleteScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', 'completed', 'code', ARGV[2], 'content_type', ARGV[3], 'body', ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// idempotencyReleaseScript token一致时删除记录. KEYS: 幂等记录; ARGV: token
+var idempotencyReleaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// AcquireIdempotencyKey 抢占幂等key, 抢到时返回nil, 否则返回已有的记录. token标识这一次处理, 完成或释放时要带上.
+// lockTTL是处理中状态的最长保留时间, 进程崩溃时到期释放
+func (c *Cache) AcquireIdempotencyKey(ctx context.Context, key, fingerprint, token string, lockTTL time.Duration) (*IdempotentRecord, error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyIdempotency, key)
+	vals, err := idempotencyAcquireScript.Run(ctx, c.rdb, []string{redisKey}, fingerprint, token, lockTTL.Milliseconds()).Slice()
+	if err != nil {
+		logger.New(ctx).Error("redis acquire idempotency key error", "err", err)
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, nil
+	}
+
+	field := func(i int) string {
+		s, _ := vals[i].(string)
+		return s
+	}
+	record := &IdempotentRecord{
+		Fingerprint: field(0),
+		Status:      field(1),
+		ContentType: field(3),
+		Body:        []byte(field(4)),
+	}
+	record.HTTPStatus, _ = strconv.Atoi(field(2))
+
+	return record, nil
+}
+
+// CompleteIdempotencyKey 保存处理结果, ttl内同一个key的重试直接返回这份响应. key已不属于token时不保存
+func (c *Cache) CompleteIdempotencyKey(ctx context.Context, key, token string, record *IdempotentRecord, ttl time.Duration) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyIdempotency, key)
+	owned, err := idempotencyCompleteScript.Run(ctx, c.rdb, []string{redisKey},
+		token, record.HTTPStatus, record.ContentType, record.Body, ttl.Milliseconds()).Int()
+	if err != nil {
+		logger.New(ctx).Error("redis complete idempotency key error", "err", err)
+		return err
+	}
+	if owned == 0 {
+		logger.New(ctx).Warn("idempotency key lock expired before completion", "key", key)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey 删除幂等记录, 处理失败且允许重试时调用, 下一次重试会重新执行. key已不属于token时不删除
+func (c *Cache) ReleaseIdempotencyKey(ctx context.Context, key, token string) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyIdempotency, key)
+	if err := idempotencyReleaseScript.Run(ctx, c.rdb, []string{redisKey}, token).Err(); err != nil {
+		logger.New(ctx).Error("redis release idempotency key error", "err", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 处理超过lockTTL后key被下一次重试占用, 超时的那次既不能覆盖也不能删除新的记录
+func TestIdempotencyKeyToken(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+
+	if record, err := c.AcquireIdempotencyKey(ctx, "k", "fp", "slow", time.Second); err != nil || record != nil {
+		t.Fatalf("first acquire: record=%+v err=%v", record, err)
+	}
+	mr.FastForward(2 * time.Second)
+	if record, err := c.AcquireIdempotencyKey(ctx, "k", "fp", "retry", time.Minute); err != nil || record != nil {
+		t.Fatalf("acquire after lock expired: record=%+v err=%v", record, err)
+	}
+
+	if err := c.ReleaseIdempotencyKey(ctx, "k", "slow"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CompleteIdempotencyKey(ctx, "k", "slow", &IdempotentRecord{HTTPStatus: 500}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	record, err := c.AcquireIdempotencyKey(ctx, "k", "fp", "other", time.Minute)
+	if err != nil || record == nil || record.Status != IdempotencyProcessing {
+		t.Fatalf("stale token touched the record: record=%+v err=%v", record, err)
+	}
+
+	if err = c.CompleteIdempotencyKey(ctx, "k", "retry", &IdempotentRecord{HTTPStatus: 200, Body: []byte("ok")}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	record, err = c.AcquireIdempotencyKey(ctx, "k", "fp", "other", time.Minute)
+	if err != nil || record == nil || record.Status != IdempotencyCompleted || record.HTTPStatus != 200 || string(record.Body) != "ok" {
+		t.Fatalf("completed record = %+v, err=%v", record, err)
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/metrics"
+)
+
+// metricsHook 统计Redis命令的耗时和错误, key不存在(redis.Nil)不算错误
+type metricsHook struct{}
+
+var _ redis.Hook = metricsHook{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		begin := time.Now()
+		conn, err := next(ctx, network, addr)
+		metrics.ObserveRedis("dial", time.Since(begin), err)
+		return conn, err
+	}
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		begin := time.Now()
+		err := next(ctx, cmd)
+		metrics.ObserveRedis(cmd.Name(), time.Since(begin), redisMetricErr(err))
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		begin := time.Now()
+		err := next(ctx, cmds)
+		metrics.ObserveRedis("pipeline", time.Since(begin), redisMetricErr(err))
+		return err
+	}
+}
+
+func redisMetricErr(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+
+	return err
+}
@@ -9,26 +9,35 @@ import (
 	"github.com/kackerx/go-mall/config"
 )
 
-var redisClient *redis.Client
-
-func Redis() *redis.Client {
-	return redisClient
-}
-
-func init() {
-	redisConf := config.Conf.Redis
-	redisClient = redis.NewClient(&redis.Options{
-		Addr:         redisConf.Addr,
-		Password:     redisConf.Password,
-		DB:           redisConf.Db,
-		PoolSize:     redisConf.PoolSize,
+// NewClient 按配置创建Redis客户端并检查连通性
+func NewClient(cfg *config.Redis) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.Db,
+		PoolSize:     cfg.PoolSize,
 		DialTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		PoolTimeout:  10 * time.Second,
 	})
 
-	if err := redisClient.Ping(context.Background()).Err(); err != nil {
-		panic(err)
+	client.AddHook(tracingHook{})
+	client.AddHook(metricsHook{})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
 	}
+
+	return client, nil
+}
+
+// Cache 业务缓存的读写入口, 依赖redis.UniversalClient, 单机、哨兵、集群都可以传进来
+type Cache struct {
+	rdb redis.UniversalClient
+}
+
+func New(rdb redis.UniversalClient) *Cache {
+	return &Cache{rdb: rdb}
 }
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// ratingStatsIncrScript 增量更新评分统计, 统计不在Redis中时不写入, 下次读取时从数据库全量重建, 避免只有增量的残缺数据.
+// KEYS: 统计; ARGV: 星级, 增量(1或-1)
+var ratingStatsIncrScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], 'count', ARGV[2])
+redis.call('HINCRBY', KEYS[1], 'sum', tonumber(ARGV[1]) * tonumber(ARGV[2]))
+redis.call('HINCRBY', KEYS[1], 'r' .. ARGV[1], ARGV[2])
+return 1
+`)
+
+// ratingStatsSetScript 统计不存在时写入并设置过期时间, 已存在说明其他请求已经重建或有了更新的增量, 不覆盖.
+// KEYS: 统计; ARGV: 过期毫秒数, 之后是字段和值交替
+var ratingStatsSetScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], unpack(ARGV, 2))
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return 1
+`)
+
+// IncrRatingStats 评价通过审核时delta为1, 已通过的评价被下架时为-1
+func (c *Cache) IncrRatingStats(ctx context.Context, commodityID int64, rating int8, delta int64) error {
+	redisKey := fmt.Sprintf(enum.RedisKeyReviewRatingStats, commodityID)
+	if err := ratingStatsIncrScript.Run(ctx, c.rdb, []string{redisKey}, rating, delta).Err(); err != nil {
+		logger.New(ctx).Error("redis incr rating stats error", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetRatingStats 统计不在Redis中时返回nil
+func (c *Cache) GetRatingStats(ctx context.Context, commodityID int64) (*do.RatingStats, error) {
+	redisKey := fmt.Sprintf(enum.RedisKeyReviewRatingStats, commodityID)
+	fields, err := c.rdb.HGetAll(ctx, redisKey).Result()
+	if err != nil {
+		logger.New(ctx).Error("redis get rating stats error", "err", err)
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	stats := &do.RatingStats{CommodityID: commodityID, Distribution: make(map[int8]int64, enum.ReviewMaxRating)}
+	stats.Count, _ = strconv.ParseInt(fields["count"], 10, 64)
+	stats.Sum, _ = strconv.ParseInt(fields["sum"], 10, 64)
+	for rating := int8(enum.ReviewMinRating); rating <= enum.ReviewMaxRating; rating++ {
+		stats.Distribution[rating], _ = strconv.ParseInt(fields[fmt.Sprintf("r%d", rating)], 10, 64)
+	}
+
+	return stats, nil
+}
+
+// SetRatingStats 用数据库全量统计的结果重建Redis中的评分统计, 已存在时不覆盖.
+// 统计到写入之间通过审核的评价不会计入, 靠过期后重建纠正
+func (c *Cache) SetRatingStats(ctx context.Context, stats *do.RatingStats) error {
+	args := []any{enum.ReviewRatingStatsTTL.Milliseconds(), "count", stats.Count, "sum", stats.Sum}
+	for rating := int8(enum.ReviewMinRating); rating <= enum.ReviewMaxRating; rating++ {
+		args = append(args, fmt.Sprintf("r%d", rating), stats.Distribution[rating])
+	}
+
+	redisKey := fmt.Sprintf(enum.RedisKeyReviewRatingStats, stats.CommodityID)
+	if err := ratingStatsSetScript.Run(ctx, c.rdb, []string{redisKey}, args...).Err(); err != nil {
+		logger.New(ctx).Error("redis set rating stats error", "err", err)
+		return err
+	}
+
+	return nil
+}
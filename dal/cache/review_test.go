@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+func TestRatingStats(t *testing.T) {
+	c, mr := newTestCache(t)
+	ctx := context.Background()
+
+	// 统计不在Redis中时增量不写入
+	if err := c.IncrRatingStats(ctx, 1, 5, 1); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := c.GetRatingStats(ctx, 1); err != nil || stats != nil {
+		t.Fatalf("stats after incr on missing key = %+v, err = %v", stats, err)
+	}
+
+	rebuilt := &do.RatingStats{CommodityID: 1, Count: 2, Sum: 9, Distribution: map[int8]int64{4: 1, 5: 1}}
+	if err := c.SetRatingStats(ctx, rebuilt); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("gomall:review:rating_stats_1"); ttl != enum.ReviewRatingStatsTTL {
+		t.Errorf("ttl = %v, want %v", ttl, enum.ReviewRatingStatsTTL)
+	}
+	if err := c.IncrRatingStats(ctx, 1, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 较慢的重建拿着旧快照写回时不覆盖
+	if err := c.SetRatingStats(ctx, &do.RatingStats{CommodityID: 1, Count: 1, Sum: 5, Distribution: map[int8]int64{5: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := c.GetRatingStats(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != 3 || stats.Sum != 12 || stats.Distribution[3] != 1 || stats.Distribution[4] != 1 || stats.Distribution[5] != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
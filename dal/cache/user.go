@@ -18,14 +18,14 @@ import (
 )
 
 // SetUserToken 设置用户的AccessToken 和 RefreshToken 缓存
-func SetUserToken(ctx context.Context, session *do.SessionInfo) error {
+func (c *Cache) SetUserToken(ctx context.Context, session *do.SessionInfo) error {
 	logger := logger.New(ctx)
-	err := setAccessToken(ctx, session)
+	err := c.setAccessToken(ctx, session)
 	if err != nil {
 		logger.Error("redis error", "err", err)
 		return err
 	}
-	err = setRefreshToken(ctx, session)
+	err = c.setRefreshToken(ctx, session)
 	if err != nil {
 		logger.Error("redis error", "err", err)
 		return err
@@ -33,10 +33,10 @@ func SetUserToken(ctx context.Context, session *do.SessionInfo) error {
 	return err
 }
 
-func SetUserSession(ctx context.Context, session *do.SessionInfo) error {
+func (c *Cache) SetUserSession(ctx context.Context, session *do.SessionInfo) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyUserSession, session.UserID)
 	sessionDataBytes, _ := json.Marshal(session)
-	err := Redis().HSet(ctx, redisKey, session.Platform, sessionDataBytes).Err()
+	err := c.rdb.HSet(ctx, redisKey, session.Platform, sessionDataBytes).Err()
 	if err != nil {
 		logger.New(ctx).Error("redis error", "err", err)
 		return err
@@ -45,9 +45,9 @@ func SetUserSession(ctx context.Context, session *do.SessionInfo) error {
 }
 
 // DelOldSessionTokens 删除用户旧Session的Token
-func DelOldSessionTokens(ctx context.Context, session *do.SessionInfo) error {
+func (c *Cache) DelOldSessionTokens(ctx context.Context, session *do.SessionInfo) error {
 	// log := logger.New(ctx)
-	oldSession, err := GetUserPlatformSession(ctx, session.UserID, session.Platform)
+	oldSession, err := c.GetUserPlatformSession(ctx, session.UserID, session.Platform)
 	if err != nil {
 		return err
 	}
@@ -55,11 +55,11 @@ func DelOldSessionTokens(ctx context.Context, session *do.SessionInfo) error {
 		// 没有旧Session
 		return nil
 	}
-	err = DelAccessToken(ctx, oldSession.AccessToken)
+	err = c.DelAccessToken(ctx, oldSession.AccessToken)
 	if err != nil {
 		return errcode.Wrap("redis error", err)
 	}
-	err = DelayDelRefreshToken(ctx, oldSession.RefreshToken)
+	err = c.DelayDelRefreshToken(ctx, oldSession.RefreshToken)
 	if err != nil {
 		return errcode.Wrap("redis error", err)
 	}
@@ -67,9 +67,9 @@ func DelOldSessionTokens(ctx context.Context, session *do.SessionInfo) error {
 }
 
 // GetUserPlatformSession 获取用户在指定平台中的Session信息
-func GetUserPlatformSession(ctx context.Context, userId int64, platform string) (*do.SessionInfo, error) {
+func (c *Cache) GetUserPlatformSession(ctx context.Context, userId int64, platform string) (*do.SessionInfo, error) {
 	redisKey := fmt.Sprintf(enum.RedisKeyUserSession, userId)
-	result, err := Redis().HGet(ctx, redisKey, platform).Result()
+	result, err := c.rdb.HGet(ctx, redisKey, platform).Result()
 	if err != nil && err != redis.Nil {
 		return nil, err
 	}
@@ -85,63 +85,63 @@ func GetUserPlatformSession(ctx context.Context, userId int64, platform string)
 	return session, nil
 }
 
-func setAccessToken(ctx context.Context, session *do.SessionInfo) error {
+func (c *Cache) setAccessToken(ctx context.Context, session *do.SessionInfo) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyAccessToken, session.AccessToken)
 	sessionDataBytes, _ := json.Marshal(session)
-	res, err := Redis().Set(ctx, redisKey, sessionDataBytes, enum.AccessTokenDuration).Result()
+	res, err := c.rdb.Set(ctx, redisKey, sessionDataBytes, enum.AccessTokenDuration).Result()
 	logger.New(ctx).Debug("redis debug", "res", res, "err", err)
 	return err
 }
 
-func setRefreshToken(ctx context.Context, session *do.SessionInfo) error {
+func (c *Cache) setRefreshToken(ctx context.Context, session *do.SessionInfo) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyRefreshToken, session.RefreshToken)
 	sessionDataBytes, _ := json.Marshal(session)
-	return Redis().Set(ctx, redisKey, sessionDataBytes, enum.RefreshTokenDuration).Err()
+	return c.rdb.Set(ctx, redisKey, sessionDataBytes, enum.RefreshTokenDuration).Err()
 }
 
-func DelAccessToken(ctx context.Context, accessToken string) error {
+func (c *Cache) DelAccessToken(ctx context.Context, accessToken string) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyAccessToken, accessToken)
-	return Redis().Del(ctx, redisKey).Err()
+	return c.rdb.Del(ctx, redisKey).Err()
 }
 
 // DelayDelRefreshToken 刷新Token时让旧的RefreshToken 保留一段时间自己过期
-func DelayDelRefreshToken(ctx context.Context, refreshToken string) error {
+func (c *Cache) DelayDelRefreshToken(ctx context.Context, refreshToken string) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyRefreshToken, refreshToken)
-	return Redis().Expire(ctx, redisKey, enum.OldRefreshTokenHoldingDuration).Err()
+	return c.rdb.Expire(ctx, redisKey, enum.OldRefreshTokenHoldingDuration).Err()
 }
 
 // DelRefreshToken 直接删除RefreshToken缓存  修改密码、退出登录时使用
-func DelRefreshToken(ctx context.Context, refreshToken string) error {
+func (c *Cache) DelRefreshToken(ctx context.Context, refreshToken string) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyRefreshToken, refreshToken)
-	return Redis().Del(ctx, redisKey).Err()
+	return c.rdb.Del(ctx, redisKey).Err()
 }
 
 // DelUserSessionOnPlatform Delete user's session on specific platform
-func DelUserSessionOnPlatform(ctx context.Context, userId int64, platform string) error {
+func (c *Cache) DelUserSessionOnPlatform(ctx context.Context, userId int64, platform string) error {
 	redisKey := fmt.Sprintf(enum.RedisKeyUserSession, userId)
-	return Redis().HDel(ctx, redisKey, platform).Err()
+	return c.rdb.HDel(ctx, redisKey, platform).Err()
 }
 
 // DelUserSessions Delete user's sessions on all platform
-func DelUserSessions(ctx context.Context, userId int64) error {
+func (c *Cache) DelUserSessions(ctx context.Context, userId int64) error {
 	// 先获取所有平台上的Session信息中
-	sessions, err := GetUserAllSessions(ctx, userId)
+	sessions, err := c.GetUserAllSessions(ctx, userId)
 	if err != nil {
 		return err
 	}
 	// 把所有Session中保存的正在用的Token都过期掉
 	for _, sessInfo := range sessions {
-		DelOldSessionTokens(ctx, sessInfo)
+		c.DelOldSessionTokens(ctx, sessInfo)
 	}
 	// Token过期完成后再删掉Session
 	redisKey := fmt.Sprintf(enum.RedisKeyUserSession, userId)
-	return Redis().Del(ctx, redisKey).Err()
+	return c.rdb.Del(ctx, redisKey).Err()
 }
 
 // GetUserAllSessions 获取用户在所有platform上的Session
-func GetUserAllSessions(ctx context.Context, userId int64) (map[string]*do.SessionInfo, error) {
+func (c *Cache) GetUserAllSessions(ctx context.Context, userId int64) (map[string]*do.SessionInfo, error) {
 	redisKey := fmt.Sprintf(enum.RedisKeyUserSession, userId)
-	result, err := Redis().HGetAll(ctx, redisKey).Result()
+	result, err := c.rdb.HGetAll(ctx, redisKey).Result()
 	if err != nil && err != redis.Nil {
 		return nil, err
 	}
@@ -162,19 +162,19 @@ func GetUserAllSessions(ctx context.Context, userId int64) (map[string]*do.Sessi
 	return sessions, nil
 }
 
-func LockTokenRefresh(ctx context.Context, refreshToken string) (bool, error) {
+func (c *Cache) LockTokenRefresh(ctx context.Context, refreshToken string) (bool, error) {
 	redisLockKey := fmt.Sprintf(enum.RediskeyTokenRefreshLock, refreshToken)
-	return Redis().SetNX(ctx, redisLockKey, "locked", 10*time.Second).Result()
+	return c.rdb.SetNX(ctx, redisLockKey, "locked", 10*time.Second).Result()
 }
 
-func UnlockTokenRefresh(ctx context.Context, refreshToken string) error {
+func (c *Cache) UnlockTokenRefresh(ctx context.Context, refreshToken string) error {
 	redisLockKey := fmt.Sprintf(enum.RediskeyTokenRefreshLock, refreshToken)
-	return Redis().Del(ctx, redisLockKey).Err()
+	return c.rdb.Del(ctx, redisLockKey).Err()
 }
 
-func GetRefreshToken(ctx context.Context, refreshToken string) (*do.SessionInfo, error) {
+func (c *Cache) GetRefreshToken(ctx context.Context, refreshToken string) (*do.SessionInfo, error) {
 	redisKey := fmt.Sprintf(enum.RedisKeyRefreshToken, refreshToken)
-	result, err := Redis().Get(ctx, redisKey).Result()
+	result, err := c.rdb.Get(ctx, redisKey).Result()
 	if err != nil && err != redis.Nil {
 		return nil, err
 	}
@@ -187,9 +187,9 @@ func GetRefreshToken(ctx context.Context, refreshToken string) (*do.SessionInfo,
 	return session, nil
 }
 
-func GetAccessToken(ctx context.Context, accessToken string) (*do.SessionInfo, error) {
+func (c *Cache) GetAccessToken(ctx context.Context, accessToken string) (*do.SessionInfo, error) {
 	redisKey := fmt.Sprintf(enum.RedisKeyAccessToken, accessToken)
-	result, err := Redis().Get(ctx, redisKey).Result()
+	result, err := c.rdb.Get(ctx, redisKey).Result()
 	if err != nil && err != redis.Nil {
 		return nil, err
 	}
@@ -207,15 +207,15 @@ func GetAccessToken(ctx context.Context, accessToken string) (*do.SessionInfo, e
 // @param userId
 // @param token 重置密码的验证Token
 // @param code 验证码
-func SetPasswordResetToken(ctx context.Context, userId int64, token, code string) error {
+func (c *Cache) SetPasswordResetToken(ctx context.Context, userId int64, token, code string) error {
 	redisKey := fmt.Sprintf(enum.RediskeyPasswordresetToken, token)
 	val := fmt.Sprintf("%d:%s", userId, code) // val 以 userId:code 的字符串形式存储
-	return Redis().Set(ctx, redisKey, val, enum.PasswordTokenDuration).Err()
+	return c.rdb.Set(ctx, redisKey, val, enum.PasswordTokenDuration).Err()
 }
 
-func GetPasswordResetToken(ctx context.Context, token string) (userId int64, code string, err error) {
+func (c *Cache) GetPasswordResetToken(ctx context.Context, token string) (userId int64, code string, err error) {
 	redisKey := fmt.Sprintf(enum.RediskeyPasswordresetToken, token)
-	val, redisErr := Redis().Get(ctx, redisKey).Result()
+	val, redisErr := c.rdb.Get(ctx, redisKey).Result()
 	if redisErr != nil && !errors.Is(redisErr, redis.Nil) {
 		err = redisErr
 		return
@@ -230,7 +230,7 @@ func GetPasswordResetToken(ctx context.Context, token string) (userId int64, cod
 	return
 }
 
-func DelPasswordResetToken(ctx context.Context, token string) error {
+func (c *Cache) DelPasswordResetToken(ctx context.Context, token string) error {
 	redisKey := fmt.Sprintf(enum.RediskeyPasswordresetToken, token)
-	return Redis().Del(ctx, redisKey).Err()
+	return c.rdb.Del(ctx, redisKey).Err()
 }
@@ -0,0 +1,255 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type AfterSaleDao struct {
+	db DBProvider
+}
+
+func NewAfterSaleDao(db DBProvider) *AfterSaleDao {
+	return &AfterSaleDao{db: db}
+}
+
+// CreateAfterSale 写入售后单和申请日志. 事务内锁住订单行后重新累计各明细已占用的售后数量, 防止并发申请超出购买数量
+func (a *AfterSaleDao) CreateAfterSale(ctx context.Context, afterSale *do.AfterSale, log *do.AfterSaleLog) error {
+	afterSalePO := new(model.AfterSale)
+	if err := util.Copy(afterSalePO, afterSale); err != nil {
+		return errcode.Wrap("CreateAfterSale copy err", err)
+	}
+
+	err := a.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", afterSalePO.OrderID).
+			First(&model.Order{}).Error; err != nil {
+			return errcode.Wrap("CreateAfterSale lock order err", err)
+		}
+
+		occupied, err := sumAfterSaleQuantity(tx, afterSalePO.OrderID, do.AfterSaleActiveStates())
+		if err != nil {
+			return err
+		}
+
+		var orderItems []*model.OrderItem
+		if err = tx.Where("order_id = ?", afterSalePO.OrderID).Find(&orderItems).Error; err != nil {
+			return errcode.Wrap("CreateAfterSale find order items err", err)
+		}
+		bought := make(map[int64]int64, len(orderItems))
+		for _, item := range orderItems {
+			bought[item.ID] = item.Quantity
+		}
+
+		// 同一明细在一次申请里出现多次时数量要合计后再比较
+		for _, item := range afterSalePO.Items {
+			occupied[item.OrderItemID] += item.Quantity
+			if occupied[item.OrderItemID] > bought[item.OrderItemID] {
+				return errcode.ErrAfterSaleQuantityExceeded
+			}
+		}
+
+		if err = tx.Create(afterSalePO).Error; err != nil {
+			return errcode.Wrap("CreateAfterSale db create err", err)
+		}
+
+		log.AfterSaleID = afterSalePO.ID
+		return createAfterSaleLog(tx, log)
+	})
+	if err != nil {
+		return err
+	}
+
+	return util.Copy(afterSale, afterSalePO)
+}
+
+func (a *AfterSaleDao) FindAfterSaleByNo(ctx context.Context, ticketNo string) (*do.AfterSale, error) {
+	afterSalePO := new(model.AfterSale)
+	err := a.db.Master(ctx).Preload("Items").Where("ticket_no = ?", ticketNo).First(afterSalePO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("FindAfterSaleByNo err", err)
+	}
+
+	afterSale := new(do.AfterSale)
+	util.Copy(afterSale, afterSalePO)
+	return afterSale, nil
+}
+
+func (a *AfterSaleDao) ListAfterSales(ctx context.Context, filter *do.AfterSaleListFilter, page *do.PageQuery) ([]*do.AfterSale, int64, error) {
+	query := a.db.Conn(ctx).Model(&model.AfterSale{})
+	if filter.UserID > 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.State != nil {
+		query = query.Where("state = ?", *filter.State)
+	}
+
+	var total int64
+	if !page.Keyset {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, errcode.Wrap("ListAfterSales count err", err)
+		}
+	}
+
+	var afterSalePOs []*model.AfterSale
+	if err := query.Preload("Items").Scopes(Paginate(page, "")).Find(&afterSalePOs).Error; err != nil {
+		return nil, 0, errcode.Wrap("ListAfterSales find err", err)
+	}
+	afterSalePOs = nextPage(page, afterSalePOs, func(po *model.AfterSale) *util.Cursor { return &util.Cursor{ID: po.ID} })
+
+	afterSales := make([]*do.AfterSale, 0, len(afterSalePOs))
+	for _, po := range afterSalePOs {
+		afterSale := new(do.AfterSale)
+		util.Copy(afterSale, po)
+		afterSales = append(afterSales, afterSale)
+	}
+
+	return afterSales, total, nil
+}
+
+func (a *AfterSaleDao) ListAfterSaleLogs(ctx context.Context, afterSaleID int64) ([]*do.AfterSaleLog, error) {
+	var logPOs []*model.AfterSaleLog
+	if err := a.db.Conn(ctx).Where("after_sale_id = ?", afterSaleID).Order("id").Find(&logPOs).Error; err != nil {
+		return nil, errcode.Wrap("ListAfterSaleLogs err", err)
+	}
+
+	logs := make([]*do.AfterSaleLog, 0, len(logPOs))
+	for _, po := range logPOs {
+		log := new(do.AfterSaleLog)
+		util.Copy(log, po)
+		logs = append(logs, log)
+	}
+
+	return logs, nil
+}
+
+// TransitAfterSale 售后单从log.FromState流转到log.ToState, 同时更新columns指定的字段并写入审计日志.
+// 售后单已不在FromState时返回false, 说明被并发处理过
+func (a *AfterSaleDao) TransitAfterSale(ctx context.Context, afterSale *do.AfterSale, log *do.AfterSaleLog, columns ...string) (bool, error) {
+	var updated bool
+	err := a.db.Conn(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		updated, err = transitAfterSale(tx, afterSale, log, columns...)
+		return
+	})
+
+	return updated, err
+}
+
+// CompleteRefund 网关退款成功后在同一事务中: 售后单置为已退款, 写审计日志, restock为true时回补SKU库存;
+// order不为nil时把订单从orderFromState流转到order.State(全部明细退完后关闭订单)
+func (a *AfterSaleDao) CompleteRefund(ctx context.Context, afterSale *do.AfterSale, log *do.AfterSaleLog, restock bool, order *do.Order, orderFromState int8) (bool, error) {
+	var updated bool
+	err := a.db.Conn(ctx).Transaction(func(tx *gorm.DB) (err error) {
+		updated, err = transitAfterSale(tx, afterSale, log, "refund_trade_no", "refunded_at")
+		if err != nil || !updated {
+			return err
+		}
+
+		if restock {
+			if err = restockAfterSaleItems(tx, afterSale.Items); err != nil {
+				return err
+			}
+		}
+
+		if order == nil {
+			return nil
+		}
+
+		if err = tx.Model(&model.Order{}).
+			Where("id = ? AND state = ?", order.ID, orderFromState).
+			Update("state", order.State).Error; err != nil {
+			return errcode.Wrap("CompleteRefund update order err", err)
+		}
+
+		return nil
+	})
+
+	return updated, err
+}
+
+func restockAfterSaleItems(tx *gorm.DB, items []*do.AfterSaleItem) error {
+	for _, item := range items {
+		if err := tx.Model(&model.CommoditySku{}).
+			Where("id = ?", item.SkuID).
+			Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+			return errcode.Wrap("CompleteRefund incr stock err", err)
+		}
+	}
+
+	return nil
+}
+
+// SumRefundedQuantity 订单各明细已退款的数量, key为订单明细ID
+func (a *AfterSaleDao) SumRefundedQuantity(ctx context.Context, orderID int64) (map[int64]int64, error) {
+	return sumAfterSaleQuantity(a.db.Master(ctx), orderID, []int8{enum.AfterSaleStateRefunded})
+}
+
+func sumAfterSaleQuantity(db *gorm.DB, orderID int64, states []int8) (map[int64]int64, error) {
+	var rows []struct {
+		OrderItemID int64
+		Quantity    int64
+	}
+	err := db.Model(&model.AfterSaleItem{}).
+		Select("after_sale_items.order_item_id, SUM(after_sale_items.quantity) AS quantity").
+		Joins("JOIN after_sales ON after_sales.id = after_sale_items.after_sale_id").
+		Where("after_sales.order_id = ? AND after_sales.state IN ? AND after_sales.is_del = 0", orderID, states).
+		Group("after_sale_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errcode.Wrap("sumAfterSaleQuantity err", err)
+	}
+
+	quantities := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+
+	return quantities, nil
+}
+
+func transitAfterSale(tx *gorm.DB, afterSale *do.AfterSale, log *do.AfterSaleLog, columns ...string) (bool, error) {
+	afterSalePO := new(model.AfterSale)
+	if err := util.Copy(afterSalePO, afterSale); err != nil {
+		return false, errcode.Wrap("transitAfterSale copy err", err)
+	}
+	afterSalePO.State = log.ToState
+	afterSalePO.Items = nil
+
+	res := tx.Model(&model.AfterSale{}).
+		Where("id = ? AND state = ?", afterSale.ID, log.FromState).
+		Select(append([]string{"state"}, columns...)).
+		Updates(afterSalePO)
+	if res.Error != nil {
+		return false, errcode.Wrap("transitAfterSale update err", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	return true, createAfterSaleLog(tx, log)
+}
+
+func createAfterSaleLog(tx *gorm.DB, log *do.AfterSaleLog) error {
+	logPO := new(model.AfterSaleLog)
+	if err := util.Copy(logPO, log); err != nil {
+		return errcode.Wrap("createAfterSaleLog copy err", err)
+	}
+
+	if err := tx.Create(logPO).Error; err != nil {
+		return errcode.Wrap("createAfterSaleLog err", err)
+	}
+
+	return nil
+}
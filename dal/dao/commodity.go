@@ -1,8 +1,124 @@
 package dao
 
+import (
+	"context"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
 type CommodityDao struct {
+	db          DBProvider
+	commodities *Repository[model.Commodity, do.Commodity]
+	skus        *Repository[model.CommoditySku, do.CommoditySku]
+	categories  *Repository[model.CommodityCategory, do.CommodityCategory]
+	stores      *Repository[model.Store, do.Store]
+}
+
+func NewCommodityDao(db DBProvider) *CommodityDao {
+	return &CommodityDao{
+		db:          db,
+		commodities: NewRepository[model.Commodity, do.Commodity](db, nil),
+		skus:        NewRepository[model.CommoditySku, do.CommoditySku](db, nil),
+		categories:  NewRepository[model.CommodityCategory, do.CommodityCategory](db, nil),
+		stores:      NewRepository[model.Store, do.Store](db, nil),
+	}
+}
+
+// FindSkusByIDs 批量查询SKU, 同时带出所属商品的信息
+func (c *CommodityDao) FindSkusByIDs(ctx context.Context, skuIDs []int64) ([]*do.CommoditySku, error) {
+	skus, err := c.skus.FindByIDs(ctx, skuIDs)
+	if err != nil {
+		return nil, errcode.Wrap("FindSkusByIDs find skus err", err)
+	}
+
+	commodityIDs := make([]int64, 0, len(skus))
+	for _, sku := range skus {
+		commodityIDs = append(commodityIDs, sku.CommodityID)
+	}
+
+	commodityList, err := c.commodities.FindByIDs(ctx, commodityIDs)
+	if err != nil {
+		return nil, errcode.Wrap("FindSkusByIDs find commodities err", err)
+	}
+	commodities := make(map[int64]*do.Commodity, len(commodityList))
+	for _, commodity := range commodityList {
+		commodities[commodity.ID] = commodity
+	}
+
+	for _, sku := range skus {
+		sku.Commodity = commodities[sku.CommodityID]
+	}
+
+	return skus, nil
+}
+
+// SaveCategories 批量写入类目, ID已存在时更新
+func (c *CommodityDao) SaveCategories(ctx context.Context, categories []*do.CommodityCategory) error {
+	if err := c.categories.Upsert(ctx, categories); err != nil {
+		return errcode.Wrap("SaveCategories err", err)
+	}
+
+	return nil
+}
+
+func (c *CommodityDao) ListCategories(ctx context.Context) ([]*do.CommodityCategory, error) {
+	categories, err := c.categories.Find(ctx, OrderBy("level, `rank` DESC"))
+	if err != nil {
+		return nil, errcode.Wrap("ListCategories err", err)
+	}
+
+	return categories, nil
+}
+
+// FindCommoditiesByIDs 批量查询商品并带出SKU最低价, 包括已下架的商品, key为商品ID
+func (c *CommodityDao) FindCommoditiesByIDs(ctx context.Context, commodityIDs []int64) (map[int64]*do.Commodity, error) {
+	if len(commodityIDs) == 0 {
+		return map[int64]*do.Commodity{}, nil
+	}
+
+	commodityList, err := c.commodities.FindByIDs(ctx, commodityIDs)
+	if err != nil {
+		return nil, errcode.Wrap("FindCommoditiesByIDs find commodities err", err)
+	}
+
+	var prices []struct {
+		CommodityID int64
+		MinPrice    int64
+	}
+	if err = c.db.Conn(ctx).Model(&model.CommoditySku{}).
+		Select("commodity_id, MIN(price) AS min_price").
+		Where("commodity_id IN ?", commodityIDs).
+		Group("commodity_id").
+		Scan(&prices).Error; err != nil {
+		return nil, errcode.Wrap("FindCommoditiesByIDs min price err", err)
+	}
+	minPrices := make(map[int64]int64, len(prices))
+	for _, price := range prices {
+		minPrices[price.CommodityID] = price.MinPrice
+	}
+
+	commodities := make(map[int64]*do.Commodity, len(commodityList))
+	for _, commodity := range commodityList {
+		commodity.MinPrice = minPrices[commodity.ID]
+		commodities[commodity.ID] = commodity
+	}
+
+	return commodities, nil
 }
 
-func NewCommodityDao() *CommodityDao {
-	return &CommodityDao{}
+// FindStoresByIDs 批量查询店铺, key为店铺ID
+func (c *CommodityDao) FindStoresByIDs(ctx context.Context, storeIDs []int64) (map[int64]*do.Store, error) {
+	storeList, err := c.stores.FindByIDs(ctx, storeIDs)
+	if err != nil {
+		return nil, errcode.Wrap("FindStoresByIDs err", err)
+	}
+
+	stores := make(map[int64]*do.Store, len(storeList))
+	for _, store := range storeList {
+		stores[store.ID] = store
+	}
+
+	return stores, nil
 }
@@ -0,0 +1,197 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type CouponDao struct {
+	db DBProvider
+}
+
+func NewCouponDao(db DBProvider) *CouponDao {
+	return &CouponDao{db: db}
+}
+
+func (c *CouponDao) CreateTemplate(ctx context.Context, template *do.CouponTemplate) error {
+	templatePO := new(model.CouponTemplate)
+	if err := util.Copy(templatePO, template); err != nil {
+		return errcode.Wrap("CreateTemplate copy err", err)
+	}
+
+	if err := c.db.Conn(ctx).Create(templatePO).Error; err != nil {
+		return errcode.Wrap("CreateTemplate db create err", err)
+	}
+
+	return util.Copy(template, templatePO)
+}
+
+func (c *CouponDao) FindTemplateByID(ctx context.Context, templateID int64) (*do.CouponTemplate, error) {
+	templatePO := new(model.CouponTemplate)
+	err := c.db.Conn(ctx).Where("id = ?", templateID).First(templatePO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("FindTemplateByID err", err)
+	}
+
+	template := new(do.CouponTemplate)
+	util.Copy(template, templatePO)
+	return template, nil
+}
+
+// ListClaimableTemplates 当前处于领取时间内的优惠券模板
+func (c *CouponDao) ListClaimableTemplates(ctx context.Context, now time.Time, page *do.PageQuery) ([]*do.CouponTemplate, int64, error) {
+	query := c.db.Conn(ctx).Model(&model.CouponTemplate{}).
+		Where("claim_start_at <= ? AND claim_end_at > ?", now, now)
+
+	var total int64
+	if !page.Keyset {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, errcode.Wrap("ListClaimableTemplates count err", err)
+		}
+	}
+
+	var templatePOs []*model.CouponTemplate
+	if err := query.Scopes(Paginate(page, "")).Find(&templatePOs).Error; err != nil {
+		return nil, 0, errcode.Wrap("ListClaimableTemplates find err", err)
+	}
+	templatePOs = nextPage(page, templatePOs, func(po *model.CouponTemplate) *util.Cursor { return &util.Cursor{ID: po.ID} })
+
+	templates := make([]*do.CouponTemplate, 0, len(templatePOs))
+	for _, po := range templatePOs {
+		template := new(do.CouponTemplate)
+		util.Copy(template, po)
+		templates = append(templates, template)
+	}
+
+	return templates, total, nil
+}
+
+// CountClaimed 模板已被领取的数量, userID大于0时只统计该用户的
+func (c *CouponDao) CountClaimed(ctx context.Context, templateID, userID int64) (int64, error) {
+	query := c.db.Master(ctx).Model(&model.UserCoupon{}).Where("template_id = ?", templateID)
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, errcode.Wrap("CountClaimed err", err)
+	}
+
+	return count, nil
+}
+
+func (c *CouponDao) CreateUserCoupon(ctx context.Context, coupon *do.UserCoupon) error {
+	couponPO := new(model.UserCoupon)
+	if err := util.Copy(couponPO, coupon); err != nil {
+		return errcode.Wrap("CreateUserCoupon copy err", err)
+	}
+	couponPO.Template = nil
+
+	if err := c.db.Conn(ctx).Create(couponPO).Error; err != nil {
+		return errcode.Wrap("CreateUserCoupon db create err", err)
+	}
+
+	coupon.ID = couponPO.ID
+	coupon.CreatedAt = couponPO.CreatedAt
+	return nil
+}
+
+// ListUserCoupons 用户的优惠券列表, state为nil时不按状态筛选
+func (c *CouponDao) ListUserCoupons(ctx context.Context, userID int64, state *int8, page *do.PageQuery) ([]*do.UserCoupon, int64, error) {
+	query := c.db.Conn(ctx).Model(&model.UserCoupon{}).Where("user_id = ?", userID)
+	if state != nil {
+		query = query.Where("state = ?", *state)
+	}
+
+	var total int64
+	if !page.Keyset {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, errcode.Wrap("ListUserCoupons count err", err)
+		}
+	}
+
+	var couponPOs []*model.UserCoupon
+	if err := query.Preload("Template").Scopes(Paginate(page, "")).Find(&couponPOs).Error; err != nil {
+		return nil, 0, errcode.Wrap("ListUserCoupons find err", err)
+	}
+	couponPOs = nextPage(page, couponPOs, func(po *model.UserCoupon) *util.Cursor { return &util.Cursor{ID: po.ID} })
+
+	return convertUserCoupons(couponPOs), total, nil
+}
+
+// FindUsableCoupons 用户当前可用于下单的全部优惠券, ids不为空时只查这些券
+func (c *CouponDao) FindUsableCoupons(ctx context.Context, userID int64, ids []int64, now time.Time) ([]*do.UserCoupon, error) {
+	query := c.db.Master(ctx).
+		Where("user_id = ? AND state = ? AND valid_from <= ? AND valid_to > ?", userID, enum.UserCouponStateUnused, now, now)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var couponPOs []*model.UserCoupon
+	if err := query.Preload("Template").Find(&couponPOs).Error; err != nil {
+		return nil, errcode.Wrap("FindUsableCoupons err", err)
+	}
+
+	return convertUserCoupons(couponPOs), nil
+}
+
+// lockCoupons 下单时把用户优惠券锁定到订单上, 有券已被使用或过期时返回ErrCouponUnavailable
+func lockCoupons(tx *gorm.DB, userID int64, orderNo string, couponIDs []int64) error {
+	res := tx.Model(&model.UserCoupon{}).
+		Where("id IN ? AND user_id = ? AND state = ? AND valid_to > ?", couponIDs, userID, enum.UserCouponStateUnused, time.Now()).
+		Updates(map[string]any{"state": enum.UserCouponStateLocked, "order_no": orderNo})
+	if res.Error != nil {
+		return errcode.Wrap("lockCoupons err", res.Error)
+	}
+	if res.RowsAffected != int64(len(couponIDs)) {
+		return errcode.ErrCouponUnavailable
+	}
+
+	return nil
+}
+
+// unlockCoupons 订单关闭时把锁定在订单上的优惠券还给用户
+func unlockCoupons(tx *gorm.DB, orderNo string) error {
+	if err := tx.Model(&model.UserCoupon{}).
+		Where("order_no = ? AND state = ?", orderNo, enum.UserCouponStateLocked).
+		Updates(map[string]any{"state": enum.UserCouponStateUnused, "order_no": ""}).Error; err != nil {
+		return errcode.Wrap("unlockCoupons err", err)
+	}
+
+	return nil
+}
+
+// UseLockedCoupons 订单支付成功后把锁定在订单上的优惠券核销
+func (c *CouponDao) UseLockedCoupons(ctx context.Context, orderNo string, usedAt time.Time) error {
+	if err := c.db.Conn(ctx).Model(&model.UserCoupon{}).
+		Where("order_no = ? AND state = ?", orderNo, enum.UserCouponStateLocked).
+		Updates(map[string]any{"state": enum.UserCouponStateUsed, "used_at": usedAt}).Error; err != nil {
+		return errcode.Wrap("UseLockedCoupons err", err)
+	}
+
+	return nil
+}
+
+func convertUserCoupons(couponPOs []*model.UserCoupon) []*do.UserCoupon {
+	coupons := make([]*do.UserCoupon, 0, len(couponPOs))
+	for _, po := range couponPOs {
+		coupon := new(do.UserCoupon)
+		util.Copy(coupon, po)
+		coupons = append(coupons, coupon)
+	}
+
+	return coupons
+}
@@ -10,10 +10,16 @@ import (
 
 type DemoDao struct {
 	ctx context.Context
+	db  DBProvider
 }
 
-func NewDemoDao(ctx context.Context) *DemoDao {
-	return &DemoDao{ctx: ctx}
+func NewDemoDao(ctx context.Context, db DBProvider) *DemoDao {
+	return &DemoDao{ctx: ctx, db: db}
+}
+
+// WithContext 返回绑定到ctx的DemoDao, 在tx.Manager.Do里用事务ctx重新绑定后, 写操作才会进入事务
+func (i *DemoDao) WithContext(ctx context.Context) *DemoDao {
+	return &DemoDao{ctx: ctx, db: i.db}
 }
 
 func (i *DemoDao) CreateDemoOrder(demoOrder *do.DemoOrder) (*model.DemoOrder, error) {
@@ -22,6 +28,6 @@ func (i *DemoDao) CreateDemoOrder(demoOrder *do.DemoOrder) (*model.DemoOrder, er
 		return nil, err
 	}
 
-	err := DB().WithContext(i.ctx).Create(po).Error
+	err := i.db.Conn(i.ctx).Create(po).Error
 	return po, err
 }
@@ -0,0 +1,85 @@
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type FavoriteDao struct {
+	db DBProvider
+}
+
+func NewFavoriteDao(db DBProvider) *FavoriteDao {
+	return &FavoriteDao{db: db}
+}
+
+// AddFavorite 添加收藏, 已收藏过时返回false
+func (f *FavoriteDao) AddFavorite(ctx context.Context, favorite *do.Favorite) (bool, error) {
+	favoritePO := new(model.Favorite)
+	if err := util.Copy(favoritePO, favorite); err != nil {
+		return false, errcode.Wrap("AddFavorite copy err", err)
+	}
+
+	res := f.db.Conn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(favoritePO)
+	if res.Error != nil {
+		return false, errcode.Wrap("AddFavorite db create err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// RemoveFavorite 取消收藏, 本来就没有收藏时返回false
+func (f *FavoriteDao) RemoveFavorite(ctx context.Context, userID int64, targetType int8, targetID int64) (bool, error) {
+	res := f.db.Conn(ctx).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Delete(&model.Favorite{})
+	if res.Error != nil {
+		return false, errcode.Wrap("RemoveFavorite err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (f *FavoriteDao) ListFavorites(ctx context.Context, userID int64, targetType int8, page *do.PageQuery) ([]*do.Favorite, int64, error) {
+	query := f.db.Conn(ctx).Model(&model.Favorite{}).Where("user_id = ? AND target_type = ?", userID, targetType)
+
+	var total int64
+	if !page.Keyset {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, errcode.Wrap("ListFavorites count err", err)
+		}
+	}
+
+	var favoritePOs []*model.Favorite
+	if err := query.Scopes(Paginate(page, "")).Find(&favoritePOs).Error; err != nil {
+		return nil, 0, errcode.Wrap("ListFavorites find err", err)
+	}
+	favoritePOs = nextPage(page, favoritePOs, func(po *model.Favorite) *util.Cursor { return &util.Cursor{ID: po.ID} })
+
+	favorites := make([]*do.Favorite, 0, len(favoritePOs))
+	for _, po := range favoritePOs {
+		favorite := new(do.Favorite)
+		util.Copy(favorite, po)
+		favorites = append(favorites, favorite)
+	}
+
+	return favorites, total, nil
+}
+
+// CountFavorites 收藏对象被收藏的总次数
+func (f *FavoriteDao) CountFavorites(ctx context.Context, targetType int8, targetID int64) (int64, error) {
+	var count int64
+	if err := f.db.Conn(ctx).Model(&model.Favorite{}).
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Count(&count).Error; err != nil {
+		return 0, errcode.Wrap("CountFavorites err", err)
+	}
+
+	return count, nil
+}
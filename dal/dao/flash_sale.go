@@ -0,0 +1,160 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type FlashSaleDao struct {
+	db DBProvider
+}
+
+func NewFlashSaleDao(db DBProvider) *FlashSaleDao {
+	return &FlashSaleDao{db: db}
+}
+
+func (f *FlashSaleDao) CreateFlashSale(ctx context.Context, flashSale *do.FlashSale) error {
+	flashSalePO := new(model.FlashSale)
+	if err := util.Copy(flashSalePO, flashSale); err != nil {
+		return errcode.Wrap("CreateFlashSale copy err", err)
+	}
+
+	if err := f.db.Conn(ctx).Create(flashSalePO).Error; err != nil {
+		return errcode.Wrap("CreateFlashSale db create err", err)
+	}
+
+	return util.Copy(flashSale, flashSalePO)
+}
+
+func (f *FlashSaleDao) FindFlashSaleByID(ctx context.Context, flashSaleID int64) (*do.FlashSale, error) {
+	flashSalePO := new(model.FlashSale)
+	err := f.db.Master(ctx).Where("id = ?", flashSaleID).First(flashSalePO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("FindFlashSaleByID err", err)
+	}
+
+	flashSale := new(do.FlashSale)
+	util.Copy(flashSale, flashSalePO)
+	return flashSale, nil
+}
+
+// ReserveStock 预热时从SKU库存中划出秒杀库存, 活动已预热过时返回false
+func (f *FlashSaleDao) ReserveStock(ctx context.Context, flashSale *do.FlashSale) (bool, error) {
+	var reserved bool
+	err := f.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.FlashSale{}).
+			Where("id = ? AND warmed = 0", flashSale.ID).
+			Update("warmed", 1)
+		if res.Error != nil {
+			return errcode.Wrap("ReserveStock update flash sale err", res.Error)
+		}
+		if reserved = res.RowsAffected > 0; !reserved {
+			return nil
+		}
+
+		res = tx.Model(&model.CommoditySku{}).
+			Where("id = ? AND stock >= ?", flashSale.SkuID, flashSale.TotalStock).
+			Update("stock", gorm.Expr("stock - ?", flashSale.TotalStock))
+		if res.Error != nil {
+			return errcode.Wrap("ReserveStock decr stock err", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return errcode.ErrCommodityStockNotEnough
+		}
+
+		return nil
+	})
+
+	return reserved, err
+}
+
+// Settle 结算活动, 没卖完的unsold件库存退回SKU. 活动已结算过时返回false
+func (f *FlashSaleDao) Settle(ctx context.Context, flashSale *do.FlashSale, unsold int64) (bool, error) {
+	var settled bool
+	err := f.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.FlashSale{}).
+			Where("id = ? AND warmed = 1 AND settled = 0", flashSale.ID).
+			Update("settled", 1)
+		if res.Error != nil {
+			return errcode.Wrap("Settle update flash sale err", res.Error)
+		}
+		if settled = res.RowsAffected > 0; !settled || unsold == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&model.CommoditySku{}).
+			Where("id = ?", flashSale.SkuID).
+			Update("stock", gorm.Expr("stock + ?", unsold)).Error; err != nil {
+			return errcode.Wrap("Settle incr stock err", err)
+		}
+
+		return nil
+	})
+
+	return settled, err
+}
+
+// CountOrders 活动已生成的订单数, 用于重新预热时计算剩余库存
+func (f *FlashSaleDao) CountOrders(ctx context.Context, flashSaleID int64) (int64, error) {
+	var count int64
+	if err := f.db.Master(ctx).Model(&model.FlashSaleOrder{}).
+		Where("flash_sale_id = ?", flashSaleID).
+		Count(&count).Error; err != nil {
+		return 0, errcode.Wrap("CountOrders err", err)
+	}
+
+	return count, nil
+}
+
+// FindOrderNo 用户在活动中已生成的订单号, 没有时返回空
+func (f *FlashSaleDao) FindOrderNo(ctx context.Context, flashSaleID, userID int64) (string, error) {
+	po := new(model.FlashSaleOrder)
+	err := f.db.Master(ctx).Where("flash_sale_id = ? AND user_id = ?", flashSaleID, userID).First(po).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errcode.Wrap("FindOrderNo err", err)
+	}
+
+	return po.OrderNo, nil
+}
+
+// CreateOrder 写入秒杀订单和活动关联. 库存在预热时已经从SKU划出, 这里不再扣减
+func (f *FlashSaleDao) CreateOrder(ctx context.Context, flashSaleID int64, order *do.Order) error {
+	orderPO := new(model.Order)
+	if err := util.Copy(orderPO, order); err != nil {
+		return errcode.Wrap("FlashSaleDao CreateOrder copy err", err)
+	}
+
+	err := f.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&model.FlashSaleOrder{
+			FlashSaleID: flashSaleID,
+			UserID:      order.UserID,
+			OrderNo:     order.OrderNo,
+		}).Error; err != nil {
+			return errcode.Wrap("FlashSaleDao CreateOrder create relation err", err)
+		}
+
+		if err := tx.Create(orderPO).Error; err != nil {
+			return errcode.Wrap("FlashSaleDao CreateOrder db create err", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return util.Copy(order, orderPO)
+}
@@ -1,49 +1,247 @@
 package dao
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
+	"github.com/kackerx/go-mall/common/logger"
 	"github.com/kackerx/go-mall/config"
+	"github.com/kackerx/go-mall/dal/tx"
 )
 
-var (
-	_DbMaster *gorm.DB
-	_DbSlave  *gorm.DB
+// DBProvider DAO拿数据库连接的入口, ctx上有tx.Manager开启的事务时两个方法都返回事务连接.
+// 单测里可以换成连sqlite或者mock的实现
+type DBProvider interface {
+	// Conn 按语句自动选库: 写操作和事务走主库, 读走从库, 同一请求写过之后的读也走主库
+	Conn(ctx context.Context) *gorm.DB
+	// Master 直连主库, 用于跨请求也必须读到最新数据的场景, 比如状态流转前的查询
+	Master(ctx context.Context) *gorm.DB
+}
+
+// DB 一个主库和若干从库的连接池
+type DB struct {
+	master *gorm.DB // 直连主库, 不做路由
+	routed *gorm.DB // 和master共用连接池, 注册了读写路由的回调
+
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+var _ DBProvider = (*DB)(nil)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	replicaPingTimeout         = time.Second
 )
 
-func DB() *gorm.DB {
-	return _DbSlave
+// NewDB 按配置连接主库和所有从库, 连接不上直接返回错误, 由调用方决定是否退出
+func NewDB(cfg *config.DB) (*DB, error) {
+	if cfg == nil || cfg.Master == nil || cfg.Slave == nil {
+		return nil, errors.New("db config missing master or slave")
+	}
+
+	master, err := openDB(cfg.Master)
+	if err != nil {
+		return nil, err
+	}
+	d := &DB{master: master}
+
+	if d.routed, err = newRoutedDB(master); err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+	if err = d.routed.Use(&resolver{db: d}); err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+
+	for i, option := range append([]*config.DBConnectOption{cfg.Slave}, cfg.Replicas...) {
+		db, err := openDB(option)
+		if err != nil {
+			_ = d.Close()
+			return nil, fmt.Errorf("open replica %d: %w", i, err)
+		}
+		r := &replica{name: fmt.Sprintf("replica-%d", i), db: db}
+		r.healthy.Store(true)
+		d.replicas = append(d.replicas, r)
+	}
+
+	return d, nil
 }
 
-func DBMaster() *gorm.DB {
-	return _DbMaster
+func (d *DB) Conn(ctx context.Context) *gorm.DB {
+	if db, ok := tx.FromContext(ctx); ok {
+		return db.WithContext(ctx)
+	}
+
+	return d.routed.WithContext(ctx)
+}
+
+func (d *DB) Master(ctx context.Context) *gorm.DB {
+	if db, ok := tx.FromContext(ctx); ok {
+		return db.WithContext(ctx)
+	}
+
+	return d.master.WithContext(ctx)
+}
+
+// WatchReplicas 定时探活从库, 探活失败的从库移出轮询, 恢复后加回, ctx取消时退出
+func (d *DB) WatchReplicas(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.checkReplicas(ctx)
+		}
+	}
 }
 
-func init() {
-	_DbMaster = initDB(config.Conf.DB.Master)
-	_DbSlave = initDB(config.Conf.DB.Slave)
+func (d *DB) checkReplicas(ctx context.Context) {
+	for _, r := range d.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := pingDB(pingCtx, r.db)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logger.New(ctx).Info("db replica recovered", "replica", r.name)
+			} else {
+				logger.New(ctx).Error("db replica unhealthy, removed from rotation", "replica", r.name, "err", err)
+			}
+		}
+	}
 }
 
-func initDB(option *config.DBConnectOption) *gorm.DB {
-	db, err := gorm.Open(mysql.Open(option.Dsn), &gorm.Config{
+// pickReplica 在健康的从库间轮询, 全部不可用时返回nil, 由调用方回落到主库
+func (d *DB) pickReplica() *replica {
+	n := len(d.replicas)
+	start := d.next.Add(1)
+	for i := 0; i < n; i++ {
+		r := d.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// PingMaster 就绪检查用, 检查主库连接是否可用
+func (d *DB) PingMaster(ctx context.Context) error {
+	return pingDB(ctx, d.master)
+}
+
+// PingSlave 就绪检查用, 有一个从库可用就算正常; 从库全挂时读会回落到主库, 这里只是暴露出来
+func (d *DB) PingSlave(ctx context.Context) error {
+	var errs []error
+	for _, r := range d.replicas {
+		err := pingDB(ctx, r.db)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close 关闭主从连接池, routed和master共用连接池, 不需要单独关闭
+func (d *DB) Close() error {
+	var errs []error
+	if d.master != nil {
+		errs = append(errs, closeDB(d.master))
+	}
+	for _, r := range d.replicas {
+		errs = append(errs, closeDB(r.db))
+	}
+
+	return errors.Join(errs...)
+}
+
+func gormConfig() *gorm.Config {
+	return &gorm.Config{
 		Logger:                                   NewGormLogger(500 * time.Millisecond),
 		DisableForeignKeyConstraintWhenMigrating: true,
-	})
+	}
+}
+
+func openDB(option *config.DBConnectOption) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(option.Dsn), gormConfig())
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if err = db.Use(sqlTracer{}); err != nil {
+		return nil, err
 	}
 
-	sqlDb, _ := db.DB()
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
 	sqlDb.SetMaxOpenConns(option.MaxOpen)
 	sqlDb.SetConnMaxLifetime(time.Duration(option.MaxLiftTime) * time.Second * 60)
 	sqlDb.SetMaxIdleConns(option.MaxIdle)
 
 	if err = sqlDb.Ping(); err != nil {
-		panic(err)
+		_ = sqlDb.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// newRoutedDB 复用主库的连接池再开一个gorm实例, 路由回调只注册在这个实例上, 不影响master
+func newRoutedDB(master *gorm.DB) (*gorm.DB, error) {
+	sqlDb, err := master.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDb}), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Use(sqlTracer{}); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func pingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDb.PingContext(ctx)
+}
+
+func closeDB(db *gorm.DB) error {
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
 	}
 
-	return db
+	return sqlDb.Close()
 }
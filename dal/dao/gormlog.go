@@ -2,11 +2,18 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 
 	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/metrics"
+	"github.com/kackerx/go-mall/common/tracing"
 )
 
 type GormLogger struct {
@@ -30,10 +37,16 @@ func (g *GormLogger) Error(ctx context.Context, s string, data ...interface{}) {
 }
 
 func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
-	dura := time.Since(begin).Milliseconds()
+	elapsed := time.Since(begin)
+	dura := elapsed.Milliseconds()
 
 	// 错误日志
 	sql, rows := fc()
+	metricErr := err
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		metricErr = nil
+	}
+	metrics.ObserveSQL(sql, elapsed, metricErr)
 	if err != nil {
 		logger.New(ctx).Error(
 			"SQL ERROR",
@@ -51,6 +64,62 @@ func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql
 	}
 }
 
+const sqlTraceBeginKey = "gomall:trace_begin"
+
+// sqlTracer gorm插件, 每条语句补一个子span. 在回调里拿还没填参数的SQL, 参数里的手机号、密码哈希这类数据不进链路
+type sqlTracer struct{}
+
+func (sqlTracer) Name() string {
+	return "gomall:tracing"
+}
+
+func (t sqlTracer) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return firstErr(
+		cb.Create().Before("gorm:create").Register("gomall:trace_begin", t.begin),
+		cb.Create().After("gorm:create").Register("gomall:trace_end", t.end),
+		cb.Query().Before("gorm:query").Register("gomall:trace_begin", t.begin),
+		cb.Query().After("gorm:query").Register("gomall:trace_end", t.end),
+		cb.Update().Before("gorm:update").Register("gomall:trace_begin", t.begin),
+		cb.Update().After("gorm:update").Register("gomall:trace_end", t.end),
+		cb.Delete().Before("gorm:delete").Register("gomall:trace_begin", t.begin),
+		cb.Delete().After("gorm:delete").Register("gomall:trace_end", t.end),
+		cb.Row().Before("gorm:row").Register("gomall:trace_begin", t.begin),
+		cb.Row().After("gorm:row").Register("gomall:trace_end", t.end),
+		cb.Raw().Before("gorm:raw").Register("gomall:trace_begin", t.begin),
+		cb.Raw().After("gorm:raw").Register("gomall:trace_end", t.end),
+	)
+}
+
+func (sqlTracer) begin(db *gorm.DB) {
+	db.InstanceSet(sqlTraceBeginKey, time.Now())
+}
+
+func (sqlTracer) end(db *gorm.DB) {
+	stmt := db.Statement
+	begin, ok := db.InstanceGet(sqlTraceBeginKey)
+	if !ok || stmt.SQL.Len() == 0 {
+		return
+	}
+
+	sql := stmt.SQL.String()
+	_, span := tracing.Tracer().Start(stmt.Context, "SQL "+metrics.SQLOperation(sql),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(begin.(time.Time)),
+		trace.WithAttributes(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.statement", sql),
+			attribute.String("db.sql.table", stmt.Table),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		),
+	)
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 func NewGormLogger(slowThreshold time.Duration) *GormLogger {
 	return &GormLogger{SlowThreshold: slowThreshold}
 }
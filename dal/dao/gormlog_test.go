@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/kackerx/go-mall/dal/model"
+)
+
+func TestSQLTracerParameterized(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	db := newDryRunDB(t)
+	if err := db.Use(sqlTracer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db.WithContext(context.Background()).Where("user_name = ?", "13800000000").First(&model.User{})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "SQL select" {
+		t.Fatalf("spans = %v", spans)
+	}
+	attrs := make(map[string]string)
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if stmt := attrs["db.statement"]; strings.Contains(stmt, "13800000000") || !strings.Contains(stmt, "user_name = ?") {
+		t.Errorf("db.statement = %s, want parameterized", stmt)
+	}
+	if attrs["db.sql.table"] != "users" {
+		t.Errorf("db.sql.table = %s, want users", attrs["db.sql.table"])
+	}
+}
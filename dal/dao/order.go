@@ -0,0 +1,196 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type OrderDao struct {
+	db DBProvider
+}
+
+func NewOrderDao(db DBProvider) *OrderDao {
+	return &OrderDao{db: db}
+}
+
+// CreateOrder 扣减SKU库存, 锁定使用的优惠券并写入订单和明细, 在同一个事务中完成
+func (o *OrderDao) CreateOrder(ctx context.Context, order *do.Order) error {
+	orderPO := new(model.Order)
+	if err := util.Copy(orderPO, order); err != nil {
+		return errcode.Wrap("CreateOrder copy err", err)
+	}
+
+	err := o.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range orderPO.Items {
+			res := tx.Model(&model.CommoditySku{}).
+				Where("id = ? AND stock >= ?", item.SkuID, item.Quantity).
+				Update("stock", gorm.Expr("stock - ?", item.Quantity))
+			if res.Error != nil {
+				return errcode.Wrap("CreateOrder decr stock err", res.Error)
+			}
+			if res.RowsAffected == 0 {
+				return errcode.ErrCommodityStockNotEnough
+			}
+		}
+
+		if len(order.CouponIDs) > 0 {
+			if err := lockCoupons(tx, order.UserID, order.OrderNo, order.CouponIDs); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(orderPO).Error; err != nil {
+			return errcode.Wrap("CreateOrder db create err", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return util.Copy(order, orderPO)
+}
+
+// MarkOrderPaid 订单从fromState流转到已支付, 订单已不在fromState(如超时关闭后才支付成功)时返回false
+func (o *OrderDao) MarkOrderPaid(ctx context.Context, order *do.Order, fromState int8) (bool, error) {
+	res := o.db.Conn(ctx).Model(&model.Order{}).
+		Where("id = ? AND state = ?", order.ID, fromState).
+		Updates(map[string]any{"state": order.State, "paid_at": order.PaidAt})
+	if res.Error != nil {
+		return false, errcode.Wrap("MarkOrderPaid err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// FindExpiredOrders 超过支付截止时间仍未支付的订单, 按id升序最多返回limit个
+func (o *OrderDao) FindExpiredOrders(ctx context.Context, now time.Time, limit int) ([]*do.Order, error) {
+	var orderPOs []*model.Order
+	if err := o.db.Master(ctx).Preload("Items").
+		Where("state = ? AND expire_at < ?", enum.OrderStateCreated, now).
+		Order("id").Limit(limit).
+		Find(&orderPOs).Error; err != nil {
+		return nil, errcode.Wrap("FindExpiredOrders err", err)
+	}
+
+	orders := make([]*do.Order, 0, len(orderPOs))
+	for _, po := range orderPOs {
+		order := new(do.Order)
+		util.Copy(order, po)
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// CloseUnpaidOrder 未支付订单流转为已关闭, 同一事务内解锁订单上的优惠券并归还SKU库存.
+// 秒杀订单的库存在活动预热时已经划出, 由活动结算统一归还, 这里不加回SKU.
+// 订单已不是待支付(如关闭前刚支付成功)时返回false, 不做任何修改
+func (o *OrderDao) CloseUnpaidOrder(ctx context.Context, order *do.Order) (bool, error) {
+	closed := false
+	err := o.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Order{}).
+			Where("id = ? AND state = ?", order.ID, enum.OrderStateCreated).
+			Update("state", enum.OrderStateClosed)
+		if res.Error != nil {
+			return errcode.Wrap("CloseUnpaidOrder update state err", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		closed = true
+
+		if err := unlockCoupons(tx, order.OrderNo); err != nil {
+			return err
+		}
+
+		var flashSaleOrders int64
+		if err := tx.Model(&model.FlashSaleOrder{}).Where("order_no = ?", order.OrderNo).Count(&flashSaleOrders).Error; err != nil {
+			return errcode.Wrap("CloseUnpaidOrder find flash sale order err", err)
+		}
+		if flashSaleOrders > 0 {
+			return nil
+		}
+		for _, item := range order.Items {
+			if err := tx.Model(&model.CommoditySku{}).
+				Where("id = ?", item.SkuID).
+				Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+				return errcode.Wrap("CloseUnpaidOrder incr stock err", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if closed {
+		order.State = enum.OrderStateClosed
+	}
+
+	return closed, nil
+}
+
+// LockOrder 锁住订单行直到事务结束, 需要在事务中调用, 用来串行化同一订单上的并发操作
+func (o *OrderDao) LockOrder(ctx context.Context, orderID int64) error {
+	var ids []int64
+	if err := o.db.Master(ctx).Model(&model.Order{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", orderID).
+		Pluck("id", &ids).Error; err != nil {
+		return errcode.Wrap("LockOrder err", err)
+	}
+
+	return nil
+}
+
+func (o *OrderDao) FindOrderByNo(ctx context.Context, orderNo string) (*do.Order, error) {
+	orderPO := new(model.Order)
+	err := o.db.Master(ctx).Preload("Items").Where("order_no = ?", orderNo).First(orderPO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("FindOrderByNo err", err)
+	}
+
+	order := new(do.Order)
+	util.Copy(order, orderPO)
+	return order, nil
+}
+
+// FindOrderByItemID 按订单明细查询所属订单
+func (o *OrderDao) FindOrderByItemID(ctx context.Context, orderItemID int64) (*do.Order, error) {
+	itemPO := new(model.OrderItem)
+	err := o.db.Master(ctx).Where("id = ?", orderItemID).First(itemPO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("FindOrderByItemID find item err", err)
+	}
+
+	orderPO := new(model.Order)
+	err = o.db.Master(ctx).Preload("Items").Where("id = ?", itemPO.OrderID).First(orderPO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("FindOrderByItemID find order err", err)
+	}
+
+	order := new(do.Order)
+	util.Copy(order, orderPO)
+	return order, nil
+}
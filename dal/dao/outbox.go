@@ -0,0 +1,122 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type OutboxDao struct {
+	db DBProvider
+}
+
+func NewOutboxDao(db DBProvider) *OutboxDao {
+	return &OutboxDao{db: db}
+}
+
+// CreateEvent 写入待发布事件, 需要和业务数据在同一个事务中调用
+func (o *OutboxDao) CreateEvent(ctx context.Context, event *do.OutboxEvent) error {
+	eventPO := new(model.OutboxEvent)
+	if err := util.Copy(eventPO, event); err != nil {
+		return errcode.Wrap("CreateEvent copy err", err)
+	}
+
+	if err := o.db.Conn(ctx).Create(eventPO).Error; err != nil {
+		return errcode.Wrap("CreateEvent db create err", err)
+	}
+
+	event.ID = eventPO.ID
+	return nil
+}
+
+// ClaimDueEvents 在一个短事务里认领到期待投递的事件, 认领到leaseUntil为止. 多个中继实例用SKIP LOCKED各取各的,
+// 认领后事务就提交, 投递在事务外进行; 认领到期还没写结果的事件可以被其他中继重新认领
+func (o *OutboxDao) ClaimDueEvents(ctx context.Context, owner string, now, leaseUntil time.Time, limit int) ([]*do.OutboxEvent, error) {
+	var eventPOs []*model.OutboxEvent
+	err := o.db.Master(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_retry_at <= ? AND lease_until <= ?", enum.OutboxStatusPending, now, now).
+			Order("id").
+			Limit(limit).
+			Find(&eventPOs).Error; err != nil {
+			return errcode.Wrap("ClaimDueEvents find err", err)
+		}
+		if len(eventPOs) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, 0, len(eventPOs))
+		for _, po := range eventPOs {
+			ids = append(ids, po.ID)
+		}
+		if err := tx.Model(&model.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"lease_owner": owner, "lease_until": leaseUntil}).Error; err != nil {
+			return errcode.Wrap("ClaimDueEvents update err", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*do.OutboxEvent, 0, len(eventPOs))
+	for _, po := range eventPOs {
+		event := new(do.OutboxEvent)
+		util.Copy(event, po)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// MarkPublished 只更新owner还持有认领的事件, 认领过期被别的中继取走时以后者的结果为准
+func (o *OutboxDao) MarkPublished(ctx context.Context, id int64, owner string, publishedAt time.Time) error {
+	if err := o.db.Conn(ctx).Model(&model.OutboxEvent{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Updates(map[string]any{"status": enum.OutboxStatusPublished, "published_at": publishedAt}).Error; err != nil {
+		return errcode.Wrap("MarkPublished err", err)
+	}
+
+	return nil
+}
+
+// MarkFailed 记录一次投递失败并释放认领, event里是更新后的重试次数、下次重试时间和状态
+func (o *OutboxDao) MarkFailed(ctx context.Context, event *do.OutboxEvent, owner string, now time.Time) error {
+	if err := o.db.Conn(ctx).Model(&model.OutboxEvent{}).
+		Where("id = ? AND lease_owner = ?", event.ID, owner).
+		Updates(map[string]any{
+			"status":        event.Status,
+			"attempts":      event.Attempts,
+			"next_retry_at": event.NextRetryAt,
+			"last_error":    event.LastError,
+			"lease_until":   now,
+		}).Error; err != nil {
+		return errcode.Wrap("MarkFailed err", err)
+	}
+
+	return nil
+}
+
+// ReleaseLease 释放还没来得及投递的事件, 不用等认领过期就能被再次认领
+func (o *OutboxDao) ReleaseLease(ctx context.Context, ids []int64, owner string, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := o.db.Conn(ctx).Model(&model.OutboxEvent{}).
+		Where("id IN ? AND lease_owner = ?", ids, owner).
+		Update("lease_until", now).Error; err != nil {
+		return errcode.Wrap("ReleaseLease err", err)
+	}
+
+	return nil
+}
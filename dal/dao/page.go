@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// Paginate 分页的GORM scope, 列表按column倒序、id倒序排列, column为空时只按id倒序.
+// 游标模式从page.After之后开始, 多取一行用来判断是否还有下一页, 查询后要用nextPage去掉多取的行.
+// 列名都带上当前表名, join其他表时不会有歧义
+func Paginate(page *do.PageQuery, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id := clause.Column{Table: clause.CurrentTable, Name: "id"}
+		sortCol := clause.Column{Table: clause.CurrentTable, Name: column}
+		if column != "" {
+			db = db.Order(clause.OrderByColumn{Column: sortCol, Desc: true})
+		}
+		db = db.Order(clause.OrderByColumn{Column: id, Desc: true})
+		if !page.Keyset {
+			return db.Offset(page.Offset).Limit(page.Limit)
+		}
+
+		if after := page.After; after != nil {
+			if column == "" {
+				db = db.Where(clause.Lt{Column: id, Value: after.ID})
+			} else if key := after.SortKey(); key != nil {
+				db = db.Where(clause.Or(
+					clause.Lt{Column: sortCol, Value: key},
+					clause.And(clause.Eq{Column: sortCol, Value: key}, clause.Lt{Column: id, Value: after.ID}),
+				))
+			} else {
+				// 只有id的游标不能用在按其他列排序的列表上
+				_ = db.AddError(util.ErrInvalidCursor)
+			}
+		}
+		return db.Limit(page.Limit + 1)
+	}
+}
+
+// nextPage 游标模式下去掉Paginate多取的一行, 有多取的行说明还有下一页, 用本页最后一行生成page.Next.
+// cursorOf要带上Paginate用的排序列的值, 只按id排序时只填ID
+func nextPage[T any](page *do.PageQuery, rows []*T, cursorOf func(*T) *util.Cursor) []*T {
+	page.Next = nil
+	if !page.Keyset || len(rows) <= page.Limit {
+		return rows
+	}
+
+	rows = rows[:page.Limit]
+	page.Next = cursorOf(rows[len(rows)-1])
+	return rows
+}
@@ -0,0 +1,68 @@
+package dao
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+func TestPaginate(t *testing.T) {
+	db := newDryRunDB(t)
+
+	tests := []struct {
+		page   *do.PageQuery
+		column string
+		want   string
+	}{
+		{&do.PageQuery{Offset: 20, Limit: 10}, "",
+			"SELECT * FROM `reviews` WHERE `reviews`.`is_del` = 0 ORDER BY `reviews`.`id` DESC LIMIT 10 OFFSET 20"},
+		{&do.PageQuery{Limit: 10, Keyset: true}, "",
+			"SELECT * FROM `reviews` WHERE `reviews`.`is_del` = 0 ORDER BY `reviews`.`id` DESC LIMIT 11"},
+		{&do.PageQuery{Limit: 10, Keyset: true, After: &util.Cursor{ID: 99}}, "",
+			"SELECT * FROM `reviews` WHERE `reviews`.`id` < 99 AND `reviews`.`is_del` = 0 ORDER BY `reviews`.`id` DESC LIMIT 11"},
+		{&do.PageQuery{Offset: 20, Limit: 10}, "rating",
+			"SELECT * FROM `reviews` WHERE `reviews`.`is_del` = 0 ORDER BY `reviews`.`rating` DESC,`reviews`.`id` DESC LIMIT 10 OFFSET 20"},
+		// 排序键相同的行再按id往后翻
+		{&do.PageQuery{Limit: 10, Keyset: true, After: util.IntCursor(4, 99)}, "rating",
+			"SELECT * FROM `reviews` WHERE (`reviews`.`rating` < 4 OR (`reviews`.`rating` = 4 AND `reviews`.`id` < 99)) AND `reviews`.`is_del` = 0 ORDER BY `reviews`.`rating` DESC,`reviews`.`id` DESC LIMIT 11"},
+	}
+	for _, tt := range tests {
+		got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return tx.Scopes(Paginate(tt.page, tt.column)).Find(&[]*model.Review{})
+		})
+		if got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+
+	// 只有id的游标不能用在按其他列排序的列表上
+	page := &do.PageQuery{Limit: 10, Keyset: true, After: &util.Cursor{ID: 99}}
+	if err := db.Scopes(Paginate(page, "rating")).Find(&[]*model.Review{}).Error; !errors.Is(err, util.ErrInvalidCursor) {
+		t.Errorf("cursor without sort key err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	cursorOf := func(id *int64) *util.Cursor { return &util.Cursor{ID: *id} }
+	ids := func(n int) []*int64 {
+		rows := make([]*int64, n)
+		for i := range rows {
+			id := int64(100 - i)
+			rows[i] = &id
+		}
+		return rows
+	}
+
+	page := &do.PageQuery{Limit: 3, Keyset: true}
+	if rows := nextPage(page, ids(4), cursorOf); len(rows) != 3 || page.Next == nil || page.Next.ID != 98 {
+		t.Errorf("rows=%d next=%+v, want 3 rows and next after 98", len(rows), page.Next)
+	}
+	if rows := nextPage(page, ids(3), cursorOf); len(rows) != 3 || page.Next != nil {
+		t.Errorf("last page: rows=%d next=%+v", len(rows), page.Next)
+	}
+}
@@ -0,0 +1,126 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type PaymentDao struct {
+	db DBProvider
+}
+
+func NewPaymentDao(db DBProvider) *PaymentDao {
+	return &PaymentDao{db: db}
+}
+
+func (p *PaymentDao) CreatePayment(ctx context.Context, payment *do.Payment) error {
+	paymentPO := new(model.Payment)
+	if err := util.Copy(paymentPO, payment); err != nil {
+		return errcode.Wrap("CreatePayment copy err", err)
+	}
+
+	if err := p.db.Conn(ctx).Create(paymentPO).Error; err != nil {
+		return errcode.Wrap("CreatePayment db create err", err)
+	}
+
+	payment.ID = paymentPO.ID
+	return nil
+}
+
+func (p *PaymentDao) FindPaymentByIdempotencyKey(ctx context.Context, key string) (*do.Payment, error) {
+	return p.findPayment(ctx, "idempotency_key = ?", key)
+}
+
+func (p *PaymentDao) FindPaymentByNo(ctx context.Context, paymentNo string) (*do.Payment, error) {
+	return p.findPayment(ctx, "payment_no = ?", paymentNo)
+}
+
+// FindSuccessPaymentByOrderID 订单支付成功的那一笔支付单, 退款时原路退回
+func (p *PaymentDao) FindSuccessPaymentByOrderID(ctx context.Context, orderID int64) (*do.Payment, error) {
+	return p.findPayment(ctx, "order_id = ? AND status = ?", orderID, enum.PaymentStatusSuccess)
+}
+
+// FindActivePaymentByOrderID 订单上待支付或已成功的支付单, 一个订单同时只允许有一笔, 需要先锁住订单行再查
+func (p *PaymentDao) FindActivePaymentByOrderID(ctx context.Context, orderID int64) (*do.Payment, error) {
+	return p.findPayment(ctx, "order_id = ? AND status IN ?", orderID, []int{enum.PaymentStatusPending, enum.PaymentStatusSuccess})
+}
+
+func (p *PaymentDao) findPayment(ctx context.Context, query string, args ...any) (*do.Payment, error) {
+	paymentPO := new(model.Payment)
+	err := p.db.Master(ctx).Where(query, args...).First(paymentPO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("findPayment err", err)
+	}
+
+	payment := new(do.Payment)
+	util.Copy(payment, paymentPO)
+	return payment, nil
+}
+
+// UpdateGatewayTrade 记录网关下单返回的流水号和支付地址
+func (p *PaymentDao) UpdateGatewayTrade(ctx context.Context, payment *do.Payment) error {
+	err := p.db.Conn(ctx).Model(&model.Payment{}).
+		Where("id = ?", payment.ID).
+		Updates(map[string]any{"trade_no": payment.TradeNo, "pay_url": payment.PayURL}).Error
+	if err != nil {
+		return errcode.Wrap("UpdateGatewayTrade err", err)
+	}
+
+	return nil
+}
+
+// MarkPaymentFailed 待支付->支付失败, 返回是否由本次调用完成了状态变更
+func (p *PaymentDao) MarkPaymentFailed(ctx context.Context, payment *do.Payment) (bool, error) {
+	res := p.db.Conn(ctx).Model(&model.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, enum.PaymentStatusPending).
+		Updates(map[string]any{
+			"status":      enum.PaymentStatusFailed,
+			"trade_no":    payment.TradeNo,
+			"fail_reason": payment.FailReason,
+		})
+	if res.Error != nil {
+		return false, errcode.Wrap("MarkPaymentFailed err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// MarkPaymentSuccess 待支付->支付成功, 重复回调时支付单已不是待支付状态, 返回false.
+// 订单流转和优惠券核销由领域服务放在同一个事务里完成
+func (p *PaymentDao) MarkPaymentSuccess(ctx context.Context, payment *do.Payment) (bool, error) {
+	res := p.db.Conn(ctx).Model(&model.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, enum.PaymentStatusPending).
+		Updates(map[string]any{
+			"status":   enum.PaymentStatusSuccess,
+			"trade_no": payment.TradeNo,
+			"paid_at":  payment.PaidAt,
+		})
+	if res.Error != nil {
+		return false, errcode.Wrap("MarkPaymentSuccess err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// MarkPaymentRefunded 支付成功->已退款, 用于订单已无法支付时原路退回, 重复调用返回false
+func (p *PaymentDao) MarkPaymentRefunded(ctx context.Context, payment *do.Payment) (bool, error) {
+	res := p.db.Conn(ctx).Model(&model.Payment{}).
+		Where("id = ? AND status = ?", payment.ID, enum.PaymentStatusSuccess).
+		Updates(map[string]any{"status": enum.PaymentStatusRefunded, "fail_reason": payment.FailReason})
+	if res.Error != nil {
+		return false, errcode.Wrap("MarkPaymentRefunded err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,266 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// Converter model和do之间的转换, 字段能按名字对上的用CopyConverter, 对不上的自己实现
+type Converter[M, D any] interface {
+	ToDO(m *M) (*D, error)
+	ToModel(d *D) (*M, error)
+}
+
+// CopyConverter 用util.Copy按同名字段复制
+type CopyConverter[M, D any] struct{}
+
+func (CopyConverter[M, D]) ToDO(m *M) (*D, error) {
+	d := new(D)
+	if err := util.Copy(d, m); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (CopyConverter[M, D]) ToModel(d *D) (*M, error) {
+	m := new(M)
+	if err := util.Copy(m, d); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Spec 查询条件, 本身就是GORM scope, 可以和Paginate等scope混用
+type Spec func(db *gorm.DB) *gorm.DB
+
+// Where 过滤条件, 写法同gorm.DB.Where
+func Where(query any, args ...any) Spec {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// OrderBy 排序, 写法同gorm.DB.Order, 用于Find和First; List的排序由ListBy指定, 不要再传
+func OrderBy(order string) Spec {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(order)
+	}
+}
+
+// Preload 预加载关联, 写法同gorm.DB.Preload
+func Preload(query string, args ...any) Spec {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload(query, args...)
+	}
+}
+
+// WithDeleted 查询结果包含已软删除的行
+func WithDeleted() Spec {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+}
+
+// Model Repository要求的gorm model, 主键是int64类型的ID. GetID用值接收者, M本身就满足约束
+type Model interface {
+	GetID() int64
+}
+
+// Repository 单表的通用读写, M是gorm model, D是对应的领域对象.
+// 带软删除字段的model查询时自动过滤已删除的行, Delete是软删除
+type Repository[M Model, D any] struct {
+	db   DBProvider
+	conv Converter[M, D]
+	name string // 错误信息里的model名
+}
+
+// NewRepository conv为nil时用CopyConverter
+func NewRepository[M Model, D any](db DBProvider, conv Converter[M, D]) *Repository[M, D] {
+	if conv == nil {
+		conv = CopyConverter[M, D]{}
+	}
+
+	return &Repository[M, D]{db: db, conv: conv, name: reflect.TypeOf((*M)(nil)).Elem().Name()}
+}
+
+// Create 写入一行, fill用来补充do上没有的字段, 如密码哈希; 返回写入后的model, 带自增ID和时间戳
+func (r *Repository[M, D]) Create(ctx context.Context, d *D, fill ...func(m *M)) (*M, error) {
+	m, err := r.conv.ToModel(d)
+	if err != nil {
+		return nil, r.wrap("Create convert", err)
+	}
+	for _, f := range fill {
+		f(m)
+	}
+
+	if err = r.db.Conn(ctx).Create(m).Error; err != nil {
+		return nil, r.wrap("Create", err)
+	}
+	return m, nil
+}
+
+// Upsert 批量写入, 主键或唯一键冲突时更新所有字段
+func (r *Repository[M, D]) Upsert(ctx context.Context, ds []*D) error {
+	if len(ds) == 0 {
+		return nil
+	}
+
+	ms := make([]*M, 0, len(ds))
+	for _, d := range ds {
+		m, err := r.conv.ToModel(d)
+		if err != nil {
+			return r.wrap("Upsert convert", err)
+		}
+		ms = append(ms, m)
+	}
+
+	if err := r.db.Conn(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&ms).Error; err != nil {
+		return r.wrap("Upsert", err)
+	}
+	return nil
+}
+
+// Update 按d的ID更新columns列出的列, 零值也会写入, 没有列出的列不更新; updated_at由GORM自动更新.
+// 返回是否有行被更新
+func (r *Repository[M, D]) Update(ctx context.Context, d *D, columns ...string) (bool, error) {
+	if len(columns) == 0 {
+		return false, r.wrap("Update", errors.New("no columns to update"))
+	}
+	m, err := r.conv.ToModel(d)
+	if err != nil {
+		return false, r.wrap("Update convert", err)
+	}
+	if (*m).GetID() == 0 {
+		return false, r.wrap("Update", errors.New("missing id"))
+	}
+
+	res := r.db.Conn(ctx).Model(m).Select(columns).Updates(m)
+	if res.Error != nil {
+		return false, r.wrap("Update", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Delete 按ID删除, 有软删除字段时只打删除标记, 返回是否有行被删除
+func (r *Repository[M, D]) Delete(ctx context.Context, id int64) (bool, error) {
+	res := r.db.Conn(ctx).Where("id = ?", id).Delete(new(M))
+	if res.Error != nil {
+		return false, r.wrap("Delete", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Get 按ID查询, 不存在时返回nil
+func (r *Repository[M, D]) Get(ctx context.Context, id int64, specs ...Spec) (*D, error) {
+	return r.First(ctx, append(specs, Where("id = ?", id))...)
+}
+
+// First 查询满足条件的第一行, 不存在时返回nil
+func (r *Repository[M, D]) First(ctx context.Context, specs ...Spec) (*D, error) {
+	m := new(M)
+	err := r.query(ctx, specs).First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.wrap("First", err)
+	}
+
+	d, err := r.conv.ToDO(m)
+	if err != nil {
+		return nil, r.wrap("First convert", err)
+	}
+	return d, nil
+}
+
+// FindByIDs 批量按ID查询, 不保证和ids的顺序一致
+func (r *Repository[M, D]) FindByIDs(ctx context.Context, ids []int64, specs ...Spec) ([]*D, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return r.Find(ctx, append(specs, Where("id IN ?", ids))...)
+}
+
+// Find 查询满足条件的所有行, 数据量不可控的列表用List分页
+func (r *Repository[M, D]) Find(ctx context.Context, specs ...Spec) ([]*D, error) {
+	var ms []*M
+	if err := r.query(ctx, specs).Find(&ms).Error; err != nil {
+		return nil, r.wrap("Find", err)
+	}
+	return r.toDOs(ms)
+}
+
+// ListOrder List的排序, 列表按Column倒序、id倒序排列. Cursor用一行的排序键和ID生成游标,
+// 类型要和列一致, 如按created_at排序时用util.TimeCursor(m.CreatedAt, m.ID)
+type ListOrder[M any] struct {
+	Column string
+	Cursor func(m *M) *util.Cursor
+}
+
+// List 按id倒序分页查询, 见ListBy
+func (r *Repository[M, D]) List(ctx context.Context, page *do.PageQuery, specs ...Spec) ([]*D, int64, error) {
+	return r.ListBy(ctx, page, ListOrder[M]{Cursor: func(m *M) *util.Cursor {
+		return &util.Cursor{ID: (*m).GetID()}
+	}}, specs...)
+}
+
+// ListBy 按order分页查询, page由app.Pagination转换而来. 按页码翻页时返回总数; 按游标翻页时不统计总数, 回填page.Next
+func (r *Repository[M, D]) ListBy(ctx context.Context, page *do.PageQuery, order ListOrder[M], specs ...Spec) ([]*D, int64, error) {
+	var total int64
+	if !page.Keyset {
+		var err error
+		if total, err = r.Count(ctx, specs...); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var ms []*M
+	if err := r.query(ctx, specs).Scopes(Paginate(page, order.Column)).Find(&ms).Error; err != nil {
+		return nil, 0, r.wrap("List", err)
+	}
+	ms = nextPage(page, ms, order.Cursor)
+
+	ds, err := r.toDOs(ms)
+	return ds, total, err
+}
+
+func (r *Repository[M, D]) Count(ctx context.Context, specs ...Spec) (int64, error) {
+	var count int64
+	if err := r.query(ctx, specs).Count(&count).Error; err != nil {
+		return 0, r.wrap("Count", err)
+	}
+	return count, nil
+}
+
+func (r *Repository[M, D]) query(ctx context.Context, specs []Spec) *gorm.DB {
+	db := r.db.Conn(ctx).Model(new(M))
+	for _, spec := range specs {
+		db = spec(db)
+	}
+	return db
+}
+
+func (r *Repository[M, D]) toDOs(ms []*M) ([]*D, error) {
+	ds := make([]*D, 0, len(ms))
+	for _, m := range ms {
+		d, err := r.conv.ToDO(m)
+		if err != nil {
+			return nil, r.wrap("convert", err)
+		}
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+func (r *Repository[M, D]) wrap(op string, err error) error {
+	return errcode.Wrap(fmt.Sprintf("Repository[%s] %s err", r.name, op), err)
+}
@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type dryRunProvider struct {
+	db *gorm.DB
+}
+
+func (p dryRunProvider) Conn(ctx context.Context) *gorm.DB   { return p.db.WithContext(ctx) }
+func (p dryRunProvider) Master(ctx context.Context) *gorm.DB { return p.db.WithContext(ctx) }
+
+// newDryRunDB MySQL方言的DryRun连接, 只生成SQL不连数据库
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRepositorySQL(t *testing.T) {
+	db := newDryRunDB(t)
+	updatedAt := regexp.MustCompile("`updated_at`='[^']*'")
+	var sqls []string
+	record := func(tx *gorm.DB) {
+		sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		sqls = append(sqls, updatedAt.ReplaceAllString(sql, "`updated_at`=?"))
+	}
+	db.Callback().Query().After("gorm:query").Register("test:record", record)
+	db.Callback().Delete().After("gorm:delete").Register("test:record", record)
+	db.Callback().Update().After("gorm:update").Register("test:record", record)
+
+	ctx := context.Background()
+	stores := NewRepository[model.Store, do.Store](dryRunProvider{db}, nil)
+
+	_, _ = stores.Get(ctx, 7)
+	_, _ = stores.Find(ctx, WithDeleted(), Where("name = ?", "a"))
+	_, _, _ = stores.List(ctx, &do.PageQuery{Offset: 10, Limit: 5})
+	_, _, _ = stores.List(ctx, &do.PageQuery{Limit: 5, Keyset: true, After: &util.Cursor{ID: 3}})
+	byCreatedAt := ListOrder[model.Store]{Column: "created_at", Cursor: func(m *model.Store) *util.Cursor {
+		return util.TimeCursor(m.CreatedAt, m.ID)
+	}}
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, _, _ = stores.ListBy(ctx, &do.PageQuery{Limit: 5, Keyset: true, After: util.TimeCursor(createdAt, 3)}, byCreatedAt)
+	// 只更新列出的列, 零值也要写入
+	_, _ = stores.Update(ctx, &do.Store{ID: 7, Name: "b"}, "name", "is_open")
+	_, _ = stores.Delete(ctx, 7)
+
+	want := []string{
+		"SELECT * FROM `stores` WHERE id = 7 AND `stores`.`is_del` = 0 ORDER BY `stores`.`id` LIMIT 1",
+		"SELECT * FROM `stores` WHERE name = 'a'",
+		"SELECT count(*) FROM `stores` WHERE `stores`.`is_del` = 0",
+		"SELECT * FROM `stores` WHERE `stores`.`is_del` = 0 ORDER BY `stores`.`id` DESC LIMIT 5 OFFSET 10",
+		"SELECT * FROM `stores` WHERE `stores`.`id` < 3 AND `stores`.`is_del` = 0 ORDER BY `stores`.`id` DESC LIMIT 6",
+		"SELECT * FROM `stores` WHERE (`stores`.`created_at` < '2024-05-01 12:00:00' OR (`stores`.`created_at` = '2024-05-01 12:00:00' AND `stores`.`id` < 3)) AND `stores`.`is_del` = 0 ORDER BY `stores`.`created_at` DESC,`stores`.`id` DESC LIMIT 6",
+		"UPDATE `stores` SET `name`='b',`is_open`=0,`updated_at`=? WHERE `stores`.`is_del` = 0 AND `id` = 7",
+		"UPDATE `stores` SET `is_del`=1 WHERE id = 7 AND `stores`.`is_del` = 0",
+	}
+	if len(sqls) != len(want) {
+		t.Fatalf("got %d statements %q, want %d", len(sqls), sqls, len(want))
+	}
+	for i := range want {
+		if sqls[i] != want[i] {
+			t.Errorf("got  %s\nwant %s", sqls[i], want[i])
+		}
+	}
+}
+
+func TestRepositoryUpdateRequiresColumns(t *testing.T) {
+	stores := NewRepository[model.Store, do.Store](dryRunProvider{newDryRunDB(t)}, nil)
+	if _, err := stores.Update(context.Background(), &do.Store{ID: 7, Name: "b"}); err == nil {
+		t.Error("Update without columns succeeded")
+	}
+	if _, err := stores.Update(context.Background(), &do.Store{Name: "b"}, "name"); err == nil {
+		t.Error("Update without id succeeded")
+	}
+}
@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type readYourWritesKey struct{}
+
+// WithReadYourWrites 给请求开启读己之写: 返回的ctx上一旦发生过写操作, 后续的读都走主库,
+// 避免主从延迟导致刚写的数据读不到. 一般在请求入口的中间件里调用
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, new(atomic.Bool))
+}
+
+func markWritten(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	if written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+func hasWritten(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool)
+	return ok && written.Load()
+}
+
+// resolver gorm插件, 在执行语句前替换连接池实现读写分离
+type resolver struct {
+	db *DB
+}
+
+func (r *resolver) Name() string {
+	return "gomall:resolver"
+}
+
+func (r *resolver) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return firstErr(
+		cb.Query().Before("gorm:query").Register("gomall:route_read", r.routeRead),
+		cb.Row().Before("gorm:row").Register("gomall:route_read", r.routeRead),
+		cb.Create().Before("gorm:create").Register("gomall:mark_written", r.markWritten),
+		cb.Update().Before("gorm:update").Register("gomall:mark_written", r.markWritten),
+		cb.Delete().Before("gorm:delete").Register("gomall:mark_written", r.markWritten),
+		cb.Raw().Before("gorm:raw").Register("gomall:mark_written", r.markWritten),
+	)
+}
+
+// routeRead 普通读换到从库, 以下情况留在主库: 事务中, SELECT ... FOR UPDATE这类锁定读,
+// 本请求已经写过, 原生SQL不是SELECT, 以及没有可用的从库
+func (r *resolver) routeRead(db *gorm.DB) {
+	stmt := db.Statement
+	if _, inTx := stmt.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if stmt.SQL.Len() > 0 && !isSelect(stmt.SQL.String()) {
+		markWritten(stmt.Context)
+		return
+	}
+	if hasWritten(stmt.Context) {
+		return
+	}
+
+	if replica := r.db.pickReplica(); replica != nil {
+		stmt.ConnPool = replica.db.ConnPool
+	}
+}
+
+func (r *resolver) markWritten(db *gorm.DB) {
+	markWritten(db.Statement.Context)
+}
+
+func isSelect(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	return len(sql) >= 6 && strings.EqualFold(sql[:6], "select")
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
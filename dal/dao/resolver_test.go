@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPickReplica(t *testing.T) {
+	d := &DB{}
+	for _, name := range []string{"r0", "r1", "r2"} {
+		r := &replica{name: name}
+		r.healthy.Store(true)
+		d.replicas = append(d.replicas, r)
+	}
+
+	seen := map[string]int{}
+	for i := 0; i < 6; i++ {
+		seen[d.pickReplica().name]++
+	}
+	if seen["r0"] != 2 || seen["r1"] != 2 || seen["r2"] != 2 {
+		t.Errorf("round robin = %v, want 2 each", seen)
+	}
+
+	d.replicas[1].healthy.Store(false)
+	for i := 0; i < 6; i++ {
+		if name := d.pickReplica().name; name == "r1" {
+			t.Fatal("unhealthy replica picked")
+		}
+	}
+
+	d.replicas[0].healthy.Store(false)
+	d.replicas[2].healthy.Store(false)
+	if r := d.pickReplica(); r != nil {
+		t.Errorf("all unhealthy, got %s, want nil to fall back to master", r.name)
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	// 没有开启时写操作不影响读
+	markWritten(context.Background())
+	if hasWritten(context.Background()) {
+		t.Error("hasWritten without WithReadYourWrites")
+	}
+
+	ctx := WithReadYourWrites(context.Background())
+	if hasWritten(ctx) {
+		t.Fatal("hasWritten before any write")
+	}
+	// 下游派生的ctx共享同一个标记
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	markWritten(child)
+	if !hasWritten(ctx) {
+		t.Error("write on child ctx should pin parent request to master")
+	}
+}
+
+func TestIsSelect(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT * FROM user":    true,
+		"  select 1":            true,
+		"UPDATE user SET a = 1": false,
+		"sel":                   false,
+	}
+	for sql, want := range cases {
+		if got := isSelect(sql); got != want {
+			t.Errorf("isSelect(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
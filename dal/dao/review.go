@@ -0,0 +1,173 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type ReviewDao struct {
+	db DBProvider
+}
+
+func NewReviewDao(db DBProvider) *ReviewDao {
+	return &ReviewDao{db: db}
+}
+
+func (r *ReviewDao) CreateReview(ctx context.Context, review *do.Review) error {
+	reviewPO := new(model.Review)
+	if err := util.Copy(reviewPO, review); err != nil {
+		return errcode.Wrap("CreateReview copy err", err)
+	}
+	reviewPO.FollowUp = nil
+
+	if err := r.db.Conn(ctx).Create(reviewPO).Error; err != nil {
+		return errcode.Wrap("CreateReview db create err", err)
+	}
+
+	review.ID = reviewPO.ID
+	review.CreatedAt = reviewPO.CreatedAt
+	return nil
+}
+
+func (r *ReviewDao) FindReviewByID(ctx context.Context, reviewID int64) (*do.Review, error) {
+	return r.findReview(ctx, "id = ?", reviewID)
+}
+
+// FindReviewByOrderItem 查询订单明细的首次评价(parentID为0)或某条评价的追评
+func (r *ReviewDao) FindReviewByOrderItem(ctx context.Context, orderItemID, parentID int64) (*do.Review, error) {
+	return r.findReview(ctx, "order_item_id = ? AND parent_id = ?", orderItemID, parentID)
+}
+
+func (r *ReviewDao) findReview(ctx context.Context, query string, args ...any) (*do.Review, error) {
+	reviewPO := new(model.Review)
+	err := r.db.Master(ctx).Where(query, args...).First(reviewPO).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("findReview err", err)
+	}
+
+	review := new(do.Review)
+	util.Copy(review, reviewPO)
+	return review, nil
+}
+
+// ListApprovedReviews 商品详情页的评价列表, 只返回审核通过的首次评价, 带上审核通过的追评
+func (r *ReviewDao) ListApprovedReviews(ctx context.Context, commodityID int64, page *do.PageQuery) ([]*do.Review, int64, error) {
+	query := r.db.Conn(ctx).Model(&model.Review{}).
+		Where("commodity_id = ? AND parent_id = 0 AND state = ?", commodityID, enum.ReviewStateApproved)
+
+	var total int64
+	if !page.Keyset {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, errcode.Wrap("ListApprovedReviews count err", err)
+		}
+	}
+
+	var reviewPOs []*model.Review
+	if err := query.Preload("FollowUp", "state = ?", enum.ReviewStateApproved).
+		Scopes(Paginate(page, "")).
+		Find(&reviewPOs).Error; err != nil {
+		return nil, 0, errcode.Wrap("ListApprovedReviews find err", err)
+	}
+	reviewPOs = nextPage(page, reviewPOs, func(po *model.Review) *util.Cursor { return &util.Cursor{ID: po.ID} })
+
+	return convertReviews(reviewPOs), total, nil
+}
+
+// ListReviews 管理后台的评价列表, 首次评价和追评都按单条返回
+func (r *ReviewDao) ListReviews(ctx context.Context, filter *do.ReviewListFilter, page *do.PageQuery) ([]*do.Review, int64, error) {
+	query := r.db.Conn(ctx).Model(&model.Review{})
+	if filter.CommodityID > 0 {
+		query = query.Where("commodity_id = ?", filter.CommodityID)
+	}
+	if filter.State != nil {
+		query = query.Where("state = ?", *filter.State)
+	}
+
+	var total int64
+	if !page.Keyset {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, errcode.Wrap("ListReviews count err", err)
+		}
+	}
+
+	var reviewPOs []*model.Review
+	if err := query.Scopes(Paginate(page, "")).Find(&reviewPOs).Error; err != nil {
+		return nil, 0, errcode.Wrap("ListReviews find err", err)
+	}
+	reviewPOs = nextPage(page, reviewPOs, func(po *model.Review) *util.Cursor { return &util.Cursor{ID: po.ID} })
+
+	return convertReviews(reviewPOs), total, nil
+}
+
+// ModerateReview 评价从fromState流转到review.State, 已不在fromState时返回false
+func (r *ReviewDao) ModerateReview(ctx context.Context, review *do.Review, fromState int8) (bool, error) {
+	res := r.db.Conn(ctx).Model(&model.Review{}).
+		Where("id = ? AND state = ?", review.ID, fromState).
+		Updates(map[string]any{
+			"state":         review.State,
+			"reject_reason": review.RejectReason,
+			"moderated_by":  review.ModeratedBy,
+		})
+	if res.Error != nil {
+		return false, errcode.Wrap("ModerateReview err", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (r *ReviewDao) ReplyReview(ctx context.Context, reviewID int64, reply string, repliedAt time.Time) error {
+	if err := r.db.Conn(ctx).Model(&model.Review{}).
+		Where("id = ?", reviewID).
+		Updates(map[string]any{"merchant_reply": reply, "replied_at": repliedAt}).Error; err != nil {
+		return errcode.Wrap("ReplyReview err", err)
+	}
+
+	return nil
+}
+
+// AggregateRatingStats 从数据库全量统计商品的评分
+func (r *ReviewDao) AggregateRatingStats(ctx context.Context, commodityID int64) (*do.RatingStats, error) {
+	var rows []struct {
+		Rating int8
+		Count  int64
+	}
+	if err := r.db.Conn(ctx).Model(&model.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("commodity_id = ? AND parent_id = 0 AND state = ?", commodityID, enum.ReviewStateApproved).
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		return nil, errcode.Wrap("AggregateRatingStats err", err)
+	}
+
+	stats := &do.RatingStats{CommodityID: commodityID, Distribution: make(map[int8]int64, enum.ReviewMaxRating)}
+	for _, row := range rows {
+		stats.Count += row.Count
+		stats.Sum += int64(row.Rating) * row.Count
+		stats.Distribution[row.Rating] = row.Count
+	}
+
+	return stats, nil
+}
+
+func convertReviews(reviewPOs []*model.Review) []*do.Review {
+	reviews := make([]*do.Review, 0, len(reviewPOs))
+	for _, po := range reviewPOs {
+		review := new(do.Review)
+		util.Copy(review, po)
+		reviews = append(reviews, review)
+	}
+
+	return reviews
+}
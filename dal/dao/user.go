@@ -2,49 +2,64 @@ package dao
 
 import (
 	"context"
-	"errors"
-
-	"gorm.io/gorm"
 
 	"github.com/kackerx/go-mall/common/errcode"
-	"github.com/kackerx/go-mall/common/util"
 	"github.com/kackerx/go-mall/dal/model"
 	"github.com/kackerx/go-mall/logic/do"
 )
 
 type UserDao struct {
+	users *Repository[model.User, do.UserBaseInfo]
 }
 
-func NewUserDao() *UserDao {
-	return &UserDao{}
+func NewUserDao(db DBProvider) *UserDao {
+	return &UserDao{users: NewRepository[model.User, do.UserBaseInfo](db, nil)}
 }
 
-func (u *UserDao) CreateUser(ctx context.Context, user *do.UserBaseInfo, passwordHash string) (userID int64, err error) {
-	userModel := new(model.User)
-
-	if err = util.Copy(userModel, user); err != nil {
-		err = errcode.Wrap("CreateUser copy err", err)
-		return
+func (u *UserDao) CreateUser(ctx context.Context, user *do.UserBaseInfo, passwordHash string) (int64, error) {
+	userPO, err := u.users.Create(ctx, user, func(m *model.User) {
+		m.Password = passwordHash
+	})
+	if err != nil {
+		return 0, errcode.Wrap("CreateUser err", err)
 	}
 
-	userModel.Password = passwordHash
-	if err = DBMaster().WithContext(ctx).Create(userModel).Error; err != nil {
-		err = errcode.Wrap("CreateUser db create err", err)
-		return
+	return userPO.ID, nil
+}
+
+// FindUserByUserName 用户不存在时返回空的UserBaseInfo
+func (u *UserDao) FindUserByUserName(ctx context.Context, userName string) (*do.UserBaseInfo, error) {
+	user, err := u.users.First(ctx, Where("user_name = ?", userName))
+	if err != nil {
+		return nil, errcode.Wrap("FindUserByUserName err", err)
+	}
+	if user == nil {
+		user = new(do.UserBaseInfo)
 	}
 
-	return userModel.ID, nil
+	return user, nil
 }
 
-func (u *UserDao) FindUserByUserName(ctx context.Context, userName string) (user *do.UserBaseInfo, err error) {
-	userPO := new(model.User)
-	if err = DBMaster().WithContext(ctx).
-		Where("user_name = ?", userName).
-		First(&userPO).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return
+func (u *UserDao) FindUserByID(ctx context.Context, userID int64) (*do.UserBaseInfo, error) {
+	user, err := u.users.Get(ctx, userID)
+	if err != nil {
+		return nil, errcode.Wrap("FindUserByID err", err)
 	}
 
-	user = new(do.UserBaseInfo)
-	util.Copy(user, userPO)
 	return user, nil
 }
+
+// FindUsersByIDs 批量查询用户, key为用户ID
+func (u *UserDao) FindUsersByIDs(ctx context.Context, userIDs []int64) (map[int64]*do.UserBaseInfo, error) {
+	userList, err := u.users.FindByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, errcode.Wrap("FindUsersByIDs err", err)
+	}
+
+	users := make(map[int64]*do.UserBaseInfo, len(userList))
+	for _, user := range userList {
+		users[user.ID] = user
+	}
+
+	return users, nil
+}
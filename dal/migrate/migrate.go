@@ -0,0 +1,370 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// VersionTable 记录已执行迁移的表
+	VersionTable = "schema_migrations"
+
+	lockName = "gomall_schema_migrations"
+)
+
+var (
+	fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+	ErrLocked = errors.New("migrate: another migration is running")
+)
+
+// Migration 一个版本的迁移, Up和Down是SQL文件原文
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 迁移的执行状态, AppliedAt为零值表示还未执行
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// Load 从fsys根目录读取迁移文件, 每个版本必须同时有up和down, 版本号不能重复
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read dir: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migrate: bad file name %s, want <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d has two names: %s, %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migrate: version %d_%s needs both up and down sql", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// SplitStatements 按行尾的分号拆分SQL语句, 整行的--注释会被去掉. 不支持存储过程这类语句内带分号的写法
+func SplitStatements(content string) []string {
+	var stmts []string
+	var cur strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(cur.String()), ";"))
+			cur.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(cur.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
+
+// Migrator 在MySQL上执行迁移. 执行期间持有GET_LOCK命名锁, 多个实例同时发布时只有一个在迁移
+type Migrator struct {
+	db          *sql.DB
+	migrations  []*Migration
+	out         io.Writer
+	dryRun      bool
+	lockTimeout time.Duration
+}
+
+type Option func(m *Migrator)
+
+// WithDryRun 只打印将要执行的SQL, 不执行也不加锁
+func WithDryRun(dryRun bool) Option {
+	return func(m *Migrator) {
+		m.dryRun = dryRun
+	}
+}
+
+// WithLockTimeout 等待其他实例释放迁移锁的最长时间
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+func New(db *sql.DB, migrations []*Migration, out io.Writer, opts ...Option) *Migrator {
+	m := &Migrator{db: db, migrations: migrations, out: out, lockTimeout: 30 * time.Second}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Up 按版本号顺序执行未执行过的迁移, steps<=0时执行全部
+func (m *Migrator) Up(ctx context.Context, steps int) error {
+	return m.run(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		pending := Pending(m.migrations, applied)
+		if steps > 0 && len(pending) > steps {
+			pending = pending[:steps]
+		}
+		if len(pending) == 0 {
+			fmt.Fprintln(m.out, "no pending migrations")
+			return nil
+		}
+
+		for _, migration := range pending {
+			if err = m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down 从最新版本开始回滚steps个已执行的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return errors.New("migrate: down steps must be positive")
+	}
+
+	return m.run(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		rollback := Applied(m.migrations, applied)
+		if len(rollback) > steps {
+			rollback = rollback[:steps]
+		}
+		if len(rollback) == 0 {
+			fmt.Fprintln(m.out, "no applied migrations")
+			return nil
+		}
+
+		for _, migration := range rollback {
+			if err = m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status 所有迁移文件及其执行时间
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, &Status{Version: migration.Version, Name: migration.Name, AppliedAt: applied[migration.Version]})
+	}
+
+	return statuses, nil
+}
+
+// Pending 未执行的迁移, 按版本号升序
+func Pending(migrations []*Migration, applied map[int64]time.Time) []*Migration {
+	var pending []*Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending
+}
+
+// Applied 已执行的迁移, 按版本号降序, 即回滚的顺序
+func Applied(migrations []*Migration, applied map[int64]time.Time) []*Migration {
+	var done []*Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; ok {
+			done = append(done, migrations[i])
+		}
+	}
+
+	return done
+}
+
+// run 在单独的连接上加锁执行fn, 命名锁是会话级的, 加锁、迁移、解锁必须在同一个连接上
+func (m *Migrator) run(ctx context.Context, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dryRun {
+		fmt.Fprintln(m.out, "-- dry run, nothing will be executed")
+		return fn(ctx, conn)
+	}
+
+	var got sql.NullInt64
+	if err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.lockTimeout.Seconds())).Scan(&got); err != nil {
+		return fmt.Errorf("migrate: get lock: %w", err)
+	}
+	if got.Int64 != 1 {
+		return ErrLocked
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", lockName)
+
+	if _, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS `"+VersionTable+"` ("+
+		"`version` bigint NOT NULL, "+
+		"`name` varchar(255) NOT NULL DEFAULT '', "+
+		"`applied_at` datetime(3) NOT NULL, "+
+		"PRIMARY KEY (`version`)"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"); err != nil {
+		return fmt.Errorf("migrate: create version table: %w", err)
+	}
+
+	return fn(ctx, conn)
+}
+
+// applied 已执行的版本, 版本表还不存在时视为一个都没执行
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	var exists int
+	if err := conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		VersionTable).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("migrate: check version table: %w", err)
+	}
+	applied := make(map[int64]time.Time)
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT `version`, `applied_at` FROM `"+VersionTable+"`")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read version table: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("migrate: scan version table: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// apply 执行一个版本的up或down. MySQL的DDL会隐式提交, 一个文件里前面的语句成功、后面失败时需要人工处理,
+// 所以每个迁移文件尽量只做一件事
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, up bool) error {
+	direction, content := "up", migration.Up
+	if !up {
+		direction, content = "down", migration.Down
+	}
+	fmt.Fprintf(m.out, "-- %s %d_%s\n", direction, migration.Version, migration.Name)
+
+	for _, stmt := range SplitStatements(content) {
+		if m.dryRun {
+			fmt.Fprintf(m.out, "%s;\n", stmt)
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrate: %s %d_%s: %w", direction, migration.Version, migration.Name, err)
+		}
+	}
+	if m.dryRun {
+		return nil
+	}
+
+	var err error
+	if up {
+		_, err = conn.ExecContext(ctx, "INSERT INTO `"+VersionTable+"` (`version`, `name`, `applied_at`) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now())
+	} else {
+		_, err = conn.ExecContext(ctx, "DELETE FROM `"+VersionTable+"` WHERE `version` = ?", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: record version %d: %w", migration.Version, err)
+	}
+
+	return nil
+}
+
+// NextFileNames create子命令用, 新版本号为当前最大版本号+1, 四位补零
+func NextFileNames(migrations []*Migration, name string) (up, down string, err error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+	var next int64 = 1
+	if n := len(migrations); n > 0 {
+		next = migrations[n-1].Version + 1
+	}
+
+	up = fmt.Sprintf("%04d_%s.up.sql", next, name)
+	if !fileNamePattern.MatchString(up) {
+		return "", "", fmt.Errorf("migrate: bad migration name %q, use lowercase letters, digits and underscores", name)
+	}
+	down = fmt.Sprintf("%04d_%s.down.sql", next, name)
+
+	return up, down, nil
+}
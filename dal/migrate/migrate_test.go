@@ -0,0 +1,94 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/kackerx/go-mall/resources"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX a ON t (a);")},
+		"0002_add_index.down.sql": {Data: []byte("DROP INDEX a ON t;")},
+		"0001_init.up.sql":        {Data: []byte("CREATE TABLE t (a int);")},
+		"0001_init.down.sql":      {Data: []byte("DROP TABLE t;")},
+		"README.md":               {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "add_index" {
+		t.Fatalf("migrations = %+v", migrations)
+	}
+
+	fsys["0003_missing_down.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err = Load(fsys); err == nil || !strings.Contains(err.Error(), "needs both up and down") {
+		t.Errorf("err = %v, want missing down", err)
+	}
+	delete(fsys, "0003_missing_down.up.sql")
+
+	fsys["3_Bad-Name.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err = Load(fsys); err == nil || !strings.Contains(err.Error(), "bad file name") {
+		t.Errorf("err = %v, want bad file name", err)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load(resources.MigrationFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("embedded migrations = %+v", migrations)
+	}
+	if !strings.Contains(migrations[0].Up, "CREATE TABLE IF NOT EXISTS `users`") {
+		t.Error("init migration should create users table")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- 注释
+CREATE TABLE a (
+  id bigint
+);
+
+INSERT INTO a VALUES (1);
+DROP TABLE b`
+
+	want := []string{"CREATE TABLE a (\n  id bigint\n)", "INSERT INTO a VALUES (1)", "DROP TABLE b"}
+	if got := SplitStatements(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPendingApplied(t *testing.T) {
+	migrations := []*Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	applied := map[int64]time.Time{1: time.Now(), 2: time.Now()}
+
+	if got := Pending(migrations, applied); len(got) != 1 || got[0].Version != 3 {
+		t.Errorf("pending = %v", got)
+	}
+	if got := Applied(migrations, applied); len(got) != 2 || got[0].Version != 2 || got[1].Version != 1 {
+		t.Errorf("applied should be newest first, got %v", got)
+	}
+}
+
+func TestNextFileNames(t *testing.T) {
+	up, down, err := NextFileNames([]*Migration{{Version: 1}, {Version: 7}}, "Add Order Remark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up != "0008_add_order_remark.up.sql" || down != "0008_add_order_remark.down.sql" {
+		t.Errorf("got %s, %s", up, down)
+	}
+
+	if _, _, err = NextFileNames(nil, "订单"); err == nil {
+		t.Error("want err for non ascii name")
+	}
+}
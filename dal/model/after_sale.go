@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// AfterSale 售后单, 一个售后单可以包含同一订单的多个明细
+type AfterSale struct {
+	ID            int64                 `gorm:"column:id;primary_key" json:"id"`
+	TicketNo      string                `gorm:"column:ticket_no;not null;default:'';uniqueIndex" json:"ticket_no"` // 同时作为网关退款的商户退款单号
+	OrderID       int64                 `gorm:"column:order_id;not null;default:0;index" json:"order_id"`
+	OrderNo       string                `gorm:"column:order_no;not null;default:''" json:"order_no"`
+	UserID        int64                 `gorm:"column:user_id;not null;default:0;index" json:"user_id"`
+	Type          int8                  `gorm:"column:type;not null;default:0" json:"type"`
+	State         int8                  `gorm:"column:state;not null;default:0;index" json:"state"`
+	Reason        string                `gorm:"column:reason;not null;default:''" json:"reason"`
+	RefundAmount  int64                 `gorm:"column:refund_amount;not null;default:0" json:"refund_amount"` // 单位: 分
+	PaymentNo     string                `gorm:"column:payment_no;not null;default:''" json:"payment_no"`
+	RefundTradeNo string                `gorm:"column:refund_trade_no;not null;default:''" json:"refund_trade_no"`
+	AuditRemark   string                `gorm:"column:audit_remark;not null;default:''" json:"audit_remark"`
+	AuditedBy     int64                 `gorm:"column:audited_by;not null;default:0" json:"audited_by"`
+	AuditedAt     time.Time             `gorm:"column:audited_at;default:'1970-01-01 00:00:00'" json:"audited_at"`
+	RefundedAt    time.Time             `gorm:"column:refunded_at;default:'1970-01-01 00:00:00'" json:"refunded_at"`
+	IsDel         soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt     time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time             `gorm:"column:updated_at" json:"updated_at"`
+
+	Items []*AfterSaleItem `gorm:"foreignKey:AfterSaleID" json:"items"`
+}
+
+func (a *AfterSale) TableName() string {
+	return "after_sales"
+}
+
+type AfterSaleItem struct {
+	ID           int64     `gorm:"column:id;primary_key" json:"id"`
+	AfterSaleID  int64     `gorm:"column:after_sale_id;not null;default:0;index" json:"after_sale_id"`
+	OrderItemID  int64     `gorm:"column:order_item_id;not null;default:0;index" json:"order_item_id"`
+	SkuID        int64     `gorm:"column:sku_id;not null;default:0" json:"sku_id"`
+	Quantity     int64     `gorm:"column:quantity;not null;default:0" json:"quantity"`
+	RefundAmount int64     `gorm:"column:refund_amount;not null;default:0" json:"refund_amount"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (a *AfterSaleItem) TableName() string {
+	return "after_sale_items"
+}
+
+// AfterSaleLog 售后操作审计日志, 只增不改
+type AfterSaleLog struct {
+	ID           int64     `gorm:"column:id;primary_key" json:"id"`
+	AfterSaleID  int64     `gorm:"column:after_sale_id;not null;default:0;index" json:"after_sale_id"`
+	OperatorType string    `gorm:"column:operator_type;not null;default:''" json:"operator_type"`
+	OperatorID   int64     `gorm:"column:operator_id;not null;default:0" json:"operator_id"`
+	Action       string    `gorm:"column:action;not null;default:''" json:"action"`
+	FromState    int8      `gorm:"column:from_state;not null;default:0" json:"from_state"`
+	ToState      int8      `gorm:"column:to_state;not null;default:0" json:"to_state"`
+	Remark       string    `gorm:"column:remark;not null;default:''" json:"remark"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (a *AfterSaleLog) TableName() string {
+	return "after_sale_logs"
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// Commodity 商品(SPU)
+type Commodity struct {
+	ID          int64                 `gorm:"column:id;primary_key" json:"id"`
+	StoreID     int64                 `gorm:"column:store_id;not null;default:0;index" json:"store_id"`
+	CategoryID  int64                 `gorm:"column:category_id;not null;default:0;index" json:"category_id"`
+	Name        string                `gorm:"column:name;not null;default:''" json:"name"`
+	Intro       string                `gorm:"column:intro;not null;default:''" json:"intro"`
+	CoverImg    string                `gorm:"column:cover_img;not null;default:''" json:"cover_img"`
+	IsPublished int8                  `gorm:"column:is_published;not null;default:0" json:"is_published"`
+	IsDel       soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt   time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (c *Commodity) TableName() string {
+	return "commodities"
+}
+
+func (c Commodity) GetID() int64 {
+	return c.ID
+}
+
+// CommoditySku 商品的销售单元, 价格和库存落在SKU上
+type CommoditySku struct {
+	ID          int64                 `gorm:"column:id;primary_key" json:"id"`
+	CommodityID int64                 `gorm:"column:commodity_id;not null;default:0;index" json:"commodity_id"`
+	Name        string                `gorm:"column:name;not null;default:''" json:"name"`  // 规格名称, 如: 黑色 128G
+	Price       int64                 `gorm:"column:price;not null;default:0" json:"price"` // 单位: 分
+	Stock       int64                 `gorm:"column:stock;not null;default:0" json:"stock"`
+	IsDel       soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt   time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (c *CommoditySku) TableName() string {
+	return "commodity_skus"
+}
+
+func (c CommoditySku) GetID() int64 {
+	return c.ID
+}
+
+type CommodityCategory struct {
+	ID        int64                 `gorm:"column:id;primary_key" json:"id"`
+	Level     int                   `gorm:"column:level;not null;default:0" json:"level"`
+	ParentID  int64                 `gorm:"column:parent_id;not null;default:0;index" json:"parent_id"`
+	Name      string                `gorm:"column:name;not null;default:''" json:"name"`
+	IconImg   string                `gorm:"column:icon_img;not null;default:''" json:"icon_img"`
+	Rank      int                   `gorm:"column:rank;not null;default:0" json:"rank"`
+	CreatedBy string                `gorm:"column:created_by;not null;default:''" json:"created_by"`
+	UpdatedBy string                `gorm:"column:updated_by;not null;default:''" json:"updated_by"`
+	IsDel     soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (c *CommodityCategory) TableName() string {
+	return "commodity_categories"
+}
+
+func (c CommodityCategory) GetID() int64 {
+	return c.ID
+}
+
+// Store 店铺
+type Store struct {
+	ID        int64                 `gorm:"column:id;primary_key" json:"id"`
+	Name      string                `gorm:"column:name;not null;default:''" json:"name"`
+	Logo      string                `gorm:"column:logo;not null;default:''" json:"logo"`
+	Intro     string                `gorm:"column:intro;not null;default:''" json:"intro"`
+	IsOpen    int8                  `gorm:"column:is_open;not null;default:0" json:"is_open"`
+	IsDel     soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (s *Store) TableName() string {
+	return "stores"
+}
+
+func (s Store) GetID() int64 {
+	return s.ID
+}
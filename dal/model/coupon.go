@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// CouponTemplate 优惠券模板, 用户领取后生成UserCoupon
+type CouponTemplate struct {
+	ID             int64                 `gorm:"column:id;primary_key" json:"id"`
+	Name           string                `gorm:"column:name;not null;default:''" json:"name"`
+	Type           int8                  `gorm:"column:type;not null;default:0" json:"type"`
+	Threshold      int64                 `gorm:"column:threshold;not null;default:0" json:"threshold"`             // 满减门槛, 单位: 分
+	DiscountAmount int64                 `gorm:"column:discount_amount;not null;default:0" json:"discount_amount"` // 立减/满减金额, 单位: 分
+	DiscountRate   int64                 `gorm:"column:discount_rate;not null;default:0" json:"discount_rate"`     // 折扣率, 85表示85折
+	MaxDiscount    int64                 `gorm:"column:max_discount;not null;default:0" json:"max_discount"`       // 折扣券最高优惠, 0不限
+	ScopeType      int8                  `gorm:"column:scope_type;not null;default:0" json:"scope_type"`
+	ScopeIDs       []int64               `gorm:"column:scope_ids;type:varchar(1024);not null;default:'';serializer:json" json:"scope_ids"`
+	UserSegment    string                `gorm:"column:user_segment;not null;default:''" json:"user_segment"`
+	Stackable      int8                  `gorm:"column:stackable;not null;default:0" json:"stackable"` // 能否与其他类型的券叠加
+	TotalStock     int64                 `gorm:"column:total_stock;not null;default:0" json:"total_stock"`
+	PerUserLimit   int64                 `gorm:"column:per_user_limit;not null;default:1" json:"per_user_limit"`
+	ValidDays      int                   `gorm:"column:valid_days;not null;default:0" json:"valid_days"` // 领取后的有效天数
+	ClaimStartAt   time.Time             `gorm:"column:claim_start_at;default:'1970-01-01 00:00:00'" json:"claim_start_at"`
+	ClaimEndAt     time.Time             `gorm:"column:claim_end_at;default:'1970-01-01 00:00:00'" json:"claim_end_at"`
+	CreatedBy      int64                 `gorm:"column:created_by;not null;default:0" json:"created_by"`
+	IsDel          soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt      time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (c *CouponTemplate) TableName() string {
+	return "coupon_templates"
+}
+
+// UserCoupon 用户领取到的优惠券
+type UserCoupon struct {
+	ID         int64     `gorm:"column:id;primary_key" json:"id"`
+	TemplateID int64     `gorm:"column:template_id;not null;default:0;index" json:"template_id"`
+	UserID     int64     `gorm:"column:user_id;not null;default:0;index" json:"user_id"`
+	State      int8      `gorm:"column:state;not null;default:0" json:"state"`
+	ValidFrom  time.Time `gorm:"column:valid_from;default:'1970-01-01 00:00:00'" json:"valid_from"`
+	ValidTo    time.Time `gorm:"column:valid_to;default:'1970-01-01 00:00:00'" json:"valid_to"`
+	OrderNo    string    `gorm:"column:order_no;not null;default:'';index" json:"order_no"`
+	UsedAt     time.Time `gorm:"column:used_at;default:'1970-01-01 00:00:00'" json:"used_at"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
+
+	Template *CouponTemplate `gorm:"foreignKey:TemplateID" json:"template"`
+}
+
+func (u *UserCoupon) TableName() string {
+	return "user_coupons"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Favorite 用户收藏的商品或店铺, 取消收藏直接删除
+type Favorite struct {
+	ID         int64     `gorm:"column:id;primary_key" json:"id"`
+	UserID     int64     `gorm:"column:user_id;not null;default:0;uniqueIndex:uk_user_target" json:"user_id"`
+	TargetType int8      `gorm:"column:target_type;not null;default:0;uniqueIndex:uk_user_target;index:idx_target" json:"target_type"`
+	TargetID   int64     `gorm:"column:target_id;not null;default:0;uniqueIndex:uk_user_target;index:idx_target" json:"target_id"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (f *Favorite) TableName() string {
+	return "favorites"
+}
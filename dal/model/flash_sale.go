@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// FlashSale 秒杀活动, 预热时从SKU库存中划出TotalStock专供秒杀
+type FlashSale struct {
+	ID         int64                 `gorm:"column:id;primary_key" json:"id"`
+	Name       string                `gorm:"column:name;not null;default:''" json:"name"`
+	SkuID      int64                 `gorm:"column:sku_id;not null;default:0;index" json:"sku_id"`
+	Price      int64                 `gorm:"column:price;not null;default:0" json:"price"` // 秒杀价, 单位: 分
+	TotalStock int64                 `gorm:"column:total_stock;not null;default:0" json:"total_stock"`
+	StartAt    time.Time             `gorm:"column:start_at;default:'1970-01-01 00:00:00'" json:"start_at"`
+	EndAt      time.Time             `gorm:"column:end_at;default:'1970-01-01 00:00:00'" json:"end_at"`
+	Warmed     int8                  `gorm:"column:warmed;not null;default:0" json:"warmed"`   // 是否已预热(已从SKU划出库存)
+	Settled    int8                  `gorm:"column:settled;not null;default:0" json:"settled"` // 是否已结算(没卖完的库存已退回SKU)
+	CreatedBy  int64                 `gorm:"column:created_by;not null;default:0" json:"created_by"`
+	IsDel      soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt  time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (f *FlashSale) TableName() string {
+	return "flash_sales"
+}
+
+// FlashSaleOrder 秒杀活动和订单的关联, 唯一索引保证同一用户在一个活动里只会生成一个订单
+type FlashSaleOrder struct {
+	ID          int64     `gorm:"column:id;primary_key" json:"id"`
+	FlashSaleID int64     `gorm:"column:flash_sale_id;not null;default:0;uniqueIndex:uk_flash_sale_user" json:"flash_sale_id"`
+	UserID      int64     `gorm:"column:user_id;not null;default:0;uniqueIndex:uk_flash_sale_user" json:"user_id"`
+	OrderNo     string    `gorm:"column:order_no;not null;default:''" json:"order_no"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (f *FlashSaleOrder) TableName() string {
+	return "flash_sale_orders"
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+type Order struct {
+	ID             int64                 `gorm:"column:id;primary_key" json:"id"`
+	OrderNo        string                `gorm:"column:order_no;not null;default:'';uniqueIndex" json:"order_no"`
+	UserID         int64                 `gorm:"column:user_id;not null;default:0;index" json:"user_id"`
+	State          int8                  `gorm:"column:state;not null;default:0;index:idx_state_expire" json:"state"`
+	TotalAmount    int64                 `gorm:"column:total_amount;not null;default:0" json:"total_amount"`       // 商品总额, 单位: 分
+	DiscountAmount int64                 `gorm:"column:discount_amount;not null;default:0" json:"discount_amount"` // 优惠券抵扣金额, 单位: 分
+	PayAmount      int64                 `gorm:"column:pay_amount;not null;default:0" json:"pay_amount"`           // 实付金额, 单位: 分
+	PaidAt         time.Time             `gorm:"column:paid_at;default:'1970-01-01 00:00:00'" json:"paid_at"`
+	ExpireAt       time.Time             `gorm:"column:expire_at;default:'1970-01-01 00:00:00';index:idx_state_expire" json:"expire_at"`
+	IsDel          soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt      time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time             `gorm:"column:updated_at" json:"updated_at"`
+
+	Items []*OrderItem `gorm:"foreignKey:OrderID" json:"items"`
+}
+
+func (o *Order) TableName() string {
+	return "orders"
+}
+
+// OrderItem 订单明细, 保存下单时的商品快照
+type OrderItem struct {
+	ID             int64     `gorm:"column:id;primary_key" json:"id"`
+	OrderID        int64     `gorm:"column:order_id;not null;default:0;index" json:"order_id"`
+	CommodityID    int64     `gorm:"column:commodity_id;not null;default:0" json:"commodity_id"`
+	SkuID          int64     `gorm:"column:sku_id;not null;default:0;index" json:"sku_id"`
+	CommodityName  string    `gorm:"column:commodity_name;not null;default:''" json:"commodity_name"`
+	SkuName        string    `gorm:"column:sku_name;not null;default:''" json:"sku_name"`
+	CoverImg       string    `gorm:"column:cover_img;not null;default:''" json:"cover_img"`
+	Price          int64     `gorm:"column:price;not null;default:0" json:"price"`
+	Quantity       int64     `gorm:"column:quantity;not null;default:0" json:"quantity"`
+	Amount         int64     `gorm:"column:amount;not null;default:0" json:"amount"`                   // Price * Quantity
+	DiscountAmount int64     `gorm:"column:discount_amount;not null;default:0" json:"discount_amount"` // 分摊到该明细的优惠金额
+	PayAmount      int64     `gorm:"column:pay_amount;not null;default:0" json:"pay_amount"`           // Amount - DiscountAmount, 退款按此计算
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (o *OrderItem) TableName() string {
+	return "order_items"
+}
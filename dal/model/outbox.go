@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// OutboxEvent 待发布的领域事件, 和业务数据在同一个事务中写入, 由中继异步投递
+type OutboxEvent struct {
+	ID          int64     `gorm:"column:id;primary_key" json:"id"`
+	EventID     string    `gorm:"column:event_id;not null;default:'';uniqueIndex" json:"event_id"` // 投递给消费方的去重ID
+	Topic       string    `gorm:"column:topic;not null;default:''" json:"topic"`
+	EventKey    string    `gorm:"column:event_key;not null;default:''" json:"event_key"`
+	Payload     string    `gorm:"column:payload;type:text" json:"payload"`
+	Status      int8      `gorm:"column:status;not null;default:0;index:idx_status_retry" json:"status"`
+	Attempts    int       `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextRetryAt time.Time `gorm:"column:next_retry_at;index:idx_status_retry" json:"next_retry_at"`
+	LastError   string    `gorm:"column:last_error;size:512;not null;default:''" json:"last_error"`    // 最近一次投递失败的原因, 超长截断
+	LeaseOwner  string    `gorm:"column:lease_owner;size:64;not null;default:''" json:"lease_owner"`   // 认领这条事件的中继批次
+	LeaseUntil  time.Time `gorm:"column:lease_until;default:'1970-01-01 00:00:00'" json:"lease_until"` // 认领到期前其他中继不会再取
+	PublishedAt time.Time `gorm:"column:published_at;default:'1970-01-01 00:00:00'" json:"published_at"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (o *OutboxEvent) TableName() string {
+	return "outbox_events"
+}
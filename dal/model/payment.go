@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// Payment 支付单, 一个订单可能有多次支付尝试, 每次尝试由客户端的幂等键区分
+type Payment struct {
+	ID             int64                 `gorm:"column:id;primary_key" json:"id"`
+	PaymentNo      string                `gorm:"column:payment_no;not null;default:'';uniqueIndex" json:"payment_no"` // 传给网关的商户单号
+	IdempotencyKey string                `gorm:"column:idempotency_key;not null;default:'';uniqueIndex" json:"idempotency_key"`
+	OrderID        int64                 `gorm:"column:order_id;not null;default:0;index" json:"order_id"`
+	OrderNo        string                `gorm:"column:order_no;not null;default:''" json:"order_no"`
+	UserID         int64                 `gorm:"column:user_id;not null;default:0" json:"user_id"`
+	Channel        string                `gorm:"column:channel;not null;default:''" json:"channel"`
+	Amount         int64                 `gorm:"column:amount;not null;default:0" json:"amount"` // 单位: 分
+	Status         int8                  `gorm:"column:status;not null;default:0" json:"status"`
+	TradeNo        string                `gorm:"column:trade_no;not null;default:''" json:"trade_no"` // 网关侧流水号
+	PayURL         string                `gorm:"column:pay_url;not null;default:''" json:"pay_url"`
+	FailReason     string                `gorm:"column:fail_reason;not null;default:''" json:"fail_reason"`
+	PaidAt         time.Time             `gorm:"column:paid_at;default:'1970-01-01 00:00:00'" json:"paid_at"`
+	IsDel          soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt      time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (p *Payment) TableName() string {
+	return "payments"
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// Review 商品评价, 追评也存一行, ParentID指向首次评价
+type Review struct {
+	ID            int64                 `gorm:"column:id;primary_key" json:"id"`
+	ParentID      int64                 `gorm:"column:parent_id;not null;default:0;uniqueIndex:uk_order_item_parent" json:"parent_id"`
+	OrderItemID   int64                 `gorm:"column:order_item_id;not null;default:0;uniqueIndex:uk_order_item_parent" json:"order_item_id"`
+	OrderID       int64                 `gorm:"column:order_id;not null;default:0" json:"order_id"`
+	UserID        int64                 `gorm:"column:user_id;not null;default:0;index" json:"user_id"`
+	CommodityID   int64                 `gorm:"column:commodity_id;not null;default:0;index" json:"commodity_id"`
+	SkuID         int64                 `gorm:"column:sku_id;not null;default:0" json:"sku_id"`
+	SkuName       string                `gorm:"column:sku_name;not null;default:''" json:"sku_name"`
+	Rating        int8                  `gorm:"column:rating;not null;default:0" json:"rating"` // 1~5星, 追评为0
+	Content       string                `gorm:"column:content;type:varchar(1000);not null;default:''" json:"content"`
+	Images        []string              `gorm:"column:images;type:varchar(2048);not null;default:'';serializer:json" json:"images"`
+	State         int8                  `gorm:"column:state;not null;default:0" json:"state"`
+	RejectReason  string                `gorm:"column:reject_reason;not null;default:''" json:"reject_reason"`
+	ModeratedBy   int64                 `gorm:"column:moderated_by;not null;default:0" json:"moderated_by"`
+	MerchantReply string                `gorm:"column:merchant_reply;type:varchar(1000);not null;default:''" json:"merchant_reply"`
+	RepliedAt     time.Time             `gorm:"column:replied_at;default:'1970-01-01 00:00:00'" json:"replied_at"`
+	IsDel         soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt     time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time             `gorm:"column:updated_at" json:"updated_at"`
+
+	FollowUp *Review `gorm:"foreignKey:ParentID" json:"follow_up"`
+}
+
+func (r *Review) TableName() string {
+	return "reviews"
+}
@@ -23,3 +23,27 @@ type User struct {
 func (u *User) TableName() string {
 	return "users"
 }
+
+func (u User) GetID() int64 {
+	return u.ID
+}
+
+// UserAddress 用户收货地址, 每个用户最多一个默认地址
+type UserAddress struct {
+	ID            int64                 `gorm:"column:id;primary_key" json:"id"`
+	UserID        int64                 `gorm:"column:user_id;not null;default:0;index" json:"user_id"`
+	UserName      string                `gorm:"column:user_name;not null;default:''" json:"user_name"`   // 收货人
+	UserPhone     string                `gorm:"column:user_phone;not null;default:''" json:"user_phone"` // 收货人手机号
+	Province      string                `gorm:"column:province;not null;default:''" json:"province"`
+	City          string                `gorm:"column:city;not null;default:''" json:"city"`
+	District      string                `gorm:"column:district;not null;default:''" json:"district"`
+	Detail        string                `gorm:"column:detail;not null;default:''" json:"detail"`
+	IsUserDefault int8                  `gorm:"column:is_user_default;not null;default:0" json:"is_user_default"` // enum.AddressIsUserDefault
+	IsDel         soft_delete.DeletedAt `gorm:"softDelete:flag" json:"is_del"`
+	CreatedAt     time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (u *UserAddress) TableName() string {
+	return "user_addresses"
+}
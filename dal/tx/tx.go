@@ -0,0 +1,136 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+)
+
+// Conner 开启事务用的连接入口, dao.DB实现了这个接口
+type Conner interface {
+	Conn(ctx context.Context) *gorm.DB
+}
+
+// Manager 把多个DAO调用放进同一个事务. 事务通过ctx传递, DAO用DBProvider拿连接时自动取到事务连接,
+// 不需要在方法之间传*gorm.DB
+type Manager struct {
+	db Conner
+}
+
+func NewManager(db Conner) *Manager {
+	return &Manager{db: db}
+}
+
+type txKey struct{}
+
+type txState struct {
+	db    *gorm.DB
+	depth int
+	hooks []func(ctx context.Context)
+}
+
+// FromContext 取出ctx上正在进行的事务, 不在事务中时返回false
+func FromContext(ctx context.Context) (*gorm.DB, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+
+	return state.db, true
+}
+
+// Do 在事务中执行fn, fn返回错误或panic时回滚, 否则提交.
+// ctx上已经有事务时用保存点嵌套, 内层失败只回滚到保存点, 由外层决定整个事务是否继续
+func (m *Manager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if parent, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.nested(ctx, parent, fn)
+	}
+
+	db := m.db.Conn(ctx).Begin()
+	if db.Error != nil {
+		return errcode.Wrap("tx begin err", db.Error)
+	}
+	state := &txState{db: db}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		// fn返回错误或者panic都走到这里, panic在回滚后继续往上抛
+		if err := db.Rollback().Error; err != nil {
+			logger.New(ctx).Error("tx rollback err", "err", err)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	if err := db.Commit().Error; err != nil {
+		return errcode.Wrap("tx commit err", err)
+	}
+	committed = true
+
+	for _, hook := range state.hooks {
+		runHook(ctx, hook)
+	}
+
+	return nil
+}
+
+func (m *Manager) nested(ctx context.Context, parent *txState, fn func(ctx context.Context) error) error {
+	state := &txState{db: parent.db, depth: parent.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", state.depth)
+	if err := state.db.SavePoint(savepoint).Error; err != nil {
+		return errcode.Wrap("tx savepoint err", err)
+	}
+
+	done := false
+	defer func() {
+		if done {
+			return
+		}
+		if err := state.db.RollbackTo(savepoint).Error; err != nil {
+			logger.New(ctx).Error("tx rollback to savepoint err", "savepoint", savepoint, "err", err)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	done = true
+
+	// 内层成功后钩子交给外层, 整个事务提交后才执行; 内层回滚时钩子随之丢弃
+	parent.hooks = append(parent.hooks, state.hooks...)
+	return nil
+}
+
+// AfterCommit 注册事务提交后执行的钩子, 用于删缓存、发事件这类不能回滚的操作.
+// 事务回滚时钩子不执行; ctx不在事务中时立即执行
+func AfterCommit(ctx context.Context, hook func(ctx context.Context)) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		runHook(ctx, hook)
+		return
+	}
+
+	state.hooks = append(state.hooks, hook)
+}
+
+// runHook 事务已经提交, 钩子panic不能影响调用方拿到的结果
+func runHook(ctx context.Context, hook func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.New(ctx).Error("tx after commit hook panic", "panic", r)
+		}
+	}()
+
+	hook(ctx)
+}
@@ -0,0 +1,205 @@
+package tx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// fakePool 只记录执行过的语句, 不连真实数据库
+type fakePool struct {
+	stmts []string
+}
+
+func (p *fakePool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *fakePool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	p.stmts = append(p.stmts, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (p *fakePool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *fakePool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+func (p *fakePool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	p.stmts = append(p.stmts, "BEGIN")
+	return &fakeTx{p}, nil
+}
+
+type fakeTx struct {
+	*fakePool
+}
+
+func (t *fakeTx) Commit() error {
+	t.stmts = append(t.stmts, "COMMIT")
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.stmts = append(t.stmts, "ROLLBACK")
+	return nil
+}
+
+type fakeConner struct {
+	db *gorm.DB
+}
+
+func (c fakeConner) Conn(ctx context.Context) *gorm.DB {
+	return c.db.WithContext(ctx)
+}
+
+func newTestManager(t *testing.T) (*Manager, *fakePool) {
+	t.Helper()
+	pool := new(fakePool)
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewManager(fakeConner{db}), pool
+}
+
+func exec(ctx context.Context, t *testing.T, sql string) {
+	t.Helper()
+	db, ok := FromContext(ctx)
+	if !ok {
+		t.Fatal("ctx not in tx")
+	}
+	if err := db.Exec(sql).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDoCommit(t *testing.T) {
+	m, pool := newTestManager(t)
+
+	var hooked []string
+	err := m.Do(context.Background(), func(ctx context.Context) error {
+		exec(ctx, t, "INSERT a")
+		AfterCommit(ctx, func(ctx context.Context) {
+			if _, ok := FromContext(ctx); ok {
+				t.Error("after commit hook should run outside tx")
+			}
+			hooked = append(hooked, "a")
+		})
+		if len(hooked) > 0 {
+			t.Error("hook ran before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"BEGIN", "INSERT a", "COMMIT"}; !reflect.DeepEqual(pool.stmts, want) {
+		t.Errorf("stmts = %v, want %v", pool.stmts, want)
+	}
+	if !reflect.DeepEqual(hooked, []string{"a"}) {
+		t.Errorf("hooked = %v", hooked)
+	}
+}
+
+func TestDoRollback(t *testing.T) {
+	m, pool := newTestManager(t)
+	errBiz := errors.New("biz err")
+
+	hooked := false
+	err := m.Do(context.Background(), func(ctx context.Context) error {
+		exec(ctx, t, "INSERT a")
+		AfterCommit(ctx, func(ctx context.Context) { hooked = true })
+		return errBiz
+	})
+	if !errors.Is(err, errBiz) {
+		t.Fatalf("err = %v, want %v", err, errBiz)
+	}
+
+	if want := []string{"BEGIN", "INSERT a", "ROLLBACK"}; !reflect.DeepEqual(pool.stmts, want) {
+		t.Errorf("stmts = %v, want %v", pool.stmts, want)
+	}
+	if hooked {
+		t.Error("hook ran after rollback")
+	}
+}
+
+func TestDoNestedSavepoint(t *testing.T) {
+	m, pool := newTestManager(t)
+
+	var hooked []string
+	err := m.Do(context.Background(), func(ctx context.Context) error {
+		exec(ctx, t, "INSERT a")
+
+		innerErr := m.Do(ctx, func(ctx context.Context) error {
+			exec(ctx, t, "INSERT b")
+			AfterCommit(ctx, func(ctx context.Context) { hooked = append(hooked, "b") })
+			return errors.New("inner failed")
+		})
+		if innerErr == nil {
+			t.Error("inner err lost")
+		}
+
+		return m.Do(ctx, func(ctx context.Context) error {
+			exec(ctx, t, "INSERT c")
+			AfterCommit(ctx, func(ctx context.Context) { hooked = append(hooked, "c") })
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"BEGIN", "INSERT a",
+		"SAVEPOINT sp_1", "INSERT b", "ROLLBACK TO SAVEPOINT sp_1",
+		"SAVEPOINT sp_1", "INSERT c",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(pool.stmts, want) {
+		t.Errorf("stmts = %v, want %v", pool.stmts, want)
+	}
+	// 回滚掉的内层事务注册的钩子不执行
+	if !reflect.DeepEqual(hooked, []string{"c"}) {
+		t.Errorf("hooked = %v, want [c]", hooked)
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	m, pool := newTestManager(t)
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("recover = %v, want boom", r)
+		}
+		if want := []string{"BEGIN", "INSERT a", "ROLLBACK"}; !reflect.DeepEqual(pool.stmts, want) {
+			t.Errorf("stmts = %v, want %v", pool.stmts, want)
+		}
+	}()
+
+	_ = m.Do(context.Background(), func(ctx context.Context) error {
+		exec(ctx, t, "INSERT a")
+		panic("boom")
+	})
+}
+
+func TestAfterCommitOutsideTx(t *testing.T) {
+	hooked := false
+	AfterCommit(context.Background(), func(ctx context.Context) { hooked = true })
+	if !hooked {
+		t.Error("hook outside tx should run immediately")
+	}
+}
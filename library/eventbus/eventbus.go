@@ -0,0 +1,23 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+)
+
+// Event 领域事件, 由outbox中继投递. 投递语义是至少一次, ID全局唯一, 消费方按ID去重
+type Event struct {
+	ID         string    // 去重ID, 同一事件重试投递时不变
+	Topic      string    // 事件类型, 如user.registered
+	Key        string    // 业务主键, 如用户ID、订单号, 方便消费方按实体处理
+	Payload    []byte    // JSON格式的事件内容
+	OccurredAt time.Time // 事件发生时间, 即写入outbox的时间
+}
+
+// Publisher 事件发布, outbox中继只依赖这个接口. 返回nil表示事件已可靠送达, 否则中继会重试
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// Handler 进程内订阅的事件处理函数, 需要是幂等的
+type Handler func(ctx context.Context, event *Event) error
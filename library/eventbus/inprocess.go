@@ -0,0 +1,76 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const defaultDedupSize = 10000
+
+// InProcessPublisher 在当前进程内同步分发事件, 本地开发和单体部署时使用.
+// 某个订阅方失败时整个事件返回错误由中继重试, 已成功的订阅方会再次收到, 所以Handler需要幂等;
+// 全部成功的事件ID会记住一段时间, 中继在标记已发布前崩溃导致的重复投递会被跳过
+type InProcessPublisher struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+
+	dedupMu   sync.Mutex
+	delivered map[string]struct{}
+	ring      []string
+	next      int
+}
+
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{
+		handlers:  make(map[string][]Handler),
+		delivered: make(map[string]struct{}, defaultDedupSize),
+		ring:      make([]string, defaultDedupSize),
+	}
+}
+
+// Subscribe 订阅topic, 需要在中继启动前完成
+func (p *InProcessPublisher) Subscribe(topic string, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[topic] = append(p.handlers[topic], handler)
+}
+
+func (p *InProcessPublisher) Publish(ctx context.Context, event *Event) error {
+	if p.seen(event.ID) {
+		return nil
+	}
+
+	p.mu.RLock()
+	handlers := p.handlers[event.Topic]
+	p.mu.RUnlock()
+
+	for i, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("eventbus: handler %d of %s: %w", i, event.Topic, err)
+		}
+	}
+
+	p.markDelivered(event.ID)
+	return nil
+}
+
+func (p *InProcessPublisher) seen(id string) bool {
+	p.dedupMu.Lock()
+	defer p.dedupMu.Unlock()
+	_, ok := p.delivered[id]
+	return ok
+}
+
+// markDelivered 用环形缓冲只保留最近的事件ID, 内存占用固定
+func (p *InProcessPublisher) markDelivered(id string) {
+	p.dedupMu.Lock()
+	defer p.dedupMu.Unlock()
+
+	if old := p.ring[p.next]; old != "" {
+		delete(p.delivered, old)
+	}
+	p.ring[p.next] = id
+	p.delivered[id] = struct{}{}
+	p.next = (p.next + 1) % len(p.ring)
+}
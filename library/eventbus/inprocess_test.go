@@ -0,0 +1,54 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInProcessPublisher(t *testing.T) {
+	p := NewInProcessPublisher()
+
+	var got []string
+	fail := true
+	p.Subscribe("user.registered", func(ctx context.Context, event *Event) error {
+		got = append(got, "a:"+event.ID)
+		return nil
+	})
+	p.Subscribe("user.registered", func(ctx context.Context, event *Event) error {
+		if fail {
+			return errors.New("temporary")
+		}
+		got = append(got, "b:"+event.ID)
+		return nil
+	})
+
+	event := &Event{ID: "e1", Topic: "user.registered"}
+	if err := p.Publish(context.Background(), event); err == nil {
+		t.Fatal("want err when a handler fails")
+	}
+
+	// 中继重试, 已成功的订阅方会再收到一次
+	fail = false
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	// 已全部送达的事件再次投递直接跳过
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a:e1", "a:e1", "b:e1"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if err := p.Publish(context.Background(), &Event{ID: "e2", Topic: "order.paid"}); err != nil {
+		t.Errorf("topic without subscriber: %v", err)
+	}
+}
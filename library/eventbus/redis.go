@@ -0,0 +1,62 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultStreamPrefix = "gomall:events:"
+	defaultStreamMaxLen = 100000
+	dedupTTL            = 24 * time.Hour
+)
+
+// publishScript 去重和写入stream在一个脚本里完成, 不会出现占位成功但没写进stream, 或写进了但没占位的情况.
+// KEYS: 去重key, stream; ARGV: 去重过期秒数, stream最大长度, 事件字段...
+var publishScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[2], '*', unpack(ARGV, 3))
+redis.call('SET', KEYS[1], 1, 'EX', ARGV[1])
+return 1
+`)
+
+// RedisStreamPublisher 把事件写进Redis Stream, 每个topic一个stream, 消费方用消费组读取.
+// 按事件ID去重, 中继重试时已经写入过的事件不会重复进入stream
+type RedisStreamPublisher struct {
+	rdb    redis.UniversalClient
+	prefix string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher stream的key为prefix+topic, 如gomall:events:user.registered
+func NewRedisStreamPublisher(rdb redis.UniversalClient, prefix string) *RedisStreamPublisher {
+	if prefix == "" {
+		prefix = defaultStreamPrefix
+	}
+	return &RedisStreamPublisher{rdb: rdb, prefix: prefix, maxLen: defaultStreamMaxLen}
+}
+
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event *Event) error {
+	stream := p.prefix + event.Topic
+	// 去重key用stream名做hash tag, 集群模式下和stream在同一个slot, 才能在一个脚本里操作
+	dedupKey := "{" + stream + "}:published:" + event.ID
+	args := []any{
+		int64(dedupTTL / time.Second), p.maxLen,
+		"id", event.ID,
+		"topic", event.Topic,
+		"key", event.Key,
+		"payload", event.Payload,
+		"occurred_at", event.OccurredAt.UnixMilli(),
+	}
+
+	if err := publishScript.Run(ctx, p.rdb, []string{dedupKey, stream}, args...).Err(); err != nil {
+		return fmt.Errorf("eventbus: publish %s: %w", event.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStreamPublisherDedup(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	p := NewRedisStreamPublisher(rdb, "")
+	ctx := context.Background()
+
+	event := &Event{ID: "e1", Topic: "order.paid", Key: "O1", Payload: []byte(`{"order_no":"O1"}`), OccurredAt: time.Now()}
+	// 中继重试时同一事件会发布多次
+	for range 3 {
+		if err := p.Publish(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := rdb.XRange(ctx, "gomall:events:order.paid", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].Values["id"] != "e1" || msgs[0].Values["payload"] != `{"order_no":"O1"}` {
+		t.Fatalf("stream = %+v, want one message of e1", msgs)
+	}
+}
@@ -0,0 +1,102 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Gateway 支付网关, 每个支付渠道(mock, 支付宝, 微信...)各自实现, 领域层只依赖这个接口
+type Gateway interface {
+	// Channel 渠道标识, 与payments表的channel字段对应
+	Channel() string
+	// CreatePayment 在网关侧下单, 返回客户端拉起支付需要的信息. 同一PaymentNo重复下单要返回同一笔交易,
+	// 上次下单超时的支付单会用原来的PaymentNo重新下单
+	CreatePayment(ctx context.Context, req *CreatePaymentReq) (*CreatePaymentResp, error)
+	// QueryPayment 主动查询支付结果, 用于回调丢失或下单超时后的补偿
+	QueryPayment(ctx context.Context, paymentNo string) (*QueryPaymentResp, error)
+	// Refund 对已支付成功的支付单发起(部分)退款
+	Refund(ctx context.Context, req *RefundReq) (*RefundResp, error)
+	// VerifyCallback 校验异步回调的签名并解析出支付结果
+	VerifyCallback(ctx context.Context, header http.Header, body []byte) (*Notification, error)
+}
+
+type TradeStatus string
+
+const (
+	TradeStatusPending TradeStatus = "PENDING"
+	TradeStatusSuccess TradeStatus = "SUCCESS"
+	TradeStatusFailed  TradeStatus = "FAILED"
+)
+
+var (
+	ErrSignature     = errors.New("payment: invalid callback signature")
+	ErrTimeout       = errors.New("payment: gateway timeout")
+	ErrTradeNotFound = errors.New("payment: trade not found")
+)
+
+type CreatePaymentReq struct {
+	PaymentNo string // 商户侧支付单号, 网关以此做幂等
+	Amount    int64  // 单位: 分
+	Subject   string
+	NotifyURL string
+	ExpireAt  time.Time
+}
+
+type CreatePaymentResp struct {
+	TradeNo string // 网关侧流水号
+	PayURL  string // 客户端跳转或拉起支付的地址
+}
+
+type QueryPaymentResp struct {
+	TradeNo string
+	Status  TradeStatus
+	Amount  int64
+	PaidAt  time.Time
+}
+
+type RefundReq struct {
+	PaymentNo    string
+	RefundNo     string // 商户侧退款单号, 网关以此做幂等
+	RefundAmount int64
+	TotalAmount  int64
+	Reason       string
+}
+
+type RefundResp struct {
+	RefundTradeNo string
+	Status        TradeStatus
+}
+
+// Notification 网关异步回调解析后的支付结果
+type Notification struct {
+	PaymentNo string
+	TradeNo   string
+	Status    TradeStatus
+	Amount    int64
+	PaidAt    time.Time
+}
+
+// Registry 按渠道管理已启用的支付网关
+type Registry struct {
+	gateways map[string]Gateway
+}
+
+func NewRegistry(gateways ...Gateway) *Registry {
+	r := &Registry{gateways: make(map[string]Gateway, len(gateways))}
+	for _, gw := range gateways {
+		r.Register(gw)
+	}
+
+	return r
+}
+
+func (r *Registry) Register(gw Gateway) {
+	r.gateways[gw.Channel()] = gw
+}
+
+func (r *Registry) Get(channel string) (Gateway, bool) {
+	gw, ok := r.gateways[channel]
+	return gw, ok
+}
@@ -0,0 +1,248 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+)
+
+// MockScenario mock网关模拟的支付结果
+type MockScenario string
+
+const (
+	MockScenarioSuccess   MockScenario = "success"   // 下单成功, 异步回调支付成功
+	MockScenarioFail      MockScenario = "fail"      // 下单成功, 异步回调支付失败
+	MockScenarioTimeout   MockScenario = "timeout"   // 下单请求超时, 但网关侧实际已受理, 之后照常回调支付成功
+	MockScenarioDuplicate MockScenario = "duplicate" // 支付成功的回调重复推送两次
+)
+
+const (
+	MockSignatureHeader = "X-Mock-Signature"
+
+	mockNotifyRetry = 3
+	mockMaxTimeout  = 30 * time.Second
+)
+
+type mockTrade struct {
+	tradeNo  string
+	amount   int64
+	status   TradeStatus
+	paidAt   time.Time
+	refunded int64
+	refunds  map[string]*RefundResp // 退款单号 -> 退款结果, 同一退款单号重复请求返回同一结果
+}
+
+// mockNotifyBody mock网关回调的报文
+type mockNotifyBody struct {
+	PaymentNo string      `json:"payment_no"`
+	TradeNo   string      `json:"trade_no"`
+	Status    TradeStatus `json:"status"`
+	Amount    int64       `json:"amount"`
+	PaidAt    int64       `json:"paid_at"`
+}
+
+// MockGateway 进程内运行的模拟支付网关, 不依赖真实的支付服务就能把下单->回调->订单支付的流程跑通
+type MockGateway struct {
+	secret      string
+	notifyDelay time.Duration
+	client      *http.Client
+
+	mu       sync.Mutex
+	scenario MockScenario
+	trades   map[string]*mockTrade
+}
+
+func NewMockGateway(secret string, scenario MockScenario, notifyDelay time.Duration) *MockGateway {
+	if scenario == "" {
+		scenario = MockScenarioSuccess
+	}
+
+	return &MockGateway{
+		secret:      secret,
+		notifyDelay: notifyDelay,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		scenario:    scenario,
+		trades:      make(map[string]*mockTrade),
+	}
+}
+
+// SetScenario 切换之后下单的模拟结果
+func (m *MockGateway) SetScenario(scenario MockScenario) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scenario = scenario
+}
+
+func (m *MockGateway) Channel() string {
+	return enum.PaymentChannelMock
+}
+
+func (m *MockGateway) CreatePayment(ctx context.Context, req *CreatePaymentReq) (*CreatePaymentResp, error) {
+	m.mu.Lock()
+	scenario := m.scenario
+	trade, ok := m.trades[req.PaymentNo]
+	if !ok {
+		trade = &mockTrade{
+			tradeNo: fmt.Sprintf("MOCK%d", time.Now().UnixNano()),
+			amount:  req.Amount,
+			status:  TradeStatusPending,
+			refunds: make(map[string]*RefundResp),
+		}
+		m.trades[req.PaymentNo] = trade
+	}
+	m.mu.Unlock()
+
+	// 同一支付单重复下单直接返回, 不重复回调
+	if !ok {
+		go m.notify(req, trade, scenario)
+	}
+
+	if scenario == MockScenarioTimeout {
+		select {
+		case <-ctx.Done():
+		case <-time.After(mockMaxTimeout):
+		}
+		return nil, ErrTimeout
+	}
+
+	return &CreatePaymentResp{
+		TradeNo: trade.tradeNo,
+		PayURL:  "mock://pay?payment_no=" + req.PaymentNo,
+	}, nil
+}
+
+func (m *MockGateway) QueryPayment(ctx context.Context, paymentNo string) (*QueryPaymentResp, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trade, ok := m.trades[paymentNo]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+
+	return &QueryPaymentResp{
+		TradeNo: trade.tradeNo,
+		Status:  trade.status,
+		Amount:  trade.amount,
+		PaidAt:  trade.paidAt,
+	}, nil
+}
+
+func (m *MockGateway) Refund(ctx context.Context, req *RefundReq) (*RefundResp, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trade, ok := m.trades[req.PaymentNo]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+
+	if resp, ok := trade.refunds[req.RefundNo]; ok && resp.Status == TradeStatusSuccess {
+		return resp, nil
+	}
+
+	if trade.status != TradeStatusSuccess || trade.refunded+req.RefundAmount > trade.amount {
+		return &RefundResp{Status: TradeStatusFailed}, nil
+	}
+
+	trade.refunded += req.RefundAmount
+	resp := &RefundResp{
+		RefundTradeNo: fmt.Sprintf("MOCKRF%d", time.Now().UnixNano()),
+		Status:        TradeStatusSuccess,
+	}
+	trade.refunds[req.RefundNo] = resp
+	return resp, nil
+}
+
+func (m *MockGateway) VerifyCallback(ctx context.Context, header http.Header, body []byte) (*Notification, error) {
+	if !hmac.Equal([]byte(header.Get(MockSignatureHeader)), []byte(m.sign(body))) {
+		return nil, ErrSignature
+	}
+
+	notifyBody := new(mockNotifyBody)
+	if err := json.Unmarshal(body, notifyBody); err != nil {
+		return nil, err
+	}
+
+	n := &Notification{
+		PaymentNo: notifyBody.PaymentNo,
+		TradeNo:   notifyBody.TradeNo,
+		Status:    notifyBody.Status,
+		Amount:    notifyBody.Amount,
+	}
+	if notifyBody.PaidAt > 0 {
+		n.PaidAt = time.Unix(notifyBody.PaidAt, 0)
+	}
+
+	return n, nil
+}
+
+// notify 模拟用户完成支付后网关的异步回调
+func (m *MockGateway) notify(req *CreatePaymentReq, trade *mockTrade, scenario MockScenario) {
+	time.Sleep(m.notifyDelay)
+
+	m.mu.Lock()
+	if scenario == MockScenarioFail {
+		trade.status = TradeStatusFailed
+	} else {
+		trade.status = TradeStatusSuccess
+		trade.paidAt = time.Now()
+	}
+	notifyBody := &mockNotifyBody{
+		PaymentNo: req.PaymentNo,
+		TradeNo:   trade.tradeNo,
+		Status:    trade.status,
+		Amount:    trade.amount,
+	}
+	if !trade.paidAt.IsZero() {
+		notifyBody.PaidAt = trade.paidAt.Unix()
+	}
+	m.mu.Unlock()
+
+	bs, _ := json.Marshal(notifyBody)
+	times := 1
+	if scenario == MockScenarioDuplicate {
+		times = 2
+	}
+
+	for i := 0; i < times; i++ {
+		m.post(req.NotifyURL, bs)
+	}
+}
+
+// post 推送回调, 和真实网关一样非2xx响应会重试
+func (m *MockGateway) post(url string, body []byte) {
+	for i := 0; i < mockNotifyRetry; i++ {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(MockSignatureHeader, m.sign(body))
+
+		resp, err := m.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return
+			}
+		}
+
+		time.Sleep(time.Duration(i+1) * m.notifyDelay)
+	}
+}
+
+func (m *MockGateway) sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(m.secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
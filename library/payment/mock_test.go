@@ -0,0 +1,124 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newNotifyServer(t *testing.T, gw *MockGateway) (*httptest.Server, chan *Notification) {
+	notifications := make(chan *Notification, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n, err := gw.VerifyCallback(r.Context(), r.Header, body)
+		if err != nil {
+			t.Errorf("VerifyCallback err: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		notifications <- n
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, notifications
+}
+
+func waitNotification(t *testing.T, notifications chan *Notification) *Notification {
+	select {
+	case n := <-notifications:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("notification not received")
+		return nil
+	}
+}
+
+func TestMockGatewayScenarios(t *testing.T) {
+	tests := []struct {
+		scenario MockScenario
+		status   TradeStatus
+		notifies int
+	}{
+		{MockScenarioSuccess, TradeStatusSuccess, 1},
+		{MockScenarioFail, TradeStatusFailed, 1},
+		{MockScenarioDuplicate, TradeStatusSuccess, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scenario), func(t *testing.T) {
+			gw := NewMockGateway("secret", tt.scenario, 10*time.Millisecond)
+			srv, notifications := newNotifyServer(t, gw)
+
+			resp, err := gw.CreatePayment(context.Background(), &CreatePaymentReq{PaymentNo: "P1", Amount: 100, NotifyURL: srv.URL})
+			if err != nil {
+				t.Fatalf("CreatePayment err: %v", err)
+			}
+
+			for i := 0; i < tt.notifies; i++ {
+				n := waitNotification(t, notifications)
+				if n.PaymentNo != "P1" || n.TradeNo != resp.TradeNo || n.Status != tt.status || n.Amount != 100 {
+					t.Fatalf("unexpected notification: %+v", n)
+				}
+			}
+
+			queryResp, err := gw.QueryPayment(context.Background(), "P1")
+			if err != nil || queryResp.Status != tt.status {
+				t.Fatalf("QueryPayment = %+v, %v", queryResp, err)
+			}
+		})
+	}
+}
+
+func TestMockGatewayTimeout(t *testing.T) {
+	gw := NewMockGateway("secret", MockScenarioTimeout, 10*time.Millisecond)
+	srv, notifications := newNotifyServer(t, gw)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := gw.CreatePayment(ctx, &CreatePaymentReq{PaymentNo: "P1", Amount: 100, NotifyURL: srv.URL}); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("CreatePayment err = %v, want ErrTimeout", err)
+	}
+
+	// 下单超时不代表支付失败, 网关之后仍会回调
+	if n := waitNotification(t, notifications); n.Status != TradeStatusSuccess {
+		t.Fatalf("unexpected notification: %+v", n)
+	}
+}
+
+func TestMockGatewayVerifyCallback(t *testing.T) {
+	gw := NewMockGateway("secret", MockScenarioSuccess, 0)
+	header := http.Header{}
+	header.Set(MockSignatureHeader, "bad")
+
+	if _, err := gw.VerifyCallback(context.Background(), header, []byte(`{"payment_no":"P1"}`)); !errors.Is(err, ErrSignature) {
+		t.Fatalf("VerifyCallback err = %v, want ErrSignature", err)
+	}
+}
+
+func TestMockGatewayRefund(t *testing.T) {
+	gw := NewMockGateway("secret", MockScenarioSuccess, 0)
+	srv, notifications := newNotifyServer(t, gw)
+	gw.CreatePayment(context.Background(), &CreatePaymentReq{PaymentNo: "P1", Amount: 100, NotifyURL: srv.URL})
+	waitNotification(t, notifications)
+
+	resp, err := gw.Refund(context.Background(), &RefundReq{PaymentNo: "P1", RefundNo: "R1", RefundAmount: 60, TotalAmount: 100})
+	if err != nil || resp.Status != TradeStatusSuccess {
+		t.Fatalf("Refund = %+v, %v", resp, err)
+	}
+
+	// 同一退款单号重复请求幂等
+	again, err := gw.Refund(context.Background(), &RefundReq{PaymentNo: "P1", RefundNo: "R1", RefundAmount: 60, TotalAmount: 100})
+	if err != nil || again.RefundTradeNo != resp.RefundTradeNo {
+		t.Fatalf("Refund = %+v, %v", again, err)
+	}
+
+	// 累计退款不能超过支付金额
+	resp, err = gw.Refund(context.Background(), &RefundReq{PaymentNo: "P1", RefundNo: "R2", RefundAmount: 60, TotalAmount: 100})
+	if err != nil || resp.Status != TradeStatusFailed {
+		t.Fatalf("Refund = %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery 每判定这么多次清理一次过期的key
+const sweepEvery = 1024
+
+// MemoryLimiter 进程内限流, 只适合单实例部署, 多实例时每个实例各自计数
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	buckets map[string]*bucket
+	calls   int
+}
+
+// bucket 令牌桶用tokens和last, 滑动窗口用windowStart、prev和cur
+type bucket struct {
+	tokens      float64
+	last        time.Time
+	windowStart time.Time
+	prev        int64
+	cur         int64
+	expireAt    time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+func (m *MemoryLimiter) Allow(_ context.Context, key string, rule Rule) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok || now.After(b.expireAt) {
+		b = &bucket{tokens: rule.capacity(), last: now, windowStart: now.Truncate(rule.Window)}
+		m.buckets[key] = b
+	}
+
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return m.slidingWindow(b, rule, now), nil
+	}
+	return m.tokenBucket(b, rule, now), nil
+}
+
+func (m *MemoryLimiter) tokenBucket(b *bucket, rule Rule, now time.Time) *Result {
+	elapsed := float64(now.Sub(b.last)) / float64(time.Millisecond)
+	b.tokens = math.Min(rule.capacity(), b.tokens+elapsed*rule.ratePerMs())
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	res := tokenBucketResult(rule, allowed, b.tokens)
+	b.expireAt = now.Add(res.ResetAfter)
+
+	return res
+}
+
+func (m *MemoryLimiter) slidingWindow(b *bucket, rule Rule, now time.Time) *Result {
+	windowStart := now.Truncate(rule.Window)
+	switch passed := windowStart.Sub(b.windowStart); {
+	case passed == rule.Window:
+		b.prev, b.cur = b.cur, 0
+	case passed > rule.Window:
+		b.prev, b.cur = 0, 0
+	}
+	b.windowStart = windowStart
+
+	elapsed := now.Sub(windowStart)
+	estimate := float64(b.prev)*(1-float64(elapsed)/float64(rule.Window)) + float64(b.cur)
+	allowed := estimate+1 <= float64(rule.Limit)
+	if allowed {
+		b.cur++
+	}
+	b.expireAt = windowStart.Add(2 * rule.Window)
+
+	return slidingWindowResult(rule, allowed, b.prev, b.cur, elapsed)
+}
+
+func (m *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if now.After(b.expireAt) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestLimiter() (*MemoryLimiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestMemoryLimiterTokenBucket(t *testing.T) {
+	l, now := newTestLimiter()
+	rule := Rule{Algorithm: AlgorithmTokenBucket, Limit: 10, Window: time.Second, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, _ := l.Allow(ctx, "u1", rule)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i, res)
+		}
+	}
+	res, _ := l.Allow(ctx, "u1", rule)
+	if res.Allowed || res.RetryAfter != 100*time.Millisecond {
+		t.Fatalf("want denied with retry after 100ms, got %+v", res)
+	}
+	// 不同key互不影响
+	if res, _ = l.Allow(ctx, "u2", rule); !res.Allowed {
+		t.Fatal("other key should be allowed")
+	}
+
+	*now = now.Add(100 * time.Millisecond)
+	if res, _ = l.Allow(ctx, "u1", rule); !res.Allowed {
+		t.Fatalf("want allowed after refill, got %+v", res)
+	}
+}
+
+// 高频请求间隔不到1ms时补充的令牌也要累计, 不能按整毫秒截断成0
+func TestMemoryLimiterTokenBucketSubMillisecond(t *testing.T) {
+	l, now := newTestLimiter()
+	rule := Rule{Algorithm: AlgorithmTokenBucket, Limit: 2000, Window: time.Second, Burst: 1}
+	ctx := context.Background()
+
+	if res, _ := l.Allow(ctx, "u1", rule); !res.Allowed {
+		t.Fatal("first request should be allowed")
+	}
+	allowed := 0
+	for range 10 {
+		*now = now.Add(500 * time.Microsecond)
+		if res, _ := l.Allow(ctx, "u1", rule); res.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 10 {
+		t.Fatalf("allowed %d of 10 requests at 2000/s, want 10", allowed)
+	}
+}
+
+func TestMemoryLimiterSlidingWindow(t *testing.T) {
+	l, now := newTestLimiter()
+	rule := Rule{Algorithm: AlgorithmSlidingWindow, Limit: 4, Window: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if res, _ := l.Allow(ctx, "ip", rule); !res.Allowed {
+			t.Fatalf("request %d denied", i)
+		}
+	}
+	res, _ := l.Allow(ctx, "ip", rule)
+	// 当前窗口已满, 下一个窗口开始时上一窗口权重为1, 要再过1/4个窗口估算值才降到3
+	if res.Allowed || res.RetryAfter != 75*time.Second {
+		t.Fatalf("want denied with retry after 75s, got %+v", res)
+	}
+
+	*now = now.Add(time.Minute)
+	if res, _ = l.Allow(ctx, "ip", rule); res.Allowed {
+		t.Fatalf("previous window still weighs full, got %+v", res)
+	}
+	*now = now.Add(15 * time.Second)
+	if res, _ = l.Allow(ctx, "ip", rule); !res.Allowed {
+		t.Fatalf("want allowed after retry after, got %+v", res)
+	}
+}
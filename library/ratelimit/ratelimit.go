@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// 限流算法
+const (
+	// AlgorithmTokenBucket 令牌桶, 按Limit/Window的速率补充令牌, 桶容量为Burst, 允许短时突发
+	AlgorithmTokenBucket = "token_bucket"
+	// AlgorithmSlidingWindow 滑动窗口计数, 用上一个窗口的计数按重叠比例加权估算, 任意Window长度内不超过Limit
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Rule 一条限流规则
+type Rule struct {
+	Algorithm string
+	Limit     int           // Window内允许的请求数
+	Window    time.Duration // 统计窗口
+	Burst     int           // 令牌桶容量, 为0时等于Limit; 滑动窗口不使用
+}
+
+func (r Rule) capacity() float64 {
+	if r.Algorithm == AlgorithmTokenBucket && r.Burst > 0 {
+		return float64(r.Burst)
+	}
+
+	return float64(r.Limit)
+}
+
+// ratePerMs 令牌桶每毫秒补充的令牌数
+func (r Rule) ratePerMs() float64 {
+	return float64(r.Limit) / float64(r.Window.Milliseconds())
+}
+
+// Result 一次判定的结果, 用来生成X-RateLimit-*和Retry-After响应头
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 被拒绝时多久之后可以重试
+	ResetAfter time.Duration // 多久之后额度完全恢复
+}
+
+// Limiter 按key判定是否放行, 同一个key的请求共享额度
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (*Result, error)
+}
+
+// tokenBucketResult 由判定后的令牌数计算结果, 内存和Redis两种实现共用
+func tokenBucketResult(rule Rule, allowed bool, tokens float64) *Result {
+	rate := rule.ratePerMs()
+	res := &Result{
+		Allowed:    allowed,
+		Limit:      int(rule.capacity()),
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((rule.capacity()-tokens)/rate) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration(math.Ceil((1-tokens)/rate)) * time.Millisecond
+	}
+
+	return res
+}
+
+// slidingWindowResult 由上一个窗口、当前窗口的计数和当前窗口已过去的时间计算结果, 计数已包含本次放行的请求
+func slidingWindowResult(rule Rule, allowed bool, prev, cur int64, elapsed time.Duration) *Result {
+	window := rule.Window
+	limit := float64(rule.Limit)
+	estimate := float64(prev)*(1-float64(elapsed)/float64(window)) + float64(cur)
+
+	res := &Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  max(0, int(math.Floor(limit-estimate))),
+		ResetAfter: 2*window - elapsed,
+	}
+	if cur == 0 {
+		res.ResetAfter = window - elapsed
+	}
+	if allowed {
+		return res
+	}
+
+	// 估算值要降到limit-1以下才能再放行一个请求
+	if cur < int64(rule.Limit) && prev > 0 {
+		// 当前窗口还有余量, 等上一个窗口的权重衰减
+		need := 1 - (limit-1-float64(cur))/float64(prev)
+		res.RetryAfter = max(time.Duration(need*float64(window))-elapsed, time.Millisecond)
+		return res
+	}
+	// 当前窗口已经满了, 要等到下一个窗口, 当前窗口的计数变成下一个窗口的prev
+	need := 1 - (limit-1)/float64(cur)
+	res.RetryAfter = window - elapsed + time.Duration(need*float64(window))
+
+	return res
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultKeyPrefix = "gomall:ratelimit:"
+
+// tokenBucketScript KEYS[1]桶 ARGV[1]容量 ARGV[2]每毫秒补充的令牌数
+// 返回{是否放行, 剩余令牌数}, 令牌数是小数, 以字符串返回避免Lua number被截断成整数
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript KEYS[1]计数 ARGV[1]限额 ARGV[2]窗口毫秒数
+// 返回{是否放行, 上一个窗口计数, 当前窗口计数, 当前窗口已过去的毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+
+local state = redis.call('HMGET', KEYS[1], 'idx', 'prev', 'cur')
+local last = tonumber(state[1])
+local prev = tonumber(state[2]) or 0
+local cur = tonumber(state[3]) or 0
+if last == nil or idx - last > 1 then
+	prev, cur = 0, 0
+elseif idx - last == 1 then
+	prev, cur = cur, 0
+end
+
+local allowed = 0
+if prev * (1 - elapsed / window) + cur + 1 <= limit then
+	cur = cur + 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'idx', idx, 'prev', prev, 'cur', cur)
+redis.call('PEXPIRE', KEYS[1], 2 * window - elapsed)
+return {allowed, prev, cur, elapsed}
+`)
+
+// RedisLimiter 基于Redis的限流, 判定在Lua脚本里原子完成, 时间取Redis服务端时间, 多实例共享额度
+type RedisLimiter struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+// NewRedisLimiter key为prefix+限流key, 如gomall:ratelimit:order:10001
+func NewRedisLimiter(rdb redis.UniversalClient, prefix string) *RedisLimiter {
+	if prefix == "" {
+		prefix = defaultKeyPrefix
+	}
+	return &RedisLimiter{rdb: rdb, prefix: prefix}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, rule Rule) (*Result, error) {
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return l.slidingWindow(ctx, key, rule)
+	}
+	return l.tokenBucket(ctx, key, rule)
+}
+
+func (l *RedisLimiter) tokenBucket(ctx context.Context, key string, rule Rule) (*Result, error) {
+	vals, err := tokenBucketScript.Run(ctx, l.rdb, []string{l.prefix + key},
+		rule.capacity(), rule.ratePerMs()).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: token bucket %s: %w", key, err)
+	}
+	if len(vals) != 2 {
+		return nil, fmt.Errorf("ratelimit: token bucket %s: unexpected reply %v", key, vals)
+	}
+
+	allowed, _ := vals[0].(int64)
+	tokensStr, _ := vals[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: token bucket %s: %w", key, err)
+	}
+
+	return tokenBucketResult(rule, allowed == 1, tokens), nil
+}
+
+func (l *RedisLimiter) slidingWindow(ctx context.Context, key string, rule Rule) (*Result, error) {
+	vals, err := slidingWindowScript.Run(ctx, l.rdb, []string{l.prefix + key},
+		rule.Limit, rule.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: sliding window %s: %w", key, err)
+	}
+	if len(vals) != 4 {
+		return nil, fmt.Errorf("ratelimit: sliding window %s: unexpected reply %v", key, vals)
+	}
+
+	return slidingWindowResult(rule, vals[0] == 1, vals[1], vals[2], time.Duration(vals[3])*time.Millisecond), nil
+}
@@ -0,0 +1,136 @@
+package appservice
+
+import (
+	"context"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+type AfterSaleAppSvc struct {
+	afterSaleDomainSvc *domainservice.AfterSaleDomainSvc
+}
+
+func NewAfterSaleAppSvc(afterSaleDomainSvc *domainservice.AfterSaleDomainSvc) *AfterSaleAppSvc {
+	return &AfterSaleAppSvc{afterSaleDomainSvc: afterSaleDomainSvc}
+}
+
+func (a *AfterSaleAppSvc) ApplyAfterSale(ctx context.Context, userID int64, req *request.AfterSaleApplyReq) (*reply.AfterSaleResp, error) {
+	items := make([]*do.AfterSaleItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, &do.AfterSaleItem{
+			OrderItemID:  item.OrderItemID,
+			Quantity:     item.Quantity,
+			RefundAmount: item.RefundAmount,
+		})
+	}
+
+	afterSale, err := a.afterSaleDomainSvc.ApplyAfterSale(ctx, userID, req.OrderNo, req.Type, req.Reason, items)
+	if err != nil {
+		return nil, err
+	}
+
+	// todo: 通知商家有新的售后申请
+
+	return convertAfterSaleResp(afterSale)
+}
+
+func (a *AfterSaleAppSvc) CancelAfterSale(ctx context.Context, userID int64, ticketNo string) error {
+	return a.afterSaleDomainSvc.CancelAfterSale(ctx, userID, ticketNo)
+}
+
+func (a *AfterSaleAppSvc) GetUserAfterSale(ctx context.Context, userID int64, ticketNo string) (*reply.AfterSaleResp, error) {
+	afterSale, err := a.afterSaleDomainSvc.GetUserAfterSale(ctx, userID, ticketNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertAfterSaleResp(afterSale)
+}
+
+func (a *AfterSaleAppSvc) ListUserAfterSales(ctx context.Context, userID int64, pagination *app.Pagination) ([]*reply.AfterSaleResp, error) {
+	return a.listAfterSales(ctx, &do.AfterSaleListFilter{UserID: userID}, pagination)
+}
+
+// ListAfterSales 管理后台的售后单列表, state为nil时不按状态筛选
+func (a *AfterSaleAppSvc) ListAfterSales(ctx context.Context, state *int8, pagination *app.Pagination) ([]*reply.AfterSaleResp, error) {
+	return a.listAfterSales(ctx, &do.AfterSaleListFilter{State: state}, pagination)
+}
+
+// GetAfterSaleDetail 管理后台的售后单详情, 带上完整的操作记录
+func (a *AfterSaleAppSvc) GetAfterSaleDetail(ctx context.Context, ticketNo string) (*reply.AfterSaleResp, error) {
+	afterSale, err := a.afterSaleDomainSvc.GetAfterSale(ctx, ticketNo)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := a.afterSaleDomainSvc.ListAfterSaleLogs(ctx, afterSale.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := convertAfterSaleResp(afterSale)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = util.Copy(&resp.Logs, logs); err != nil {
+		return nil, errcode.Wrap("afterSaleLog转换reply失败", err)
+	}
+
+	return resp, nil
+}
+
+func (a *AfterSaleAppSvc) ApproveAfterSale(ctx context.Context, adminID int64, req *request.AfterSaleAuditReq) (*reply.AfterSaleResp, error) {
+	afterSale, err := a.afterSaleDomainSvc.ApproveAfterSale(ctx, adminID, req.TicketNo, req.Remark)
+	if err != nil {
+		return nil, err
+	}
+
+	// todo: 通知用户退款到账
+
+	return convertAfterSaleResp(afterSale)
+}
+
+func (a *AfterSaleAppSvc) RejectAfterSale(ctx context.Context, adminID int64, req *request.AfterSaleAuditReq) (*reply.AfterSaleResp, error) {
+	afterSale, err := a.afterSaleDomainSvc.RejectAfterSale(ctx, adminID, req.TicketNo, req.Remark)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertAfterSaleResp(afterSale)
+}
+
+func (a *AfterSaleAppSvc) listAfterSales(ctx context.Context, filter *do.AfterSaleListFilter, pagination *app.Pagination) ([]*reply.AfterSaleResp, error) {
+	page := pageQuery(pagination)
+	afterSales, total, err := a.afterSaleDomainSvc.ListAfterSales(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	setPageResult(pagination, page, total)
+
+	resp := make([]*reply.AfterSaleResp, 0, len(afterSales))
+	for _, afterSale := range afterSales {
+		item, err := convertAfterSaleResp(afterSale)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+func convertAfterSaleResp(afterSale *do.AfterSale) (*reply.AfterSaleResp, error) {
+	resp := new(reply.AfterSaleResp)
+	if err := util.Copy(resp, afterSale); err != nil {
+		return nil, errcode.Wrap("afterSale转换reply失败", err)
+	}
+
+	return resp, nil
+}
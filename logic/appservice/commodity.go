@@ -1,6 +1,10 @@
 package appservice
 
-import "github.com/kackerx/go-mall/logic/domainservice"
+import (
+	"context"
+
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
 
 type CommodityApp struct {
 	commoditySvc *domainservice.CommoditySvc
@@ -14,6 +18,6 @@ func NewCommodityApp(
 	}
 }
 
-func (c *CommodityApp) InitCategoryData() error {
-	return c.commoditySvc.InitCategory()
+func (c *CommodityApp) InitCategoryData(ctx context.Context) error {
+	return c.commoditySvc.InitCategory(ctx)
 }
@@ -0,0 +1,115 @@
+package appservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+type CouponAppSvc struct {
+	couponDomainSvc *domainservice.CouponDomainSvc
+}
+
+func NewCouponAppSvc(couponDomainSvc *domainservice.CouponDomainSvc) *CouponAppSvc {
+	return &CouponAppSvc{couponDomainSvc: couponDomainSvc}
+}
+
+func (c *CouponAppSvc) CreateTemplate(ctx context.Context, adminID int64, req *request.CouponTemplateCreateReq) (*reply.CouponTemplateResp, error) {
+	template := new(do.CouponTemplate)
+	if err := util.Copy(template, req); err != nil {
+		return nil, errcode.Wrap("请求转换couponTemplate失败", err)
+	}
+
+	var err error
+	if template.ClaimStartAt, err = time.ParseInLocation(time.DateTime, req.ClaimStartAt, time.Local); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+	if template.ClaimEndAt, err = time.ParseInLocation(time.DateTime, req.ClaimEndAt, time.Local); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+	if req.Stackable {
+		template.Stackable = 1
+	}
+	template.CreatedBy = adminID
+
+	if err = c.couponDomainSvc.CreateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return convertCouponTemplateResp(template)
+}
+
+func (c *CouponAppSvc) ListClaimableTemplates(ctx context.Context, pagination *app.Pagination) ([]*reply.CouponTemplateResp, error) {
+	page := pageQuery(pagination)
+	templates, total, err := c.couponDomainSvc.ListClaimableTemplates(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+	setPageResult(pagination, page, total)
+
+	resp := make([]*reply.CouponTemplateResp, 0, len(templates))
+	for _, template := range templates {
+		item, err := convertCouponTemplateResp(template)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+func (c *CouponAppSvc) ClaimCoupon(ctx context.Context, userID int64, req *request.CouponClaimReq) (*reply.UserCouponResp, error) {
+	coupon, err := c.couponDomainSvc.ClaimCoupon(ctx, userID, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertUserCouponResp(coupon)
+}
+
+// ListUserCoupons 我的优惠券, state为nil时不按状态筛选
+func (c *CouponAppSvc) ListUserCoupons(ctx context.Context, userID int64, state *int8, pagination *app.Pagination) ([]*reply.UserCouponResp, error) {
+	page := pageQuery(pagination)
+	coupons, total, err := c.couponDomainSvc.ListUserCoupons(ctx, userID, state, page)
+	if err != nil {
+		return nil, err
+	}
+	setPageResult(pagination, page, total)
+
+	resp := make([]*reply.UserCouponResp, 0, len(coupons))
+	for _, coupon := range coupons {
+		item, err := convertUserCouponResp(coupon)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+func convertCouponTemplateResp(template *do.CouponTemplate) (*reply.CouponTemplateResp, error) {
+	resp := new(reply.CouponTemplateResp)
+	if err := util.Copy(resp, template); err != nil {
+		return nil, errcode.Wrap("couponTemplate转换reply失败", err)
+	}
+
+	return resp, nil
+}
+
+func convertUserCouponResp(coupon *do.UserCoupon) (*reply.UserCouponResp, error) {
+	resp := new(reply.UserCouponResp)
+	if err := util.Copy(resp, coupon); err != nil {
+		return nil, errcode.Wrap("userCoupon转换reply失败", err)
+	}
+
+	return resp, nil
+}
@@ -22,10 +22,11 @@ import (
 type DemoAppSvc struct {
 	ctx           context.Context
 	demoDoaminSvc *domainservice.DemoDomainSvc
+	cache         *cache.Cache
 }
 
-func NewDemoAppSvc(ctx context.Context, demoDoaminSvc *domainservice.DemoDomainSvc) *DemoAppSvc {
-	return &DemoAppSvc{ctx: ctx, demoDoaminSvc: demoDoaminSvc}
+func NewDemoAppSvc(ctx context.Context, demoDoaminSvc *domainservice.DemoDomainSvc, cache *cache.Cache) *DemoAppSvc {
+	return &DemoAppSvc{ctx: ctx, demoDoaminSvc: demoDoaminSvc, cache: cache}
 }
 
 func (das *DemoAppSvc) CreateDemoOrder(orderReq *request.DemoOrderCreateReq) (*reply.DemoOrderResp, error) {
@@ -35,8 +36,8 @@ func (das *DemoAppSvc) CreateDemoOrder(orderReq *request.DemoOrderCreateReq) (*r
 	}
 
 	// redis ex
-	cache.SetDemoOrder(das.ctx, demoOrder)
-	order, err := cache.GetDemoOrder(das.ctx, demoOrder.Code)
+	das.cache.SetDemoOrder(das.ctx, demoOrder)
+	order, err := das.cache.GetDemoOrder(das.ctx, demoOrder.Code)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,162 @@
+package appservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+type FavoriteAppSvc struct {
+	favoriteDomainSvc *domainservice.FavoriteDomainSvc
+	historyDomainSvc  *domainservice.BrowseHistoryDomainSvc
+	commoditySvc      *domainservice.CommoditySvc
+}
+
+func NewFavoriteAppSvc(
+	favoriteDomainSvc *domainservice.FavoriteDomainSvc,
+	historyDomainSvc *domainservice.BrowseHistoryDomainSvc,
+	commoditySvc *domainservice.CommoditySvc,
+) *FavoriteAppSvc {
+	return &FavoriteAppSvc{
+		favoriteDomainSvc: favoriteDomainSvc,
+		historyDomainSvc:  historyDomainSvc,
+		commoditySvc:      commoditySvc,
+	}
+}
+
+func (f *FavoriteAppSvc) AddFavorite(ctx context.Context, userID int64, req *request.FavoriteReq) error {
+	return f.favoriteDomainSvc.AddFavorite(ctx, userID, req.TargetType, req.TargetID)
+}
+
+func (f *FavoriteAppSvc) RemoveFavorite(ctx context.Context, userID int64, req *request.FavoriteReq) error {
+	return f.favoriteDomainSvc.RemoveFavorite(ctx, userID, req.TargetType, req.TargetID)
+}
+
+// ListFavorites 收藏列表, 带上商品或店铺的当前快照
+func (f *FavoriteAppSvc) ListFavorites(ctx context.Context, userID int64, targetType int8, pagination *app.Pagination) ([]*reply.FavoriteResp, error) {
+	page := pageQuery(pagination)
+	favorites, total, err := f.favoriteDomainSvc.ListFavorites(ctx, userID, targetType, page)
+	if err != nil {
+		return nil, err
+	}
+	setPageResult(pagination, page, total)
+
+	targetIDs := make([]int64, 0, len(favorites))
+	for _, favorite := range favorites {
+		targetIDs = append(targetIDs, favorite.TargetID)
+	}
+
+	resp := make([]*reply.FavoriteResp, 0, len(favorites))
+	switch targetType {
+	case enum.FavoriteTargetCommodity:
+		commodities, err := f.commoditySvc.GetCommodities(ctx, targetIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, favorite := range favorites {
+			item := convertFavoriteResp(favorite)
+			item.Commodity = convertCommoditySnapshot(favorite.TargetID, commodities[favorite.TargetID])
+			resp = append(resp, item)
+		}
+	case enum.FavoriteTargetStore:
+		stores, err := f.commoditySvc.GetStores(ctx, targetIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, favorite := range favorites {
+			item := convertFavoriteResp(favorite)
+			item.Store = convertStoreSnapshot(favorite.TargetID, stores[favorite.TargetID])
+			resp = append(resp, item)
+		}
+	}
+
+	return resp, nil
+}
+
+func (f *FavoriteAppSvc) GetFavoriteCount(ctx context.Context, commodityID int64) (*reply.FavoriteCountResp, error) {
+	count, err := f.favoriteDomainSvc.GetFavoriteCount(ctx, commodityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply.FavoriteCountResp{CommodityID: commodityID, Count: count}, nil
+}
+
+func (f *FavoriteAppSvc) RecordBrowse(ctx context.Context, userID int64, req *request.BrowseRecordReq) error {
+	return f.historyDomainSvc.RecordView(ctx, userID, req.CommodityID)
+}
+
+// ListBrowseHistory 最近浏览, 按浏览时间倒序
+func (f *FavoriteAppSvc) ListBrowseHistory(ctx context.Context, userID int64, pagination *app.Pagination) ([]*reply.BrowseRecordResp, error) {
+	records, total, err := f.historyDomainSvc.ListHistory(ctx, userID, pagination.Offset(), pagination.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	pagination.SetTotal(int(total))
+
+	commodityIDs := make([]int64, 0, len(records))
+	for _, record := range records {
+		commodityIDs = append(commodityIDs, record.CommodityID)
+	}
+	commodities, err := f.commoditySvc.GetCommodities(ctx, commodityIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*reply.BrowseRecordResp, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, &reply.BrowseRecordResp{
+			ViewedAt:  record.ViewedAt.Format(time.DateTime),
+			Commodity: convertCommoditySnapshot(record.CommodityID, commodities[record.CommodityID]),
+		})
+	}
+
+	return resp, nil
+}
+
+func (f *FavoriteAppSvc) ClearBrowseHistory(ctx context.Context, userID int64) error {
+	return f.historyDomainSvc.ClearHistory(ctx, userID)
+}
+
+func convertFavoriteResp(favorite *do.Favorite) *reply.FavoriteResp {
+	return &reply.FavoriteResp{
+		TargetType: favorite.TargetType,
+		TargetID:   favorite.TargetID,
+		CreatedAt:  favorite.CreatedAt.Format(time.DateTime),
+	}
+}
+
+// convertCommoditySnapshot commodity为nil说明商品已被删除
+func convertCommoditySnapshot(commodityID int64, commodity *do.Commodity) *reply.CommoditySnapshotResp {
+	if commodity == nil {
+		return &reply.CommoditySnapshotResp{ID: commodityID, Unavailable: true}
+	}
+
+	return &reply.CommoditySnapshotResp{
+		ID:          commodity.ID,
+		Name:        commodity.Name,
+		CoverImg:    commodity.CoverImg,
+		MinPrice:    commodity.MinPrice,
+		Unavailable: commodity.IsPublished != enum.CommodityPublished,
+	}
+}
+
+// convertStoreSnapshot store为nil说明店铺已被删除
+func convertStoreSnapshot(storeID int64, store *do.Store) *reply.StoreSnapshotResp {
+	if store == nil {
+		return &reply.StoreSnapshotResp{ID: storeID, Unavailable: true}
+	}
+
+	return &reply.StoreSnapshotResp{
+		ID:          store.ID,
+		Name:        store.Name,
+		Logo:        store.Logo,
+		Unavailable: store.IsOpen != enum.StoreOpen,
+	}
+}
@@ -0,0 +1,123 @@
+package appservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+type FlashSaleAppSvc struct {
+	flashSaleDomainSvc *domainservice.FlashSaleDomainSvc
+}
+
+func NewFlashSaleAppSvc(flashSaleDomainSvc *domainservice.FlashSaleDomainSvc) *FlashSaleAppSvc {
+	return &FlashSaleAppSvc{flashSaleDomainSvc: flashSaleDomainSvc}
+}
+
+func (f *FlashSaleAppSvc) CreateFlashSale(ctx context.Context, adminID int64, req *request.FlashSaleCreateReq) (*reply.FlashSaleResp, error) {
+	flashSale := &do.FlashSale{
+		Name:       req.Name,
+		SkuID:      req.SkuID,
+		Price:      req.Price,
+		TotalStock: req.TotalStock,
+		CreatedBy:  adminID,
+	}
+
+	var err error
+	if flashSale.StartAt, err = time.ParseInLocation(time.DateTime, req.StartAt, time.Local); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+	if flashSale.EndAt, err = time.ParseInLocation(time.DateTime, req.EndAt, time.Local); err != nil {
+		return nil, errcode.ErrParams.WithCause(err)
+	}
+
+	if err = f.flashSaleDomainSvc.CreateFlashSale(ctx, flashSale); err != nil {
+		return nil, err
+	}
+
+	return convertFlashSaleResp(flashSale)
+}
+
+func (f *FlashSaleAppSvc) WarmUp(ctx context.Context, req *request.FlashSaleWarmReq) (*reply.FlashSaleResp, error) {
+	flashSale, err := f.flashSaleDomainSvc.WarmUp(ctx, req.FlashSaleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertFlashSaleResp(flashSale)
+}
+
+func (f *FlashSaleAppSvc) Settle(ctx context.Context, req *request.FlashSaleSettleReq) (*reply.FlashSaleResp, error) {
+	flashSale, err := f.flashSaleDomainSvc.Settle(ctx, req.FlashSaleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertFlashSaleResp(flashSale)
+}
+
+func (f *FlashSaleAppSvc) GetFlashSale(ctx context.Context, flashSaleID int64) (*reply.FlashSaleResp, error) {
+	flashSale, err := f.flashSaleDomainSvc.GetFlashSale(ctx, flashSaleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertFlashSaleResp(flashSale)
+}
+
+func (f *FlashSaleAppSvc) IssueToken(ctx context.Context, userID, flashSaleID int64) (*reply.FlashSaleTokenResp, error) {
+	token, err := f.flashSaleDomainSvc.IssueToken(ctx, userID, flashSaleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply.FlashSaleTokenResp{Token: token}, nil
+}
+
+func (f *FlashSaleAppSvc) Grab(ctx context.Context, userID int64, req *request.FlashSaleGrabReq) error {
+	return f.flashSaleDomainSvc.Grab(ctx, userID, req.FlashSaleID, req.Token)
+}
+
+func (f *FlashSaleAppSvc) GetResult(ctx context.Context, userID, flashSaleID int64) (*reply.FlashSaleResultResp, error) {
+	result, err := f.flashSaleDomainSvc.GetResult(ctx, userID, flashSaleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply.FlashSaleResultResp{Status: result.Status, OrderNo: result.OrderNo, FailCode: result.FailCode}, nil
+}
+
+// RunOrderConsumer 启动workers个消费者异步创建秒杀订单, 同时定时回收崩溃的消费者没处理完的请求,
+// 阻塞到ctx取消且所有消费者退出
+func (f *FlashSaleAppSvc) RunOrderConsumer(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f.flashSaleDomainSvc.RequeueStaleGrabs(ctx)
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.flashSaleDomainSvc.ConsumeOrderQueue(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func convertFlashSaleResp(flashSale *do.FlashSale) (*reply.FlashSaleResp, error) {
+	resp := new(reply.FlashSaleResp)
+	if err := util.Copy(resp, flashSale); err != nil {
+		return nil, errcode.Wrap("flashSale转换reply失败", err)
+	}
+
+	return resp, nil
+}
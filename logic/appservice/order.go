@@ -0,0 +1,72 @@
+package appservice
+
+import (
+	"context"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+type OrderAppSvc struct {
+	orderDomainSvc *domainservice.OrderDomainSvc
+}
+
+func NewOrderAppSvc(orderDomainSvc *domainservice.OrderDomainSvc) *OrderAppSvc {
+	return &OrderAppSvc{orderDomainSvc: orderDomainSvc}
+}
+
+func (o *OrderAppSvc) Checkout(ctx context.Context, userID int64, req *request.OrderCheckoutReq) (*reply.CheckoutResp, error) {
+	price, err := o.orderDomainSvc.Checkout(ctx, userID, convertOrderItemReqs(req.Items), req.CouponIDs, req.AutoSelect)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(reply.CheckoutResp)
+	if err = util.Copy(resp, price); err != nil {
+		return nil, errcode.Wrap("price转换reply失败", err)
+	}
+
+	return resp, nil
+}
+
+func (o *OrderAppSvc) CreateOrder(ctx context.Context, userID int64, req *request.OrderCreateReq) (*reply.OrderResp, error) {
+	order, err := o.orderDomainSvc.CreateOrder(ctx, userID, convertOrderItemReqs(req.Items), req.CouponIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// todo: 下单成功后投递延时消息, 超时未支付关闭订单并回补库存
+
+	return convertOrderResp(order)
+}
+
+func (o *OrderAppSvc) GetOrderDetail(ctx context.Context, userID int64, orderNo string) (*reply.OrderResp, error) {
+	order, err := o.orderDomainSvc.GetUserOrder(ctx, userID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertOrderResp(order)
+}
+
+func convertOrderItemReqs(itemReqs []*request.OrderItemReq) []*do.OrderItem {
+	items := make([]*do.OrderItem, 0, len(itemReqs))
+	for _, item := range itemReqs {
+		items = append(items, &do.OrderItem{SkuID: item.SkuID, Quantity: item.Quantity})
+	}
+
+	return items
+}
+
+func convertOrderResp(order *do.Order) (*reply.OrderResp, error) {
+	resp := new(reply.OrderResp)
+	if err := util.Copy(resp, order); err != nil {
+		return nil, errcode.Wrap("order转换reply失败", err)
+	}
+
+	return resp, nil
+}
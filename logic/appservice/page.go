@@ -0,0 +1,26 @@
+package appservice
+
+import (
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// pageQuery 接口层的分页参数转成列表查询条件
+func pageQuery(pagination *app.Pagination) *do.PageQuery {
+	return &do.PageQuery{
+		Offset: pagination.Offset(),
+		Limit:  pagination.GetPageSize(),
+		Keyset: pagination.IsKeyset(),
+		After:  pagination.After(),
+	}
+}
+
+// setPageResult 按页码翻页时回填总数, 按游标翻页时回填下一页的游标
+func setPageResult(pagination *app.Pagination, page *do.PageQuery, total int64) {
+	if page.Keyset {
+		pagination.SetNextCursor(page.Next)
+		return
+	}
+
+	pagination.SetTotal(int(total))
+}
@@ -0,0 +1,59 @@
+package appservice
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/library/eventbus"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+type PaymentAppSvc struct {
+	paymentDomainSvc *domainservice.PaymentDomainSvc
+}
+
+func NewPaymentAppSvc(paymentDomainSvc *domainservice.PaymentDomainSvc) *PaymentAppSvc {
+	return &PaymentAppSvc{paymentDomainSvc: paymentDomainSvc}
+}
+
+func (p *PaymentAppSvc) CreatePayment(ctx context.Context, userID int64, req *request.PaymentCreateReq) (*reply.PaymentResp, error) {
+	payment, err := p.paymentDomainSvc.CreatePayment(ctx, userID, req.OrderNo, req.Channel, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertPaymentResp(payment)
+}
+
+// OnRefundRequired 订阅do.EventPaymentRefundRequired, 事件的Key是支付单号
+func (p *PaymentAppSvc) OnRefundRequired(ctx context.Context, event *eventbus.Event) error {
+	return p.paymentDomainSvc.RefundOrphanPayment(ctx, event.Key)
+}
+
+func (p *PaymentAppSvc) QueryPayment(ctx context.Context, userID int64, paymentNo string) (*reply.PaymentResp, error) {
+	payment, err := p.paymentDomainSvc.QueryPayment(ctx, userID, paymentNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertPaymentResp(payment)
+}
+
+func (p *PaymentAppSvc) HandleNotify(ctx context.Context, channel string, header http.Header, body []byte) error {
+	// todo: 支付成功后通知发货, 发送支付成功消息
+	return p.paymentDomainSvc.HandleNotify(ctx, channel, header, body)
+}
+
+func convertPaymentResp(payment *do.Payment) (*reply.PaymentResp, error) {
+	resp := new(reply.PaymentResp)
+	if err := util.Copy(resp, payment); err != nil {
+		return nil, errcode.Wrap("payment转换reply失败", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,162 @@
+package appservice
+
+import (
+	"context"
+
+	"github.com/kackerx/go-mall/api/reply"
+	"github.com/kackerx/go-mall/api/request"
+	"github.com/kackerx/go-mall/common/app"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/logic/do"
+	"github.com/kackerx/go-mall/logic/domainservice"
+)
+
+// anonymousNickname 用户没有设置昵称时评价展示的名字
+const anonymousNickname = "匿名用户"
+
+type ReviewAppSvc struct {
+	reviewDomainSvc *domainservice.ReviewDomainSvc
+}
+
+func NewReviewAppSvc(reviewDomainSvc *domainservice.ReviewDomainSvc) *ReviewAppSvc {
+	return &ReviewAppSvc{reviewDomainSvc: reviewDomainSvc}
+}
+
+func (r *ReviewAppSvc) CreateReview(ctx context.Context, userID int64, req *request.ReviewCreateReq) (*reply.ReviewResp, error) {
+	review, err := r.reviewDomainSvc.CreateReview(ctx, userID, req.OrderItemID, req.Rating, req.Content, req.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertReviewResp(review, nil)
+}
+
+func (r *ReviewAppSvc) FollowUpReview(ctx context.Context, userID int64, req *request.ReviewFollowUpReq) (*reply.ReviewResp, error) {
+	review, err := r.reviewDomainSvc.FollowUpReview(ctx, userID, req.ReviewID, req.Content, req.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertReviewResp(review, nil)
+}
+
+// ListCommodityReviews 商品详情页的评价列表, 昵称脱敏展示
+func (r *ReviewAppSvc) ListCommodityReviews(ctx context.Context, commodityID int64, pagination *app.Pagination) ([]*reply.ReviewResp, error) {
+	page := pageQuery(pagination)
+	reviews, total, err := r.reviewDomainSvc.ListApprovedReviews(ctx, commodityID, page)
+	if err != nil {
+		return nil, err
+	}
+	setPageResult(pagination, page, total)
+
+	reviewers, err := r.reviewDomainSvc.GetReviewers(ctx, reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*reply.ReviewResp, 0, len(reviews))
+	for _, review := range reviews {
+		item, err := convertReviewResp(review, reviewers[review.UserID])
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+func (r *ReviewAppSvc) GetRatingStats(ctx context.Context, commodityID int64) (*reply.RatingStatsResp, error) {
+	stats, err := r.reviewDomainSvc.GetRatingStats(ctx, commodityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply.RatingStatsResp{
+		CommodityID:  stats.CommodityID,
+		Count:        stats.Count,
+		Average:      stats.Average(),
+		Distribution: stats.Distribution,
+	}, nil
+}
+
+// ListReviews 管理后台的评价列表, state为nil时不按状态筛选
+func (r *ReviewAppSvc) ListReviews(ctx context.Context, filter *do.ReviewListFilter, pagination *app.Pagination) ([]*reply.AdminReviewResp, error) {
+	page := pageQuery(pagination)
+	reviews, total, err := r.reviewDomainSvc.ListReviews(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	setPageResult(pagination, page, total)
+
+	reviewers, err := r.reviewDomainSvc.GetReviewers(ctx, reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*reply.AdminReviewResp, 0, len(reviews))
+	for _, review := range reviews {
+		item, err := convertAdminReviewResp(review, reviewers[review.UserID])
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, item)
+	}
+
+	return resp, nil
+}
+
+func (r *ReviewAppSvc) ModerateReview(ctx context.Context, adminID int64, req *request.ReviewModerateReq) (*reply.AdminReviewResp, error) {
+	review, err := r.reviewDomainSvc.ModerateReview(ctx, adminID, req.ReviewID, req.Approve, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertAdminReviewResp(review, nil)
+}
+
+func (r *ReviewAppSvc) ReplyReview(ctx context.Context, req *request.ReviewReplyReq) (*reply.AdminReviewResp, error) {
+	review, err := r.reviewDomainSvc.ReplyReview(ctx, req.ReviewID, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertAdminReviewResp(review, nil)
+}
+
+// convertReviewResp reviewer为nil时不填昵称
+func convertReviewResp(review *do.Review, reviewer *do.UserBaseInfo) (*reply.ReviewResp, error) {
+	resp := new(reply.ReviewResp)
+	if err := util.Copy(resp, review); err != nil {
+		return nil, errcode.Wrap("review转换reply失败", err)
+	}
+
+	if reviewer != nil {
+		resp.Nickname = util.MaskNickname(reviewer.Nickname)
+		if resp.Nickname == "" {
+			resp.Nickname = anonymousNickname
+		}
+		if resp.FollowUp != nil {
+			resp.FollowUp.Nickname = resp.Nickname
+		}
+	}
+
+	return resp, nil
+}
+
+func convertAdminReviewResp(review *do.Review, reviewer *do.UserBaseInfo) (*reply.AdminReviewResp, error) {
+	reviewResp, err := convertReviewResp(review, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply.AdminReviewResp{
+		ReviewResp:   *reviewResp,
+		ParentID:     review.ParentID,
+		UserID:       review.UserID,
+		CommodityID:  review.CommodityID,
+		OrderItemID:  review.OrderItemID,
+		RejectReason: review.RejectReason,
+	}, nil
+}
@@ -3,22 +3,27 @@ package appservice
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/kackerx/go-mall/api/reply"
 	"github.com/kackerx/go-mall/api/request"
 	"github.com/kackerx/go-mall/common/errcode"
 	"github.com/kackerx/go-mall/common/logger"
 	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/tx"
 	"github.com/kackerx/go-mall/logic/do"
 	"github.com/kackerx/go-mall/logic/domainservice"
 )
 
 type UserAppSvc struct {
-	userDomainSvc *domainservice.UserDomainSvc
+	userDomainSvc   *domainservice.UserDomainSvc
+	outboxDomainSvc *domainservice.OutboxDomainSvc
+	tx              *tx.Manager
 }
 
-func NewUserAppSvc(userDomainSvc *domainservice.UserDomainSvc) *UserAppSvc {
-	return &UserAppSvc{userDomainSvc: userDomainSvc}
+func NewUserAppSvc(userDomainSvc *domainservice.UserDomainSvc, outboxDomainSvc *domainservice.OutboxDomainSvc, txManager *tx.Manager) *UserAppSvc {
+	return &UserAppSvc{userDomainSvc: userDomainSvc, outboxDomainSvc: outboxDomainSvc, tx: txManager}
 }
 func (us *UserAppSvc) GenToken(ctx context.Context) (resp *reply.TokenResp, err error) {
 	tokenInfo, err := us.userDomainSvc.GenAuthToken(ctx, 110, "h5", "")
@@ -26,7 +31,7 @@ func (us *UserAppSvc) GenToken(ctx context.Context) (resp *reply.TokenResp, err
 		return nil, err
 	}
 
-	logger.New(ctx).Info("gen token success", tokenInfo)
+	logger.New(ctx).Info("gen token success", "duration", tokenInfo.Duration)
 
 	resp = new(reply.TokenResp)
 	if err = util.Copy(resp, tokenInfo); err != nil {
@@ -42,7 +47,7 @@ func (us *UserAppSvc) RefreshToken(ctx context.Context, refreshToken string) (re
 		return nil, err
 	}
 
-	logger.New(ctx).Info("token refresh success", "token", token)
+	logger.New(ctx).Info("token refresh success", "duration", token.Duration)
 	resp = new(reply.TokenResp)
 	if err := util.Copy(resp, token); err != nil {
 		return nil, err
@@ -55,7 +60,20 @@ func (us *UserAppSvc) UserRegister(ctx context.Context, req *request.UserRegiste
 	user := new(do.UserBaseInfo)
 	util.Copy(user, req)
 
-	id, err := us.userDomainSvc.RegisterUser(ctx, user, req.Password)
+	// 用户注册事件和用户数据同一个事务落库, 欢迎通知、发新人券等下游由事件驱动
+	var id int64
+	err := us.tx.Do(ctx, func(ctx context.Context) (err error) {
+		id, err = us.userDomainSvc.RegisterUser(ctx, user, req.Password)
+		if err != nil {
+			return err
+		}
+
+		return us.outboxDomainSvc.Add(ctx, do.EventUserRegistered, strconv.FormatInt(id, 10), &do.UserRegisteredEvent{
+			UserID:       id,
+			UserName:     user.UserName,
+			RegisteredAt: time.Now(),
+		})
+	})
 	if err != nil {
 		if errors.Is(err, errcode.ErrUserNameOccupied) { // 用户名已存在不用额外的处理
 			return 0, err
@@ -65,8 +83,6 @@ func (us *UserAppSvc) UserRegister(ctx context.Context, req *request.UserRegiste
 		return 0, err
 	}
 
-	// todo: 注册成功后的发消息通知, 事件推送, 或者直接跳转登录逻辑, 非核心的应用层的职责
-
 	return id, nil
 }
 
@@ -0,0 +1,103 @@
+package do
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+// afterSaleStateMachine 售后单状态机, key为当前状态, value为允许流转到的目标状态
+var afterSaleStateMachine = map[int8][]int8{
+	enum.AfterSaleStatePending:      {enum.AfterSaleStateApproved, enum.AfterSaleStateRejected, enum.AfterSaleStateCancelled},
+	enum.AfterSaleStateApproved:     {enum.AfterSaleStateRefunded, enum.AfterSaleStateRefundFailed},
+	enum.AfterSaleStateRefundFailed: {enum.AfterSaleStateApproved},
+}
+
+// afterSaleActiveStates 占用可售后数量的售后单状态
+var afterSaleActiveStates = []int8{
+	enum.AfterSaleStatePending,
+	enum.AfterSaleStateApproved,
+	enum.AfterSaleStateRefunded,
+	enum.AfterSaleStateRefundFailed,
+}
+
+type AfterSale struct {
+	ID            int64     `json:"id"`
+	TicketNo      string    `json:"ticket_no"`
+	OrderID       int64     `json:"order_id"`
+	OrderNo       string    `json:"order_no"`
+	UserID        int64     `json:"user_id"`
+	Type          int8      `json:"type"`
+	State         int8      `json:"state"`
+	Reason        string    `json:"reason"`
+	RefundAmount  int64     `json:"refund_amount"`
+	PaymentNo     string    `json:"payment_no"`
+	RefundTradeNo string    `json:"refund_trade_no"`
+	AuditRemark   string    `json:"audit_remark"`
+	AuditedBy     int64     `json:"audited_by"`
+	AuditedAt     time.Time `json:"audited_at"`
+	RefundedAt    time.Time `json:"refunded_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	Items []*AfterSaleItem `json:"items"`
+}
+
+type AfterSaleItem struct {
+	ID           int64 `json:"id"`
+	AfterSaleID  int64 `json:"after_sale_id"`
+	OrderItemID  int64 `json:"order_item_id"`
+	SkuID        int64 `json:"sku_id"`
+	Quantity     int64 `json:"quantity"`
+	RefundAmount int64 `json:"refund_amount"`
+}
+
+type AfterSaleLog struct {
+	ID           int64     `json:"id"`
+	AfterSaleID  int64     `json:"after_sale_id"`
+	OperatorType string    `json:"operator_type"`
+	OperatorID   int64     `json:"operator_id"`
+	Action       string    `json:"action"`
+	FromState    int8      `json:"from_state"`
+	ToState      int8      `json:"to_state"`
+	Remark       string    `json:"remark"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AfterSaleListFilter 售后单列表的筛选条件, 零值表示不筛选
+type AfterSaleListFilter struct {
+	UserID int64
+	State  *int8
+}
+
+// AfterSaleActiveStates 占用订单明细可售后数量的状态, 被拒绝和已撤销的售后单释放数量
+func AfterSaleActiveStates() []int8 {
+	return afterSaleActiveStates
+}
+
+// NeedRestock 退款后是否回补SKU库存: 退货退款的商品会退回仓库; 仅退款时商品留在用户手里, 只有订单还没发货才回补
+func (a *AfterSale) NeedRestock(orderState int8) bool {
+	return a.Type == enum.AfterSaleTypeReturnRefund || orderState == enum.OrderStatePaid
+}
+
+// TransitTo 按状态机流转售后单状态并生成审计日志, 落库由dao以当前状态做乐观锁条件更新
+func (a *AfterSale) TransitTo(state int8, operatorType string, operatorID int64, action, remark string) (*AfterSaleLog, error) {
+	if !slices.Contains(afterSaleStateMachine[a.State], state) {
+		return nil, errcode.ErrAfterSaleStateInvalid.WithCause(fmt.Errorf("after sale %s state %d -> %d not allowed", a.TicketNo, a.State, state))
+	}
+
+	log := &AfterSaleLog{
+		AfterSaleID:  a.ID,
+		OperatorType: operatorType,
+		OperatorID:   operatorID,
+		Action:       action,
+		FromState:    a.State,
+		ToState:      state,
+		Remark:       remark,
+	}
+	a.State = state
+	return log, nil
+}
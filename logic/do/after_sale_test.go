@@ -0,0 +1,27 @@
+package do
+
+import (
+	"testing"
+
+	"github.com/kackerx/go-mall/common/enum"
+)
+
+func TestAfterSaleNeedRestock(t *testing.T) {
+	tests := []struct {
+		afterSaleType int8
+		orderState    int8
+		want          bool
+	}{
+		{enum.AfterSaleTypeReturnRefund, enum.OrderStateShipped, true},
+		{enum.AfterSaleTypeReturnRefund, enum.OrderStateCompleted, true},
+		{enum.AfterSaleTypeRefund, enum.OrderStatePaid, true}, // 未发货, 商品还在仓库
+		{enum.AfterSaleTypeRefund, enum.OrderStateShipped, false},
+		{enum.AfterSaleTypeRefund, enum.OrderStateCompleted, false},
+	}
+	for _, tt := range tests {
+		afterSale := &AfterSale{Type: tt.afterSaleType}
+		if got := afterSale.NeedRestock(tt.orderState); got != tt.want {
+			t.Errorf("type %d order state %d: NeedRestock = %v, want %v", tt.afterSaleType, tt.orderState, got, tt.want)
+		}
+	}
+}
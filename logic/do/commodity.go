@@ -3,15 +3,75 @@ package do
 import "time"
 
 type CommodityCategory struct {
-	ID       int64
-	Level    int
-	ParentID int64
-	Name     string
-	Icon     string
-	Rank     int
-
-	CreatedBy string
-	UpdatedBy string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID       int64  `json:"id"`
+	Level    int    `json:"level"`
+	ParentID int64  `json:"parent_id"`
+	Name     string `json:"name"`
+	IconImg  string `json:"icon_img"`
+	Rank     int    `json:"rank"`
+
+	CreatedBy string    `json:"created_by"`
+	UpdatedBy string    `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CategoryTree 类目层级关系, 用于判断类目的从属(如优惠券按类目子树限定适用范围)
+type CategoryTree struct {
+	parents map[int64]int64
+}
+
+func NewCategoryTree(categories []*CommodityCategory) *CategoryTree {
+	parents := make(map[int64]int64, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	return &CategoryTree{parents: parents}
+}
+
+// InSubtree categoryID是否是ancestorID本身或其子孙类目
+func (t *CategoryTree) InSubtree(categoryID, ancestorID int64) bool {
+	// 类目层级很浅, 向上追溯即可, len(parents)兜底防止脏数据成环
+	for i := 0; i <= len(t.parents) && categoryID != 0; i++ {
+		if categoryID == ancestorID {
+			return true
+		}
+		categoryID = t.parents[categoryID]
+	}
+
+	return false
+}
+
+type Commodity struct {
+	ID          int64     `json:"id"`
+	StoreID     int64     `json:"store_id"`
+	CategoryID  int64     `json:"category_id"`
+	Name        string    `json:"name"`
+	Intro       string    `json:"intro"`
+	CoverImg    string    `json:"cover_img"`
+	IsPublished int8      `json:"is_published"`
+	MinPrice    int64     `json:"min_price"` // 各SKU中的最低价, 列表展示用
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CommoditySku struct {
+	ID          int64  `json:"id"`
+	CommodityID int64  `json:"commodity_id"`
+	Name        string `json:"name"`
+	Price       int64  `json:"price"`
+	Stock       int64  `json:"stock"`
+
+	Commodity *Commodity `json:"commodity,omitempty"`
+}
+
+type Store struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Logo      string    `json:"logo"`
+	Intro     string    `json:"intro"`
+	IsOpen    int8      `json:"is_open"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,105 @@
+package do
+
+import (
+	"slices"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+)
+
+type CouponTemplate struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Type           int8      `json:"type"`
+	Threshold      int64     `json:"threshold"`
+	DiscountAmount int64     `json:"discount_amount"`
+	DiscountRate   int64     `json:"discount_rate"`
+	MaxDiscount    int64     `json:"max_discount"`
+	ScopeType      int8      `json:"scope_type"`
+	ScopeIDs       []int64   `json:"scope_ids"`
+	UserSegment    string    `json:"user_segment"`
+	Stackable      int8      `json:"stackable"`
+	TotalStock     int64     `json:"total_stock"`
+	PerUserLimit   int64     `json:"per_user_limit"`
+	ValidDays      int       `json:"valid_days"`
+	ClaimStartAt   time.Time `json:"claim_start_at"`
+	ClaimEndAt     time.Time `json:"claim_end_at"`
+	CreatedBy      int64     `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type UserCoupon struct {
+	ID         int64     `json:"id"`
+	TemplateID int64     `json:"template_id"`
+	UserID     int64     `json:"user_id"`
+	State      int8      `json:"state"`
+	ValidFrom  time.Time `json:"valid_from"`
+	ValidTo    time.Time `json:"valid_to"`
+	OrderNo    string    `json:"order_no"`
+	UsedAt     time.Time `json:"used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	Template *CouponTemplate `json:"template"`
+}
+
+// IsClaimable 当前是否在模板的领取时间内
+func (t *CouponTemplate) IsClaimable(now time.Time) bool {
+	return !now.Before(t.ClaimStartAt) && now.Before(t.ClaimEndAt)
+}
+
+// MatchSegment 用户是否属于模板限定的用户分群
+func (t *CouponTemplate) MatchSegment(user *UserBaseInfo, now time.Time) bool {
+	switch t.UserSegment {
+	case enum.CouponSegmentAll:
+		return true
+	case enum.CouponSegmentNewUser:
+		return now.Sub(user.CreatedAt) <= enum.NewUserDuration
+	default:
+		return false
+	}
+}
+
+// matchLine 订单行是否在优惠券的适用范围内, 类目范围包含其所有子类目
+func (t *CouponTemplate) matchLine(line *PriceLine, categories *CategoryTree) bool {
+	switch t.ScopeType {
+	case enum.CouponScopeAll:
+		return true
+	case enum.CouponScopeSku:
+		return slices.Contains(t.ScopeIDs, line.SkuID)
+	case enum.CouponScopeCategory:
+		for _, categoryID := range t.ScopeIDs {
+			if categories != nil && categories.InSubtree(line.CategoryID, categoryID) || line.CategoryID == categoryID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// discount 计算优惠金额. 门槛按适用商品的原价判断, 优惠基于叠加前面的券之后的剩余金额
+func (t *CouponTemplate) discount(originalBase, currentBase int64) int64 {
+	if originalBase < t.Threshold || currentBase <= 0 {
+		return 0
+	}
+
+	var amount int64
+	switch t.Type {
+	case enum.CouponTypeFixed, enum.CouponTypeThreshold:
+		amount = t.DiscountAmount
+	case enum.CouponTypePercent:
+		amount = currentBase * (100 - t.DiscountRate) / 100
+		if t.MaxDiscount > 0 {
+			amount = min(amount, t.MaxDiscount)
+		}
+	}
+
+	return min(amount, currentBase)
+}
+
+// IsUsable 优惠券当前能否用于下单
+func (c *UserCoupon) IsUsable(now time.Time) bool {
+	return c.State == enum.UserCouponStateUnused && c.Template != nil &&
+		!now.Before(c.ValidFrom) && now.Before(c.ValidTo)
+}
@@ -0,0 +1,47 @@
+package do
+
+import "time"
+
+// 领域事件的topic
+const (
+	EventUserRegistered        = "user.registered"
+	EventOrderPaid             = "order.paid"
+	EventPaymentRefundRequired = "payment.refund_required"
+)
+
+// OutboxEvent 写入outbox的事件, Payload为JSON
+type OutboxEvent struct {
+	ID          int64     `json:"id"`
+	EventID     string    `json:"event_id"`
+	Topic       string    `json:"topic"`
+	EventKey    string    `json:"event_key"`
+	Payload     string    `json:"payload"`
+	Status      int8      `json:"status"`
+	Attempts    int       `json:"attempts"`
+	NextRetryAt time.Time `json:"next_retry_at"`
+	LastError   string    `json:"last_error"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type UserRegisteredEvent struct {
+	UserID       int64     `json:"user_id"`
+	UserName     string    `json:"user_name"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+type OrderPaidEvent struct {
+	OrderNo   string    `json:"order_no"`
+	UserID    int64     `json:"user_id"`
+	PaymentNo string    `json:"payment_no"`
+	Channel   string    `json:"channel"`
+	Amount    int64     `json:"amount"` // 单位: 分
+	PaidAt    time.Time `json:"paid_at"`
+}
+
+// PaymentRefundRequiredEvent 支付成功但订单已无法流转为已支付(超时关闭、已被其他支付单支付), 需要原路退回
+type PaymentRefundRequiredEvent struct {
+	PaymentNo string `json:"payment_no"`
+	OrderNo   string `json:"order_no"`
+	Channel   string `json:"channel"`
+	Amount    int64  `json:"amount"` // 单位: 分
+}
@@ -0,0 +1,17 @@
+package do
+
+import "time"
+
+type Favorite struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	TargetType int8      `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BrowseRecord 一条浏览记录
+type BrowseRecord struct {
+	CommodityID int64     `json:"commodity_id"`
+	ViewedAt    time.Time `json:"viewed_at"`
+}
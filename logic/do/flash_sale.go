@@ -0,0 +1,47 @@
+package do
+
+import (
+	"time"
+
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+type FlashSale struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	SkuID      int64     `json:"sku_id"`
+	Price      int64     `json:"price"`
+	TotalStock int64     `json:"total_stock"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	Warmed     int8      `json:"warmed"`
+	Settled    int8      `json:"settled"`
+	CreatedBy  int64     `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CheckActive 当前是否处于秒杀时间内
+func (f *FlashSale) CheckActive(now time.Time) error {
+	if now.Before(f.StartAt) {
+		return errcode.ErrFlashSaleNotStarted
+	}
+	if !now.Before(f.EndAt) {
+		return errcode.ErrFlashSaleEnded
+	}
+
+	return nil
+}
+
+// FlashSaleGrab 抢购成功后进入队列的请求, 由消费者异步创建订单
+type FlashSaleGrab struct {
+	FlashSaleID int64 `json:"flash_sale_id"`
+	UserID      int64 `json:"user_id"`
+	GrabbedAt   int64 `json:"grabbed_at"`
+}
+
+type FlashSaleResult struct {
+	Status   string `json:"status" redis:"status"`
+	OrderNo  string `json:"order_no" redis:"order_no"`
+	FailCode int    `json:"fail_code" redis:"fail_code"` // 失败原因的错误码, 展示时按语言取文案
+}
@@ -0,0 +1,64 @@
+package do
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+// orderStateMachine 订单状态机, key为当前状态, value为允许流转到的目标状态
+var orderStateMachine = map[int8][]int8{
+	enum.OrderStateCreated: {enum.OrderStatePaid, enum.OrderStateClosed},
+	enum.OrderStatePaid:    {enum.OrderStateShipped, enum.OrderStateClosed}, // 已支付->已关闭: 发货前全额退款
+	enum.OrderStateShipped: {enum.OrderStateCompleted},
+}
+
+type Order struct {
+	ID             int64     `json:"id"`
+	OrderNo        string    `json:"order_no"`
+	UserID         int64     `json:"user_id"`
+	State          int8      `json:"state"`
+	TotalAmount    int64     `json:"total_amount"`
+	DiscountAmount int64     `json:"discount_amount"`
+	PayAmount      int64     `json:"pay_amount"`
+	PaidAt         time.Time `json:"paid_at"`
+	ExpireAt       time.Time `json:"expire_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	Items     []*OrderItem `json:"items"`
+	CouponIDs []int64      `json:"coupon_ids,omitempty"` // 下单时使用并锁定的用户优惠券
+}
+
+type OrderItem struct {
+	ID             int64  `json:"id"`
+	OrderID        int64  `json:"order_id"`
+	CommodityID    int64  `json:"commodity_id"`
+	SkuID          int64  `json:"sku_id"`
+	CommodityName  string `json:"commodity_name"`
+	SkuName        string `json:"sku_name"`
+	CoverImg       string `json:"cover_img"`
+	Price          int64  `json:"price"`
+	Quantity       int64  `json:"quantity"`
+	Amount         int64  `json:"amount"`
+	DiscountAmount int64  `json:"discount_amount"`
+	PayAmount      int64  `json:"pay_amount"`
+}
+
+// CanTransitTo 订单当前状态能否流转到目标状态
+func (o *Order) CanTransitTo(state int8) bool {
+	return slices.Contains(orderStateMachine[o.State], state)
+}
+
+// TransitTo 按状态机流转订单状态, 只修改内存中的状态, 落库由dao以当前状态做乐观锁条件更新
+func (o *Order) TransitTo(state int8) error {
+	if !o.CanTransitTo(state) {
+		return errcode.ErrOrderStateInvalid.WithCause(fmt.Errorf("order %s state %d -> %d not allowed", o.OrderNo, o.State, state))
+	}
+
+	o.State = state
+	return nil
+}
@@ -0,0 +1,13 @@
+package do
+
+import "github.com/kackerx/go-mall/common/util"
+
+// PageQuery 列表的分页条件. Keyset为true时按游标翻页, 从After之后取Limit行, 不统计总数;
+// 否则按Offset翻页. 游标模式下查询会回填Next, 为nil说明没有下一页
+type PageQuery struct {
+	Offset int
+	Limit  int
+	Keyset bool
+	After  *util.Cursor
+	Next   *util.Cursor
+}
@@ -0,0 +1,21 @@
+package do
+
+import "time"
+
+type Payment struct {
+	ID             int64     `json:"id"`
+	PaymentNo      string    `json:"payment_no"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	OrderID        int64     `json:"order_id"`
+	OrderNo        string    `json:"order_no"`
+	UserID         int64     `json:"user_id"`
+	Channel        string    `json:"channel"`
+	Amount         int64     `json:"amount"`
+	Status         int8      `json:"status"`
+	TradeNo        string    `json:"trade_no"`
+	PayURL         string    `json:"pay_url"`
+	FailReason     string    `json:"fail_reason"`
+	PaidAt         time.Time `json:"paid_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,219 @@
+package do
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+// couponApplyOrder 多张券叠加时的计算顺序: 先满减, 再立减, 最后打折
+var couponApplyOrder = map[int8]int{
+	enum.CouponTypeThreshold: 0,
+	enum.CouponTypeFixed:     1,
+	enum.CouponTypePercent:   2,
+}
+
+// PriceLine 参与结算的购物车行
+type PriceLine struct {
+	SkuID       int64
+	CommodityID int64
+	CategoryID  int64
+	Price       int64
+	Quantity    int64
+}
+
+type PriceLineResult struct {
+	SkuID          int64           `json:"sku_id"`
+	Amount         int64           `json:"amount"`
+	DiscountAmount int64           `json:"discount_amount"`
+	PayAmount      int64           `json:"pay_amount"`
+	Discounts      []*LineDiscount `json:"discounts"`
+}
+
+// LineDiscount 某张券分摊到订单行上的优惠
+type LineDiscount struct {
+	UserCouponID int64 `json:"user_coupon_id"`
+	Amount       int64 `json:"amount"`
+}
+
+type AppliedCoupon struct {
+	UserCouponID   int64  `json:"user_coupon_id"`
+	TemplateID     int64  `json:"template_id"`
+	Name           string `json:"name"`
+	Type           int8   `json:"type"`
+	DiscountAmount int64  `json:"discount_amount"`
+}
+
+type PriceResult struct {
+	Lines          []*PriceLineResult `json:"lines"`
+	Coupons        []*AppliedCoupon   `json:"coupons"`
+	TotalAmount    int64              `json:"total_amount"`
+	DiscountAmount int64              `json:"discount_amount"`
+	PayAmount      int64              `json:"pay_amount"`
+}
+
+// CouponIDs 结算使用的用户优惠券ID
+func (r *PriceResult) CouponIDs() []int64 {
+	ids := make([]int64, 0, len(r.Coupons))
+	for _, coupon := range r.Coupons {
+		ids = append(ids, coupon.UserCouponID)
+	}
+
+	return ids
+}
+
+// PriceCalculator 结算价格计算器, 纯计算不访问存储, 券的可用状态(有效期, 归属)由调用方先行过滤
+type PriceCalculator struct {
+	categories *CategoryTree
+}
+
+func NewPriceCalculator(categories *CategoryTree) *PriceCalculator {
+	return &PriceCalculator{categories: categories}
+}
+
+// Calculate 按指定的优惠券结算, 组合不能叠加或有券不满足使用条件时返回错误
+func (p *PriceCalculator) Calculate(lines []*PriceLine, coupons []*UserCoupon) (*PriceResult, error) {
+	if err := checkCouponCombination(coupons); err != nil {
+		return nil, err
+	}
+
+	result, ok := p.apply(lines, coupons)
+	if !ok {
+		return nil, errcode.ErrCouponNotApplicable
+	}
+
+	return result, nil
+}
+
+// Best 从用户的可用券中挑出优惠金额最大的组合, 优惠相同时用券更少的组合优先
+func (p *PriceCalculator) Best(lines []*PriceLine, coupons []*UserCoupon) *PriceResult {
+	type candidate struct {
+		coupon   *UserCoupon
+		discount int64
+	}
+
+	candidates := make([]candidate, 0, len(coupons))
+	for _, coupon := range coupons {
+		if result, ok := p.apply(lines, []*UserCoupon{coupon}); ok {
+			candidates = append(candidates, candidate{coupon, result.DiscountAmount})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.discount, a.discount)
+	})
+	if len(candidates) > enum.MaxCouponCombination {
+		candidates = candidates[:enum.MaxCouponCombination]
+	}
+
+	best, _ := p.apply(lines, nil)
+	for mask := 1; mask < 1<<len(candidates); mask++ {
+		combination := make([]*UserCoupon, 0, len(candidates))
+		for i, c := range candidates {
+			if mask&(1<<i) != 0 {
+				combination = append(combination, c.coupon)
+			}
+		}
+
+		if checkCouponCombination(combination) != nil {
+			continue
+		}
+
+		result, ok := p.apply(lines, combination)
+		if !ok {
+			continue
+		}
+
+		if result.DiscountAmount > best.DiscountAmount ||
+			result.DiscountAmount == best.DiscountAmount && len(result.Coupons) < len(best.Coupons) {
+			best = result
+		}
+	}
+
+	return best
+}
+
+// apply 依次叠加优惠券并把每张券的优惠按订单行的剩余金额等比分摊, 有券优惠为0时返回false
+func (p *PriceCalculator) apply(lines []*PriceLine, coupons []*UserCoupon) (*PriceResult, bool) {
+	result := &PriceResult{
+		Lines:   make([]*PriceLineResult, 0, len(lines)),
+		Coupons: make([]*AppliedCoupon, 0, len(coupons)),
+	}
+	for _, line := range lines {
+		amount := line.Price * line.Quantity
+		result.Lines = append(result.Lines, &PriceLineResult{SkuID: line.SkuID, Amount: amount, PayAmount: amount})
+		result.TotalAmount += amount
+	}
+
+	ordered := slices.Clone(coupons)
+	slices.SortStableFunc(ordered, func(a, b *UserCoupon) int {
+		return cmp.Compare(couponApplyOrder[a.Template.Type], couponApplyOrder[b.Template.Type])
+	})
+
+	for _, coupon := range ordered {
+		var eligible []int
+		var originalBase, currentBase int64
+		for i, line := range lines {
+			if coupon.Template.matchLine(line, p.categories) {
+				eligible = append(eligible, i)
+				originalBase += result.Lines[i].Amount
+				currentBase += result.Lines[i].PayAmount
+			}
+		}
+
+		discount := coupon.Template.discount(originalBase, currentBase)
+		if discount <= 0 {
+			return nil, false
+		}
+
+		remain := discount
+		for n, i := range eligible {
+			lineResult := result.Lines[i]
+			share := discount * lineResult.PayAmount / currentBase
+			if n == len(eligible)-1 { // 最后一行承担分摊的尾差
+				share = remain
+			}
+			remain -= share
+
+			lineResult.PayAmount -= share
+			lineResult.DiscountAmount += share
+			lineResult.Discounts = append(lineResult.Discounts, &LineDiscount{UserCouponID: coupon.ID, Amount: share})
+		}
+
+		result.Coupons = append(result.Coupons, &AppliedCoupon{
+			UserCouponID:   coupon.ID,
+			TemplateID:     coupon.TemplateID,
+			Name:           coupon.Template.Name,
+			Type:           coupon.Template.Type,
+			DiscountAmount: discount,
+		})
+		result.DiscountAmount += discount
+	}
+	result.PayAmount = result.TotalAmount - result.DiscountAmount
+
+	return result, true
+}
+
+// checkCouponCombination 叠加规则: 单笔订单最多MaxCouponsPerOrder张, 多张券时每张都必须可叠加且类型各不相同
+func checkCouponCombination(coupons []*UserCoupon) error {
+	if len(coupons) > enum.MaxCouponsPerOrder {
+		return errcode.ErrCouponNotStackable
+	}
+	if len(coupons) <= 1 {
+		return nil
+	}
+
+	types := make(map[int8]struct{}, len(coupons))
+	for _, coupon := range coupons {
+		if coupon.Template.Stackable == 0 {
+			return errcode.ErrCouponNotStackable
+		}
+		if _, ok := types[coupon.Template.Type]; ok {
+			return errcode.ErrCouponNotStackable
+		}
+		types[coupon.Template.Type] = struct{}{}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package do
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+func newTestCoupon(id int64, template *CouponTemplate) *UserCoupon {
+	template.ID = id
+	return &UserCoupon{ID: id, TemplateID: id, Template: template}
+}
+
+func TestPriceCalculatorCalculate(t *testing.T) {
+	tree := NewCategoryTree([]*CommodityCategory{{ID: 1}, {ID: 3, ParentID: 1}, {ID: 2}})
+	calculator := NewPriceCalculator(tree)
+	lines := []*PriceLine{
+		{SkuID: 1, CategoryID: 3, Price: 3000, Quantity: 2}, // 6000, 属于类目1的子类目
+		{SkuID: 2, CategoryID: 2, Price: 4000, Quantity: 1}, // 4000
+	}
+
+	threshold := newTestCoupon(1, &CouponTemplate{Type: enum.CouponTypeThreshold, Threshold: 5000, DiscountAmount: 1000,
+		ScopeType: enum.CouponScopeCategory, ScopeIDs: []int64{1}, Stackable: 1})
+	percent := newTestCoupon(2, &CouponTemplate{Type: enum.CouponTypePercent, DiscountRate: 90, MaxDiscount: 500, Stackable: 1})
+
+	result, err := calculator.Calculate(lines, []*UserCoupon{percent, threshold})
+	if err != nil {
+		t.Fatalf("Calculate err: %v", err)
+	}
+
+	// 满减先算: 类目1下6000满5000减1000; 再打折: (5000+4000)*10%=900, 封顶500
+	if result.DiscountAmount != 1500 || result.PayAmount != 8500 {
+		t.Fatalf("discount = %d, pay = %d", result.DiscountAmount, result.PayAmount)
+	}
+
+	var lineSum int64
+	for _, line := range result.Lines {
+		lineSum += line.PayAmount
+	}
+	if lineSum != result.PayAmount {
+		t.Fatalf("line pay sum = %d, want %d", lineSum, result.PayAmount)
+	}
+	if result.Lines[0].DiscountAmount <= result.Lines[1].DiscountAmount {
+		t.Fatalf("unexpected line discounts: %+v, %+v", result.Lines[0], result.Lines[1])
+	}
+
+	outOfScope := newTestCoupon(3, &CouponTemplate{Type: enum.CouponTypeFixed, DiscountAmount: 100,
+		ScopeType: enum.CouponScopeSku, ScopeIDs: []int64{99}})
+	if _, err = calculator.Calculate(lines, []*UserCoupon{outOfScope}); !errors.Is(err, errcode.ErrCouponNotApplicable) {
+		t.Fatalf("Calculate err = %v, want ErrCouponNotApplicable", err)
+	}
+
+	notStackable := newTestCoupon(4, &CouponTemplate{Type: enum.CouponTypeFixed, DiscountAmount: 100})
+	if _, err = calculator.Calculate(lines, []*UserCoupon{threshold, notStackable}); !errors.Is(err, errcode.ErrCouponNotStackable) {
+		t.Fatalf("Calculate err = %v, want ErrCouponNotStackable", err)
+	}
+}
+
+func TestPriceCalculatorBest(t *testing.T) {
+	calculator := NewPriceCalculator(nil)
+	lines := []*PriceLine{{SkuID: 1, Price: 10000, Quantity: 1}}
+
+	coupons := []*UserCoupon{
+		newTestCoupon(1, &CouponTemplate{Type: enum.CouponTypeFixed, DiscountAmount: 1500}), // 不可叠加
+		newTestCoupon(2, &CouponTemplate{Type: enum.CouponTypeThreshold, Threshold: 8000, DiscountAmount: 1000, Stackable: 1}),
+		newTestCoupon(3, &CouponTemplate{Type: enum.CouponTypePercent, DiscountRate: 95, Stackable: 1}),
+		newTestCoupon(4, &CouponTemplate{Type: enum.CouponTypeThreshold, Threshold: 20000, DiscountAmount: 5000, Stackable: 1}),
+	}
+
+	// 1单用1500; 2+3叠加: 1000 + 9000*5% = 1450; 4不满足门槛
+	result := calculator.Best(lines, coupons)
+	if result.DiscountAmount != 1500 || len(result.Coupons) != 1 || result.Coupons[0].UserCouponID != 1 {
+		t.Fatalf("Best = %+v", result)
+	}
+
+	coupons[0].Template.DiscountAmount = 1000
+	result = calculator.Best(lines, coupons)
+	if result.DiscountAmount != 1450 || len(result.Coupons) != 2 {
+		t.Fatalf("Best = %+v", result)
+	}
+
+	result = calculator.Best(lines, nil)
+	if result.DiscountAmount != 0 || result.PayAmount != 10000 {
+		t.Fatalf("Best = %+v", result)
+	}
+}
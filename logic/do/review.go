@@ -0,0 +1,83 @@
+package do
+
+import (
+	"math"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+// reviewStateMachine 评价审核状态的合法流转, 已通过的评价可以被下架
+var reviewStateMachine = map[int8][]int8{
+	enum.ReviewStatePending:  {enum.ReviewStateApproved, enum.ReviewStateRejected},
+	enum.ReviewStateApproved: {enum.ReviewStateRejected},
+}
+
+type Review struct {
+	ID            int64     `json:"id"`
+	ParentID      int64     `json:"parent_id"`
+	OrderItemID   int64     `json:"order_item_id"`
+	OrderID       int64     `json:"order_id"`
+	UserID        int64     `json:"user_id"`
+	CommodityID   int64     `json:"commodity_id"`
+	SkuID         int64     `json:"sku_id"`
+	SkuName       string    `json:"sku_name"`
+	Rating        int8      `json:"rating"`
+	Content       string    `json:"content"`
+	Images        []string  `json:"images"`
+	State         int8      `json:"state"`
+	RejectReason  string    `json:"reject_reason"`
+	ModeratedBy   int64     `json:"moderated_by"`
+	MerchantReply string    `json:"merchant_reply"`
+	RepliedAt     time.Time `json:"replied_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	FollowUp *Review `json:"follow_up"`
+}
+
+// IsFollowUp 是否是追评
+func (r *Review) IsFollowUp() bool {
+	return r.ParentID > 0
+}
+
+// CountsInStats 是否计入商品评分统计, 只统计审核通过的首次评价
+func (r *Review) CountsInStats() bool {
+	return !r.IsFollowUp() && r.State == enum.ReviewStateApproved
+}
+
+// TransitTo 审核评价, 返回流转前的状态
+func (r *Review) TransitTo(state int8) (int8, error) {
+	from := r.State
+	for _, to := range reviewStateMachine[from] {
+		if to == state {
+			r.State = state
+			return from, nil
+		}
+	}
+
+	return from, errcode.ErrReviewStateInvalid
+}
+
+type ReviewListFilter struct {
+	CommodityID int64
+	State       *int8
+}
+
+// RatingStats 商品评分统计
+type RatingStats struct {
+	CommodityID  int64          `json:"commodity_id"`
+	Count        int64          `json:"count"`
+	Sum          int64          `json:"sum"`
+	Distribution map[int8]int64 `json:"distribution"` // 星级 -> 评价数
+}
+
+// Average 平均评分, 保留一位小数
+func (s *RatingStats) Average() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return math.Round(float64(s.Sum)/float64(s.Count)*10) / 10
+}
@@ -0,0 +1,53 @@
+package do
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+)
+
+func TestReviewTransitTo(t *testing.T) {
+	tests := []struct {
+		from, to int8
+		ok       bool
+	}{
+		{enum.ReviewStatePending, enum.ReviewStateApproved, true},
+		{enum.ReviewStatePending, enum.ReviewStateRejected, true},
+		{enum.ReviewStateApproved, enum.ReviewStateRejected, true}, // 下架
+		{enum.ReviewStateApproved, enum.ReviewStateApproved, false},
+		{enum.ReviewStateRejected, enum.ReviewStateApproved, false},
+		{enum.ReviewStateRejected, enum.ReviewStateRejected, false},
+	}
+	for _, tt := range tests {
+		review := &Review{State: tt.from}
+		from, err := review.TransitTo(tt.to)
+		if from != tt.from {
+			t.Errorf("%d -> %d: from = %d", tt.from, tt.to, from)
+		}
+		if tt.ok && (err != nil || review.State != tt.to) {
+			t.Errorf("%d -> %d: state = %d, err = %v", tt.from, tt.to, review.State, err)
+		}
+		if !tt.ok && (!errors.Is(err, errcode.ErrReviewStateInvalid) || review.State != tt.from) {
+			t.Errorf("%d -> %d: state = %d, err = %v, want ErrReviewStateInvalid", tt.from, tt.to, review.State, err)
+		}
+	}
+}
+
+func TestReviewCountsInStats(t *testing.T) {
+	tests := []struct {
+		review *Review
+		want   bool
+	}{
+		{&Review{State: enum.ReviewStateApproved}, true},
+		{&Review{State: enum.ReviewStatePending}, false},
+		{&Review{State: enum.ReviewStateRejected}, false},
+		{&Review{ParentID: 1, State: enum.ReviewStateApproved}, false}, // 追评不计入
+	}
+	for _, tt := range tests {
+		if got := tt.review.CountsInStats(); got != tt.want {
+			t.Errorf("%+v: CountsInStats = %v, want %v", tt.review, got, tt.want)
+		}
+	}
+}
@@ -20,7 +20,7 @@ type TokenInfo struct {
 }
 
 type UserBaseInfo struct {
-	ID        uint64    `json:"id,omitempty"`
+	ID        int64     `json:"id,omitempty"`
 	Nickname  string    `json:"nickname,omitempty"`
 	UserName  string    `json:"user_name,omitempty"`
 	Password  string    `json:"password"`
@@ -0,0 +1,317 @@
+package domainservice
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/dao"
+	paygw "github.com/kackerx/go-mall/library/payment"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// afterSaleOrderStates 允许申请售后的订单状态
+var afterSaleOrderStates = []int8{enum.OrderStatePaid, enum.OrderStateShipped, enum.OrderStateCompleted}
+
+type AfterSaleDomainSvc struct {
+	afterSaleDao *dao.AfterSaleDao
+	orderDao     *dao.OrderDao
+	paymentDao   *dao.PaymentDao
+	gateways     *paygw.Registry
+}
+
+func NewAfterSaleDomainSvc(
+	afterSaleDao *dao.AfterSaleDao,
+	orderDao *dao.OrderDao,
+	paymentDao *dao.PaymentDao,
+	gateways *paygw.Registry,
+) *AfterSaleDomainSvc {
+	return &AfterSaleDomainSvc{
+		afterSaleDao: afterSaleDao,
+		orderDao:     orderDao,
+		paymentDao:   paymentDao,
+		gateways:     gateways,
+	}
+}
+
+// ApplyAfterSale 用户对已支付订单的明细申请售后, items只需要带OrderItemID, Quantity,
+// RefundAmount为0时按明细实付金额等比计算可退金额. 同一明细在items里只能出现一次
+func (a *AfterSaleDomainSvc) ApplyAfterSale(ctx context.Context, userID int64, orderNo string, afterSaleType int8, reason string, items []*do.AfterSaleItem) (*do.AfterSale, error) {
+	order, err := a.orderDao.FindOrderByNo(ctx, orderNo)
+	if err != nil {
+		return nil, errcode.Wrap("AfterSaleDomainSvc ApplyAfterSale FindOrderByNo err", err)
+	}
+	if order == nil || order.UserID != userID {
+		return nil, errcode.ErrOrderNotFound
+	}
+	if !slices.Contains(afterSaleOrderStates, order.State) {
+		return nil, errcode.ErrAfterSaleOrderInvalid
+	}
+
+	orderItems := make(map[int64]*do.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		orderItems[item.ID] = item
+	}
+
+	afterSale := &do.AfterSale{
+		TicketNo: util.GenSerialNo("AS"),
+		OrderID:  order.ID,
+		OrderNo:  order.OrderNo,
+		UserID:   userID,
+		Type:     afterSaleType,
+		State:    enum.AfterSaleStatePending,
+		Reason:   reason,
+		Items:    make([]*do.AfterSaleItem, 0, len(items)),
+	}
+	applied := make(map[int64]bool, len(items))
+	for _, item := range items {
+		orderItem, ok := orderItems[item.OrderItemID]
+		if !ok || applied[item.OrderItemID] {
+			return nil, errcode.ErrParams
+		}
+		applied[item.OrderItemID] = true
+		if item.Quantity > orderItem.Quantity {
+			return nil, errcode.ErrAfterSaleQuantityExceeded
+		}
+
+		maxAmount := orderItem.PayAmount * item.Quantity / orderItem.Quantity
+		refundAmount := item.RefundAmount
+		if refundAmount == 0 {
+			refundAmount = maxAmount
+		}
+		if refundAmount > maxAmount {
+			return nil, errcode.ErrAfterSaleAmountExceeded
+		}
+
+		afterSale.Items = append(afterSale.Items, &do.AfterSaleItem{
+			OrderItemID:  orderItem.ID,
+			SkuID:        orderItem.SkuID,
+			Quantity:     item.Quantity,
+			RefundAmount: refundAmount,
+		})
+		afterSale.RefundAmount += refundAmount
+	}
+
+	log := &do.AfterSaleLog{
+		OperatorType: enum.OperatorTypeUser,
+		OperatorID:   userID,
+		Action:       enum.AfterSaleActionApply,
+		ToState:      enum.AfterSaleStatePending,
+		Remark:       reason,
+	}
+	if err = a.afterSaleDao.CreateAfterSale(ctx, afterSale, log); err != nil {
+		return nil, err
+	}
+
+	return afterSale, nil
+}
+
+// CancelAfterSale 用户撤销待审核的售后单
+func (a *AfterSaleDomainSvc) CancelAfterSale(ctx context.Context, userID int64, ticketNo string) error {
+	afterSale, err := a.GetUserAfterSale(ctx, userID, ticketNo)
+	if err != nil {
+		return err
+	}
+
+	log, err := afterSale.TransitTo(enum.AfterSaleStateCancelled, enum.OperatorTypeUser, userID, enum.AfterSaleActionCancel, "")
+	if err != nil {
+		return err
+	}
+
+	return a.transit(ctx, afterSale, log)
+}
+
+// ApproveAfterSale 审核通过并立即发起网关退款, 退款失败的售后单可以再次审核通过重试
+func (a *AfterSaleDomainSvc) ApproveAfterSale(ctx context.Context, adminID int64, ticketNo, remark string) (*do.AfterSale, error) {
+	afterSale, err := a.GetAfterSale(ctx, ticketNo)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := afterSale.TransitTo(enum.AfterSaleStateApproved, enum.OperatorTypeAdmin, adminID, enum.AfterSaleActionApprove, remark)
+	if err != nil {
+		return nil, err
+	}
+
+	afterSale.AuditRemark = remark
+	afterSale.AuditedBy = adminID
+	afterSale.AuditedAt = time.Now()
+	if err = a.transit(ctx, afterSale, log, "audit_remark", "audited_by", "audited_at"); err != nil {
+		return nil, err
+	}
+
+	if err = a.refund(ctx, afterSale); err != nil {
+		return nil, err
+	}
+
+	return afterSale, nil
+}
+
+// RejectAfterSale 审核拒绝, 释放占用的可售后数量
+func (a *AfterSaleDomainSvc) RejectAfterSale(ctx context.Context, adminID int64, ticketNo, remark string) (*do.AfterSale, error) {
+	afterSale, err := a.GetAfterSale(ctx, ticketNo)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := afterSale.TransitTo(enum.AfterSaleStateRejected, enum.OperatorTypeAdmin, adminID, enum.AfterSaleActionReject, remark)
+	if err != nil {
+		return nil, err
+	}
+
+	afterSale.AuditRemark = remark
+	afterSale.AuditedBy = adminID
+	afterSale.AuditedAt = time.Now()
+	if err = a.transit(ctx, afterSale, log, "audit_remark", "audited_by", "audited_at"); err != nil {
+		return nil, err
+	}
+
+	return afterSale, nil
+}
+
+func (a *AfterSaleDomainSvc) GetAfterSale(ctx context.Context, ticketNo string) (*do.AfterSale, error) {
+	afterSale, err := a.afterSaleDao.FindAfterSaleByNo(ctx, ticketNo)
+	if err != nil {
+		return nil, errcode.Wrap("AfterSaleDomainSvc GetAfterSale err", err)
+	}
+	if afterSale == nil {
+		return nil, errcode.ErrAfterSaleNotFound
+	}
+
+	return afterSale, nil
+}
+
+// GetUserAfterSale 查询用户自己的售后单, 不属于该用户的售后单视为不存在
+func (a *AfterSaleDomainSvc) GetUserAfterSale(ctx context.Context, userID int64, ticketNo string) (*do.AfterSale, error) {
+	afterSale, err := a.GetAfterSale(ctx, ticketNo)
+	if err != nil {
+		return nil, err
+	}
+	if afterSale.UserID != userID {
+		return nil, errcode.ErrAfterSaleNotFound
+	}
+
+	return afterSale, nil
+}
+
+func (a *AfterSaleDomainSvc) ListAfterSales(ctx context.Context, filter *do.AfterSaleListFilter, page *do.PageQuery) ([]*do.AfterSale, int64, error) {
+	afterSales, total, err := a.afterSaleDao.ListAfterSales(ctx, filter, page)
+	if err != nil {
+		return nil, 0, errcode.Wrap("AfterSaleDomainSvc ListAfterSales err", err)
+	}
+
+	return afterSales, total, nil
+}
+
+func (a *AfterSaleDomainSvc) ListAfterSaleLogs(ctx context.Context, afterSaleID int64) ([]*do.AfterSaleLog, error) {
+	logs, err := a.afterSaleDao.ListAfterSaleLogs(ctx, afterSaleID)
+	if err != nil {
+		return nil, errcode.Wrap("AfterSaleDomainSvc ListAfterSaleLogs err", err)
+	}
+
+	return logs, nil
+}
+
+func (a *AfterSaleDomainSvc) transit(ctx context.Context, afterSale *do.AfterSale, log *do.AfterSaleLog, columns ...string) error {
+	updated, err := a.afterSaleDao.TransitAfterSale(ctx, afterSale, log, columns...)
+	if err != nil {
+		return errcode.Wrap("AfterSaleDomainSvc transit err", err)
+	}
+	if !updated {
+		return errcode.ErrAfterSaleStateInvalid
+	}
+
+	return nil
+}
+
+// refund 对审核通过的售后单原路退款, 成功后回补库存, 订单明细全部退完时关闭订单
+func (a *AfterSaleDomainSvc) refund(ctx context.Context, afterSale *do.AfterSale) error {
+	payment, err := a.paymentDao.FindSuccessPaymentByOrderID(ctx, afterSale.OrderID)
+	if err != nil {
+		return errcode.Wrap("AfterSaleDomainSvc refund FindSuccessPaymentByOrderID err", err)
+	}
+	if payment == nil {
+		return errcode.ErrPaymentNotFound
+	}
+
+	gw, ok := a.gateways.Get(payment.Channel)
+	if !ok {
+		return errcode.ErrPaymentChannel
+	}
+
+	gwCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+	resp, err := gw.Refund(gwCtx, &paygw.RefundReq{
+		PaymentNo:    payment.PaymentNo,
+		RefundNo:     afterSale.TicketNo,
+		RefundAmount: afterSale.RefundAmount,
+		TotalAmount:  payment.Amount,
+		Reason:       afterSale.Reason,
+	})
+	if err != nil || resp.Status != paygw.TradeStatusSuccess {
+		logger.New(ctx).Error("gateway refund failed", "err", err, "ticket_no", afterSale.TicketNo, "payment_no", payment.PaymentNo)
+		log, transitErr := afterSale.TransitTo(enum.AfterSaleStateRefundFailed, enum.OperatorTypeSystem, 0, enum.AfterSaleActionRefund, "gateway refund failed")
+		if transitErr == nil {
+			transitErr = a.transit(ctx, afterSale, log)
+		}
+		if transitErr != nil {
+			logger.New(ctx).Error("mark refund failed err", "err", transitErr, "ticket_no", afterSale.TicketNo)
+		}
+		return errcode.ErrAfterSaleRefundFailed.WithCause(err)
+	}
+
+	order, err := a.orderDao.FindOrderByNo(ctx, afterSale.OrderNo)
+	if err != nil {
+		return errcode.Wrap("AfterSaleDomainSvc refund FindOrderByNo err", err)
+	}
+	orderFromState := order.State
+	restock := afterSale.NeedRestock(orderFromState)
+	fullyRefunded, err := a.isOrderFullyRefunded(ctx, order, afterSale)
+	if err != nil {
+		return err
+	}
+	// 部分退款或订单已发货时不关闭订单
+	if !fullyRefunded || order.TransitTo(enum.OrderStateClosed) != nil {
+		order = nil
+	}
+
+	log, err := afterSale.TransitTo(enum.AfterSaleStateRefunded, enum.OperatorTypeSystem, 0, enum.AfterSaleActionRefund, "")
+	if err != nil {
+		return err
+	}
+	afterSale.PaymentNo = payment.PaymentNo
+	afterSale.RefundTradeNo = resp.RefundTradeNo
+	afterSale.RefundedAt = time.Now()
+	updated, err := a.afterSaleDao.CompleteRefund(ctx, afterSale, log, restock, order, orderFromState)
+	if err != nil {
+		return errcode.Wrap("AfterSaleDomainSvc refund CompleteRefund err", err)
+	}
+	if !updated {
+		return errcode.ErrAfterSaleStateInvalid
+	}
+
+	return nil
+}
+
+// isOrderFullyRefunded 加上本次售后后订单的所有明细是否都已退完
+func (a *AfterSaleDomainSvc) isOrderFullyRefunded(ctx context.Context, order *do.Order, afterSale *do.AfterSale) (bool, error) {
+	refunded, err := a.afterSaleDao.SumRefundedQuantity(ctx, order.ID)
+	if err != nil {
+		return false, errcode.Wrap("AfterSaleDomainSvc isOrderFullyRefunded err", err)
+	}
+	for _, item := range afterSale.Items {
+		refunded[item.OrderItemID] += item.Quantity
+	}
+
+	for _, item := range order.Items {
+		if refunded[item.ID] < item.Quantity {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
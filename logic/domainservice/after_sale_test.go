@@ -0,0 +1,52 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// 同一明细在一次申请里重复出现时数量要合计, 不能绕过购买数量的上限
+func TestApplyAfterSaleDuplicateItems(t *testing.T) {
+	db := newSqliteDB(t, &model.Order{}, &model.OrderItem{}, &model.AfterSale{}, &model.AfterSaleItem{}, &model.AfterSaleLog{})
+	afterSaleDao := dao.NewAfterSaleDao(db)
+	svc := NewAfterSaleDomainSvc(afterSaleDao, dao.NewOrderDao(db), nil, nil)
+	ctx := context.Background()
+
+	order := &model.Order{OrderNo: "O1", UserID: 1, State: enum.OrderStatePaid, PayAmount: 200,
+		Items: []*model.OrderItem{{SkuID: 1, Quantity: 2, Amount: 200, PayAmount: 200}}}
+	if err := db.db.Create(order).Error; err != nil {
+		t.Fatal(err)
+	}
+	itemID := order.Items[0].ID
+
+	_, err := svc.ApplyAfterSale(ctx, 1, "O1", enum.AfterSaleTypeRefund, "r", []*do.AfterSaleItem{
+		{OrderItemID: itemID, Quantity: 2},
+		{OrderItemID: itemID, Quantity: 2},
+	})
+	if !errors.Is(err, errcode.ErrParams) {
+		t.Errorf("duplicate items err = %v, want ErrParams", err)
+	}
+
+	err = afterSaleDao.CreateAfterSale(ctx, &do.AfterSale{TicketNo: "AS1", OrderID: order.ID, OrderNo: "O1", UserID: 1,
+		State: enum.AfterSaleStatePending, Items: []*do.AfterSaleItem{
+			{OrderItemID: itemID, Quantity: 2},
+			{OrderItemID: itemID, Quantity: 2},
+		}}, &do.AfterSaleLog{})
+	if !errors.Is(err, errcode.ErrAfterSaleQuantityExceeded) {
+		t.Errorf("dao duplicate items err = %v, want ErrAfterSaleQuantityExceeded", err)
+	}
+
+	if _, err = svc.ApplyAfterSale(ctx, 1, "O1", enum.AfterSaleTypeRefund, "r", []*do.AfterSaleItem{{OrderItemID: itemID, Quantity: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = svc.ApplyAfterSale(ctx, 1, "O1", enum.AfterSaleTypeRefund, "r", []*do.AfterSaleItem{{OrderItemID: itemID, Quantity: 1}}); !errors.Is(err, errcode.ErrAfterSaleQuantityExceeded) {
+		t.Errorf("apply beyond bought quantity err = %v, want ErrAfterSaleQuantityExceeded", err)
+	}
+}
@@ -1,6 +1,7 @@
 package domainservice
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/kackerx/go-mall/dal/dao"
@@ -16,7 +17,8 @@ func NewCommoditySvc(dao *dao.CommodityDao) *CommoditySvc {
 	return &CommoditySvc{dao: dao}
 }
 
-func (c *CommoditySvc) InitCategory() error {
+// InitCategory 从资源文件导入初始类目数据, 重复执行会覆盖同ID的类目
+func (c *CommoditySvc) InitCategory(ctx context.Context) error {
 	categoryFileHandler, err := resources.LoadResourceFile("category.json")
 	if err != nil {
 		return err
@@ -27,5 +29,33 @@ func (c *CommoditySvc) InitCategory() error {
 		return err
 	}
 
-	return nil
+	return c.dao.SaveCategories(ctx, categoryList)
+}
+
+// GetCategoryTree 加载全部类目的层级关系
+func (c *CommoditySvc) GetCategoryTree(ctx context.Context) (*do.CategoryTree, error) {
+	categories, err := c.dao.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return do.NewCategoryTree(categories), nil
+}
+
+// GetCommodities 批量查询商品快照, 包括已下架的商品, 已删除的商品不在结果中
+func (c *CommoditySvc) GetCommodities(ctx context.Context, commodityIDs []int64) (map[int64]*do.Commodity, error) {
+	if len(commodityIDs) == 0 {
+		return map[int64]*do.Commodity{}, nil
+	}
+
+	return c.dao.FindCommoditiesByIDs(ctx, commodityIDs)
+}
+
+// GetStores 批量查询店铺, 已删除的店铺不在结果中
+func (c *CommoditySvc) GetStores(ctx context.Context, storeIDs []int64) (map[int64]*do.Store, error) {
+	if len(storeIDs) == 0 {
+		return map[int64]*do.Store{}, nil
+	}
+
+	return c.dao.FindStoresByIDs(ctx, storeIDs)
 }
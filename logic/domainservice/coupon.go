@@ -0,0 +1,203 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/dal/cache"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// couponClaimRetry 领取时Redis中库存或用户已领数量未加载, 加载后重试的次数
+const couponClaimRetry = 3
+
+type CouponDomainSvc struct {
+	couponDao    *dao.CouponDao
+	userDao      *dao.UserDao
+	commodityDao *dao.CommodityDao
+	cache        *cache.Cache
+}
+
+func NewCouponDomainSvc(couponDao *dao.CouponDao, userDao *dao.UserDao, commodityDao *dao.CommodityDao, cache *cache.Cache) *CouponDomainSvc {
+	return &CouponDomainSvc{couponDao: couponDao, userDao: userDao, commodityDao: commodityDao, cache: cache}
+}
+
+// CreateTemplate 管理后台创建优惠券模板
+func (c *CouponDomainSvc) CreateTemplate(ctx context.Context, template *do.CouponTemplate) error {
+	if err := checkCouponTemplate(template); err != nil {
+		return errcode.ErrParams.WithCause(err)
+	}
+
+	return c.couponDao.CreateTemplate(ctx, template)
+}
+
+func (c *CouponDomainSvc) ListClaimableTemplates(ctx context.Context, page *do.PageQuery) ([]*do.CouponTemplate, int64, error) {
+	return c.couponDao.ListClaimableTemplates(ctx, time.Now(), page)
+}
+
+// ClaimCoupon 用户领取优惠券. 库存和每人限领数量由Redis脚本原子扣减, 写库失败时回补Redis
+func (c *CouponDomainSvc) ClaimCoupon(ctx context.Context, userID, templateID int64) (*do.UserCoupon, error) {
+	template, err := c.couponDao.FindTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, errcode.Wrap("CouponDomainSvc ClaimCoupon FindTemplateByID err", err)
+	}
+	if template == nil {
+		return nil, errcode.ErrCouponNotFound
+	}
+
+	now := time.Now()
+	if !template.IsClaimable(now) {
+		return nil, errcode.ErrCouponNotClaimable
+	}
+
+	if template.UserSegment != enum.CouponSegmentAll {
+		user, err := c.userDao.FindUserByID(ctx, userID)
+		if err != nil {
+			return nil, errcode.Wrap("CouponDomainSvc ClaimCoupon FindUserByID err", err)
+		}
+		if user == nil || !template.MatchSegment(user, now) {
+			return nil, errcode.ErrCouponNotClaimable
+		}
+	}
+
+	if err = c.claimStock(ctx, template, userID); err != nil {
+		return nil, err
+	}
+
+	coupon := &do.UserCoupon{
+		TemplateID: template.ID,
+		UserID:     userID,
+		State:      enum.UserCouponStateUnused,
+		ValidFrom:  now,
+		ValidTo:    now.AddDate(0, 0, template.ValidDays),
+		Template:   template,
+	}
+	if err = c.couponDao.CreateUserCoupon(ctx, coupon); err != nil {
+		if revertErr := c.cache.RevertCouponClaim(ctx, template.ID, userID); revertErr != nil {
+			logger.New(ctx).Error("revert coupon claim failed", "template_id", template.ID, "user_id", userID, "err", revertErr)
+		}
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// ListUserCoupons 用户的优惠券, state为nil时不按状态筛选
+func (c *CouponDomainSvc) ListUserCoupons(ctx context.Context, userID int64, state *int8, page *do.PageQuery) ([]*do.UserCoupon, int64, error) {
+	return c.couponDao.ListUserCoupons(ctx, userID, state, page)
+}
+
+// BestPrice 从用户当前可用的优惠券中挑出优惠最大的组合结算
+func (c *CouponDomainSvc) BestPrice(ctx context.Context, userID int64, lines []*do.PriceLine) (*do.PriceResult, error) {
+	calculator, err := c.newPriceCalculator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	coupons, err := c.couponDao.FindUsableCoupons(ctx, userID, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return calculator.Best(lines, coupons), nil
+}
+
+// CalculatePrice 按用户指定的优惠券结算, couponIDs为空时不使用优惠券
+func (c *CouponDomainSvc) CalculatePrice(ctx context.Context, userID int64, lines []*do.PriceLine, couponIDs []int64) (*do.PriceResult, error) {
+	calculator, err := c.newPriceCalculator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var coupons []*do.UserCoupon
+	if len(couponIDs) > 0 {
+		if coupons, err = c.couponDao.FindUsableCoupons(ctx, userID, couponIDs, time.Now()); err != nil {
+			return nil, err
+		}
+		if len(coupons) != len(couponIDs) {
+			return nil, errcode.ErrCouponUnavailable
+		}
+	}
+
+	return calculator.Calculate(lines, coupons)
+}
+
+func (c *CouponDomainSvc) newPriceCalculator(ctx context.Context) (*do.PriceCalculator, error) {
+	// todo: 类目变动很少, 后续加本地缓存
+	categories, err := c.commodityDao.ListCategories(ctx)
+	if err != nil {
+		return nil, errcode.Wrap("CouponDomainSvc ListCategories err", err)
+	}
+
+	return do.NewPriceCalculator(do.NewCategoryTree(categories)), nil
+}
+
+// claimStock 在Redis中扣减库存, 库存或用户已领数量不在Redis中时从数据库加载后重试
+func (c *CouponDomainSvc) claimStock(ctx context.Context, template *do.CouponTemplate, userID int64) error {
+	for i := 0; i < couponClaimRetry; i++ {
+		res, err := c.cache.ClaimCoupon(ctx, template.ID, userID, template.PerUserLimit)
+		if err != nil {
+			return errcode.Wrap("CouponDomainSvc claimStock err", err)
+		}
+
+		switch res {
+		case cache.CouponClaimOK:
+			return nil
+		case cache.CouponClaimOutOfStock:
+			return errcode.ErrCouponOutOfStock
+		case cache.CouponClaimLimitExceeded:
+			return errcode.ErrCouponClaimLimit
+		case cache.CouponClaimStockNotWarmed:
+			claimed, err := c.couponDao.CountClaimed(ctx, template.ID, 0)
+			if err != nil {
+				return err
+			}
+			if err = c.cache.WarmCouponStock(ctx, template.ID, max(template.TotalStock-claimed, 0), template.ClaimEndAt); err != nil {
+				return errcode.Wrap("CouponDomainSvc WarmCouponStock err", err)
+			}
+		case cache.CouponClaimUserNotWarmed:
+			claimed, err := c.couponDao.CountClaimed(ctx, template.ID, userID)
+			if err != nil {
+				return err
+			}
+			if err = c.cache.WarmCouponUserClaimed(ctx, template.ID, userID, claimed, template.ClaimEndAt); err != nil {
+				return errcode.Wrap("CouponDomainSvc WarmCouponUserClaimed err", err)
+			}
+		}
+	}
+
+	return errcode.ErrServer.WithCause(errors.New("coupon claim cache not warmed"))
+}
+
+func checkCouponTemplate(template *do.CouponTemplate) error {
+	switch template.Type {
+	case enum.CouponTypeFixed:
+		if template.DiscountAmount <= 0 {
+			return errors.New("fixed coupon requires discount_amount")
+		}
+	case enum.CouponTypeThreshold:
+		if template.DiscountAmount <= 0 || template.Threshold <= template.DiscountAmount {
+			return errors.New("threshold coupon requires threshold > discount_amount > 0")
+		}
+	case enum.CouponTypePercent:
+		if template.DiscountRate <= 0 || template.DiscountRate >= 100 {
+			return errors.New("percent coupon requires 0 < discount_rate < 100")
+		}
+	default:
+		return errors.New("unknown coupon type")
+	}
+
+	if template.ScopeType != enum.CouponScopeAll && len(template.ScopeIDs) == 0 {
+		return errors.New("scope_ids required for limited scope")
+	}
+	if !template.ClaimEndAt.After(template.ClaimStartAt) {
+		return errors.New("claim_end_at must be after claim_start_at")
+	}
+
+	return nil
+}
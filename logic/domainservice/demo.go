@@ -6,27 +6,34 @@ import (
 	"github.com/kackerx/go-mall/common/errcode"
 	"github.com/kackerx/go-mall/common/util"
 	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/dal/tx"
 	"github.com/kackerx/go-mall/logic/do"
 )
 
 type DemoDomainSvc struct {
 	ctx     context.Context
 	DemoDao *dao.DemoDao
+	tx      *tx.Manager
 }
 
-func NewDemoDomainSvc(ctx context.Context, demoDao *dao.DemoDao) *DemoDomainSvc {
-	return &DemoDomainSvc{ctx: ctx, DemoDao: demoDao}
+func NewDemoDomainSvc(ctx context.Context, demoDao *dao.DemoDao, txManager *tx.Manager) *DemoDomainSvc {
+	return &DemoDomainSvc{ctx: ctx, DemoDao: demoDao, tx: txManager}
 }
 
 func (d *DemoDomainSvc) CreateDemoOrder(demoOrder *do.DemoOrder) (*do.DemoOrder, error) {
 	demoOrder.Code = "110"
-	orderPO, err := d.DemoDao.CreateDemoOrder(demoOrder)
+
+	var orderPO *model.DemoOrder
+	// 主表和明细表在同一个事务中写入, 明细表的DAO调用同样使用fn收到的ctx
+	err := d.tx.Do(d.ctx, func(ctx context.Context) (err error) {
+		orderPO, err = d.DemoDao.WithContext(ctx).CreateDemoOrder(demoOrder)
+		return err
+	})
 	if err != nil {
 		return nil, errcode.Wrap("创建订单失败", err)
 	}
 
-	// 事务中写明细表
-
 	err = util.Copy(demoOrder, orderPO)
 	return demoOrder, err
 }
@@ -0,0 +1,116 @@
+package domainservice
+
+import (
+	"context"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/dal/cache"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type FavoriteDomainSvc struct {
+	favoriteDao  *dao.FavoriteDao
+	commodityDao *dao.CommodityDao
+	cache        *cache.Cache
+}
+
+func NewFavoriteDomainSvc(favoriteDao *dao.FavoriteDao, commodityDao *dao.CommodityDao, cache *cache.Cache) *FavoriteDomainSvc {
+	return &FavoriteDomainSvc{favoriteDao: favoriteDao, commodityDao: commodityDao, cache: cache}
+}
+
+// AddFavorite 收藏商品或店铺, 重复收藏不报错. 只能收藏上架中的商品和营业中的店铺
+func (f *FavoriteDomainSvc) AddFavorite(ctx context.Context, userID int64, targetType int8, targetID int64) error {
+	if err := f.checkTarget(ctx, targetType, targetID); err != nil {
+		return err
+	}
+
+	created, err := f.favoriteDao.AddFavorite(ctx, &do.Favorite{UserID: userID, TargetType: targetType, TargetID: targetID})
+	if err != nil {
+		return err
+	}
+	if created {
+		f.incrFavoriteCount(ctx, targetType, targetID, 1)
+	}
+
+	return nil
+}
+
+// RemoveFavorite 取消收藏, 没有收藏过也不报错
+func (f *FavoriteDomainSvc) RemoveFavorite(ctx context.Context, userID int64, targetType int8, targetID int64) error {
+	removed, err := f.favoriteDao.RemoveFavorite(ctx, userID, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if removed {
+		f.incrFavoriteCount(ctx, targetType, targetID, -1)
+	}
+
+	return nil
+}
+
+func (f *FavoriteDomainSvc) ListFavorites(ctx context.Context, userID int64, targetType int8, page *do.PageQuery) ([]*do.Favorite, int64, error) {
+	return f.favoriteDao.ListFavorites(ctx, userID, targetType, page)
+}
+
+// GetFavoriteCount 商品的收藏数, 缓存中没有时从数据库统计后写回
+func (f *FavoriteDomainSvc) GetFavoriteCount(ctx context.Context, commodityID int64) (int64, error) {
+	count, ok, err := f.cache.GetFavoriteCount(ctx, commodityID)
+	if err != nil {
+		return 0, errcode.Wrap("FavoriteDomainSvc GetFavoriteCount err", err)
+	}
+	if ok {
+		return count, nil
+	}
+
+	if count, err = f.favoriteDao.CountFavorites(ctx, enum.FavoriteTargetCommodity, commodityID); err != nil {
+		return 0, err
+	}
+	if err = f.cache.SetFavoriteCount(ctx, commodityID, count); err != nil {
+		logger.New(ctx).Error("set favorite count failed", "commodity_id", commodityID, "err", err)
+	}
+
+	return count, nil
+}
+
+func (f *FavoriteDomainSvc) checkTarget(ctx context.Context, targetType int8, targetID int64) error {
+	switch targetType {
+	case enum.FavoriteTargetCommodity:
+		commodities, err := f.commodityDao.FindCommoditiesByIDs(ctx, []int64{targetID})
+		if err != nil {
+			return errcode.Wrap("FavoriteDomainSvc checkTarget FindCommoditiesByIDs err", err)
+		}
+		commodity, ok := commodities[targetID]
+		if !ok {
+			return errcode.ErrFavoriteTargetNotFound
+		}
+		if commodity.IsPublished != enum.CommodityPublished {
+			return errcode.ErrCommodityUnpublished
+		}
+	case enum.FavoriteTargetStore:
+		stores, err := f.commodityDao.FindStoresByIDs(ctx, []int64{targetID})
+		if err != nil {
+			return errcode.Wrap("FavoriteDomainSvc checkTarget FindStoresByIDs err", err)
+		}
+		if store, ok := stores[targetID]; !ok || store.IsOpen != enum.StoreOpen {
+			return errcode.ErrFavoriteTargetNotFound
+		}
+	default:
+		return errcode.ErrParams
+	}
+
+	return nil
+}
+
+// incrFavoriteCount 只缓存商品的收藏数, 缓存更新失败等过期后自然修正
+func (f *FavoriteDomainSvc) incrFavoriteCount(ctx context.Context, targetType int8, targetID, delta int64) {
+	if targetType != enum.FavoriteTargetCommodity {
+		return
+	}
+
+	if err := f.cache.IncrFavoriteCount(ctx, targetID, delta); err != nil {
+		logger.New(ctx).Error("incr favorite count failed", "commodity_id", targetID, "err", err)
+	}
+}
@@ -0,0 +1,46 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+)
+
+func TestFavoriteCount(t *testing.T) {
+	db := newSqliteDB(t, &model.Favorite{}, &model.Commodity{}, &model.CommoditySku{})
+	svc := NewFavoriteDomainSvc(dao.NewFavoriteDao(db), dao.NewCommodityDao(db), newTestCache(t))
+	ctx := context.Background()
+
+	db.db.Create(&model.Commodity{ID: 1, IsPublished: enum.CommodityPublished})
+	db.db.Create(&model.Commodity{ID: 2, IsPublished: enum.CommodityUnpublished})
+
+	// 缓存收藏数后, 重复收藏和重复取消不会让计数偏差
+	if count, err := svc.GetFavoriteCount(ctx, 1); err != nil || count != 0 {
+		t.Fatalf("initial count = %d, err = %v", count, err)
+	}
+	for _, userID := range []int64{1, 1, 2} {
+		if err := svc.AddFavorite(ctx, userID, enum.FavoriteTargetCommodity, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 2 {
+		if err := svc.RemoveFavorite(ctx, 2, enum.FavoriteTargetCommodity, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count, err := svc.GetFavoriteCount(ctx, 1); err != nil || count != 1 {
+		t.Errorf("count = %d, err = %v, want 1", count, err)
+	}
+
+	if err := svc.AddFavorite(ctx, 1, enum.FavoriteTargetCommodity, 2); !errors.Is(err, errcode.ErrCommodityUnpublished) {
+		t.Errorf("favorite unpublished commodity err = %v, want ErrCommodityUnpublished", err)
+	}
+	if err := svc.AddFavorite(ctx, 1, enum.FavoriteTargetCommodity, 3); !errors.Is(err, errcode.ErrFavoriteTargetNotFound) {
+		t.Errorf("favorite missing commodity err = %v, want ErrFavoriteTargetNotFound", err)
+	}
+}
@@ -0,0 +1,355 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/metrics"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/cache"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type FlashSaleDomainSvc struct {
+	flashSaleDao *dao.FlashSaleDao
+	commodityDao *dao.CommodityDao
+	userQPS      atomic.Int64
+	cache        *cache.Cache
+}
+
+func NewFlashSaleDomainSvc(flashSaleDao *dao.FlashSaleDao, commodityDao *dao.CommodityDao, userQPS int, cache *cache.Cache) *FlashSaleDomainSvc {
+	f := &FlashSaleDomainSvc{flashSaleDao: flashSaleDao, commodityDao: commodityDao, cache: cache}
+	f.SetUserQPS(userQPS)
+	return f
+}
+
+// SetUserQPS 调整单个用户的限流阈值, 配置热更新时调用, <=0表示不限流
+func (f *FlashSaleDomainSvc) SetUserQPS(qps int) {
+	f.userQPS.Store(int64(qps))
+}
+
+// CreateFlashSale 管理后台创建秒杀活动, 秒杀价不能高于SKU原价
+func (f *FlashSaleDomainSvc) CreateFlashSale(ctx context.Context, flashSale *do.FlashSale) error {
+	if !flashSale.EndAt.After(flashSale.StartAt) {
+		return errcode.ErrParams.WithCause(errors.New("end_at must be after start_at"))
+	}
+
+	skus, err := f.commodityDao.FindSkusByIDs(ctx, []int64{flashSale.SkuID})
+	if err != nil {
+		return errcode.Wrap("FlashSaleDomainSvc CreateFlashSale FindSkusByIDs err", err)
+	}
+	if len(skus) == 0 || skus[0].Commodity == nil {
+		return errcode.ErrCommodityNotFound
+	}
+	if flashSale.Price > skus[0].Price {
+		return errcode.ErrParams.WithCause(errors.New("flash sale price higher than sku price"))
+	}
+
+	return f.flashSaleDao.CreateFlashSale(ctx, flashSale)
+}
+
+// WarmUp 预热秒杀活动: 从SKU库存中划出秒杀库存, 活动信息和库存写入Redis.
+// 可以重复执行, 已预热过的活动按已生成的订单数重新计算剩余库存, Redis中已有的库存不会被覆盖
+func (f *FlashSaleDomainSvc) WarmUp(ctx context.Context, flashSaleID int64) (*do.FlashSale, error) {
+	flashSale, err := f.flashSaleDao.FindFlashSaleByID(ctx, flashSaleID)
+	if err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc WarmUp FindFlashSaleByID err", err)
+	}
+	if flashSale == nil {
+		return nil, errcode.ErrFlashSaleNotFound
+	}
+	if !time.Now().Before(flashSale.EndAt) {
+		return nil, errcode.ErrFlashSaleEnded
+	}
+
+	if _, err = f.flashSaleDao.ReserveStock(ctx, flashSale); err != nil {
+		return nil, err
+	}
+	flashSale.Warmed = 1
+
+	ordered, err := f.flashSaleDao.CountOrders(ctx, flashSale.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.cache.SetFlashSale(ctx, flashSale); err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc WarmUp SetFlashSale err", err)
+	}
+	if err = f.cache.WarmFlashSaleStock(ctx, flashSale, max(flashSale.TotalStock-ordered, 0)); err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc WarmUp WarmFlashSaleStock err", err)
+	}
+
+	return flashSale, nil
+}
+
+// GetFlashSale 读取预热到Redis的活动信息, 抢购链路不查数据库
+func (f *FlashSaleDomainSvc) GetFlashSale(ctx context.Context, flashSaleID int64) (*do.FlashSale, error) {
+	flashSale, err := f.cache.GetFlashSale(ctx, flashSaleID)
+	if err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc GetFlashSale err", err)
+	}
+	if flashSale == nil {
+		return nil, errcode.ErrFlashSaleNotFound
+	}
+
+	return flashSale, nil
+}
+
+// IssueToken 活动开始后给用户下发一次性的抢购令牌
+func (f *FlashSaleDomainSvc) IssueToken(ctx context.Context, userID, flashSaleID int64) (string, error) {
+	if err := f.checkRate(ctx, userID); err != nil {
+		return "", err
+	}
+
+	flashSale, err := f.GetFlashSale(ctx, flashSaleID)
+	if err != nil {
+		return "", err
+	}
+	if err = flashSale.CheckActive(time.Now()); err != nil {
+		return "", err
+	}
+
+	token := util.RandomString(32)
+	if err = f.cache.SetFlashSaleToken(ctx, flashSaleID, userID, token); err != nil {
+		return "", errcode.Wrap("FlashSaleDomainSvc IssueToken err", err)
+	}
+
+	return token, nil
+}
+
+// Grab 凭令牌抢购, 抢到后进入下单队列, 订单由消费者异步创建, 客户端轮询抢购结果
+func (f *FlashSaleDomainSvc) Grab(ctx context.Context, userID, flashSaleID int64, token string) error {
+	if err := f.checkRate(ctx, userID); err != nil {
+		return err
+	}
+
+	flashSale, err := f.GetFlashSale(ctx, flashSaleID)
+	if err != nil {
+		return err
+	}
+	if err = flashSale.CheckActive(time.Now()); err != nil {
+		return err
+	}
+
+	valid, err := f.cache.ConsumeFlashSaleToken(ctx, flashSaleID, userID, token)
+	if err != nil {
+		return errcode.Wrap("FlashSaleDomainSvc Grab ConsumeFlashSaleToken err", err)
+	}
+	if !valid {
+		return errcode.ErrFlashSaleTokenInvalid
+	}
+
+	res, err := f.cache.GrabFlashSale(ctx, flashSale, &do.FlashSaleGrab{
+		FlashSaleID: flashSaleID,
+		UserID:      userID,
+		GrabbedAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		return errcode.Wrap("FlashSaleDomainSvc Grab err", err)
+	}
+
+	switch res {
+	case cache.FlashSaleGrabOK:
+		return nil
+	case cache.FlashSaleGrabSoldOut:
+		return errcode.ErrFlashSaleSoldOut
+	case cache.FlashSaleGrabDuplicate:
+		return errcode.ErrFlashSaleAlreadyGrabbed
+	default:
+		return errcode.ErrFlashSaleNotWarmed
+	}
+}
+
+func (f *FlashSaleDomainSvc) GetResult(ctx context.Context, userID, flashSaleID int64) (*do.FlashSaleResult, error) {
+	result, err := f.cache.GetFlashSaleResult(ctx, flashSaleID, userID)
+	if err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc GetResult err", err)
+	}
+	if result == nil {
+		return nil, errcode.ErrFlashSaleResultNotFound
+	}
+
+	return result, nil
+}
+
+// CreateOrder 消费下单队列中的抢购请求创建订单, 处理完确认请求. 同一抢购请求重复消费时返回已创建的订单;
+// 创建失败时退回Redis库存, 用户可以重新抢购. 确认失败时请求留在处理中列表, 超时后重新入队
+func (f *FlashSaleDomainSvc) CreateOrder(ctx context.Context, grab *do.FlashSaleGrab) error {
+	orderNo, err := f.createOrder(ctx, grab)
+	if err == nil {
+		result := &do.FlashSaleResult{Status: enum.FlashSaleResultSuccess, OrderNo: orderNo}
+		if ackErr := f.cache.CompleteFlashSaleGrab(ctx, grab, result); ackErr != nil {
+			return errcode.Wrap("FlashSaleDomainSvc CreateOrder CompleteFlashSaleGrab err", ackErr)
+		}
+		return nil
+	}
+
+	logger.New(ctx).Error("create flash sale order failed", "flash_sale_id", grab.FlashSaleID, "user_id", grab.UserID, "err", err)
+	// 并发处理同一请求时另一个消费者可能已经建好订单, 唯一索引冲突不能当作失败退库存
+	if orderNo, findErr := f.flashSaleDao.FindOrderNo(ctx, grab.FlashSaleID, grab.UserID); findErr != nil || orderNo != "" {
+		return err
+	}
+
+	result := &do.FlashSaleResult{Status: enum.FlashSaleResultFailed, FailCode: errcode.ErrFlashSaleOrderFailed.Code()}
+	if revertErr := f.cache.RevertFlashSaleGrab(ctx, grab, result); revertErr != nil {
+		return errcode.Wrap("FlashSaleDomainSvc CreateOrder RevertFlashSaleGrab err", revertErr)
+	}
+
+	return err
+}
+
+func (f *FlashSaleDomainSvc) createOrder(ctx context.Context, grab *do.FlashSaleGrab) (string, error) {
+	orderNo, err := f.flashSaleDao.FindOrderNo(ctx, grab.FlashSaleID, grab.UserID)
+	if err != nil || orderNo != "" {
+		return orderNo, err
+	}
+
+	flashSale, err := f.GetFlashSale(ctx, grab.FlashSaleID)
+	if err != nil {
+		return "", err
+	}
+
+	skus, err := f.commodityDao.FindSkusByIDs(ctx, []int64{flashSale.SkuID})
+	if err != nil {
+		return "", errcode.Wrap("FlashSaleDomainSvc createOrder FindSkusByIDs err", err)
+	}
+	if len(skus) == 0 || skus[0].Commodity == nil {
+		return "", errcode.ErrCommodityNotFound
+	}
+	sku := skus[0]
+
+	item := &do.OrderItem{
+		CommodityID:   sku.CommodityID,
+		SkuID:         sku.ID,
+		CommodityName: sku.Commodity.Name,
+		SkuName:       sku.Name,
+		CoverImg:      sku.Commodity.CoverImg,
+		Price:         flashSale.Price,
+		Quantity:      1,
+		Amount:        flashSale.Price,
+		PayAmount:     flashSale.Price,
+	}
+	order := &do.Order{
+		OrderNo:     util.GenSerialNo("O"),
+		UserID:      grab.UserID,
+		State:       enum.OrderStateCreated,
+		TotalAmount: item.Amount,
+		PayAmount:   item.PayAmount,
+		ExpireAt:    time.Now().Add(enum.OrderPayExpireDuration),
+		Items:       []*do.OrderItem{item},
+	}
+	if err = f.flashSaleDao.CreateOrder(ctx, grab.FlashSaleID, order); err != nil {
+		return "", err
+	}
+	metrics.OrdersCreated.WithLabelValues("flash_sale").Inc()
+
+	return order.OrderNo, nil
+}
+
+// checkRate 限制单个用户的请求频率, 拦截脚本刷单
+func (f *FlashSaleDomainSvc) checkRate(ctx context.Context, userID int64) error {
+	qps := int(f.userQPS.Load())
+	if qps <= 0 {
+		return nil
+	}
+
+	allowed, err := f.cache.AllowFlashSaleRequest(ctx, userID, qps)
+	if err != nil {
+		return errcode.Wrap("FlashSaleDomainSvc checkRate err", err)
+	}
+	if !allowed {
+		return errcode.ErrFlashSaleTooFrequent
+	}
+
+	return nil
+}
+
+// Settle 结算已结束的秒杀活动, 把没卖完的库存退回SKU. 还有抢购请求在排队或处理中时返回ErrFlashSaleSettleNotReady,
+// 稍后重试; 已结算过的活动直接返回
+func (f *FlashSaleDomainSvc) Settle(ctx context.Context, flashSaleID int64) (*do.FlashSale, error) {
+	flashSale, err := f.flashSaleDao.FindFlashSaleByID(ctx, flashSaleID)
+	if err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc Settle FindFlashSaleByID err", err)
+	}
+	if flashSale == nil {
+		return nil, errcode.ErrFlashSaleNotFound
+	}
+	if flashSale.Settled == 1 {
+		return flashSale, nil
+	}
+	if flashSale.Warmed == 0 {
+		return nil, errcode.ErrFlashSaleNotWarmed
+	}
+	if time.Now().Before(flashSale.EndAt) {
+		return nil, errcode.ErrFlashSaleSettleNotReady
+	}
+
+	// 抢到的请求先扣Redis库存再建订单或退回, 剩余库存+已建订单数=总库存说明没有处理中的请求.
+	// Redis库存已过期时活动早已结束, 只按订单数结算
+	stock, warmed, err := f.cache.GetFlashSaleStock(ctx, flashSaleID)
+	if err != nil {
+		return nil, errcode.Wrap("FlashSaleDomainSvc Settle GetFlashSaleStock err", err)
+	}
+	ordered, err := f.flashSaleDao.CountOrders(ctx, flashSaleID)
+	if err != nil {
+		return nil, err
+	}
+	if warmed && stock+ordered != flashSale.TotalStock {
+		return nil, errcode.ErrFlashSaleSettleNotReady
+	}
+
+	if _, err = f.flashSaleDao.Settle(ctx, flashSale, max(flashSale.TotalStock-ordered, 0)); err != nil {
+		return nil, err
+	}
+	flashSale.Settled = 1
+
+	return flashSale, nil
+}
+
+// RequeueStaleGrabs 定时把崩溃的消费者没处理完的抢购请求放回队列, 直到ctx取消
+func (f *FlashSaleDomainSvc) RequeueStaleGrabs(ctx context.Context) {
+	ticker := time.NewTicker(enum.FlashSaleRequeueAfter)
+	defer ticker.Stop()
+
+	for {
+		n, err := f.cache.RequeueStaleFlashSaleGrabs(ctx, enum.FlashSaleRequeueAfter)
+		if err != nil && ctx.Err() == nil {
+			logger.New(ctx).Error("requeue stale flash sale grabs failed", "err", err)
+		}
+		if n > 0 {
+			logger.New(ctx).Warn("requeued stale flash sale grabs", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ConsumeOrderQueue 循环消费下单队列直到ctx取消
+func (f *FlashSaleDomainSvc) ConsumeOrderQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		grab, err := f.cache.PopFlashSaleGrab(ctx, enum.FlashSaleQueuePopTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.New(ctx).Error("pop flash sale grab failed", "err", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if grab == nil {
+			continue
+		}
+
+		// 已出队的请求不受ctx取消影响, 保证处理完
+		f.CreateOrder(context.WithoutCancel(ctx), grab)
+	}
+}
@@ -0,0 +1,55 @@
+package domainservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/cache"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// BrowseHistoryDomainSvc 用户的最近浏览, 只存在Redis中
+type BrowseHistoryDomainSvc struct {
+	commodityDao *dao.CommodityDao
+	cache        *cache.Cache
+}
+
+func NewBrowseHistoryDomainSvc(commodityDao *dao.CommodityDao, cache *cache.Cache) *BrowseHistoryDomainSvc {
+	return &BrowseHistoryDomainSvc{commodityDao: commodityDao, cache: cache}
+}
+
+// RecordView 记录用户浏览了商品
+func (b *BrowseHistoryDomainSvc) RecordView(ctx context.Context, userID, commodityID int64) error {
+	commodities, err := b.commodityDao.FindCommoditiesByIDs(ctx, []int64{commodityID})
+	if err != nil {
+		return errcode.Wrap("BrowseHistoryDomainSvc RecordView FindCommoditiesByIDs err", err)
+	}
+	if _, ok := commodities[commodityID]; !ok {
+		return errcode.ErrCommodityNotFound
+	}
+
+	if err = b.cache.AddBrowseRecord(ctx, userID, commodityID, time.Now()); err != nil {
+		return errcode.Wrap("BrowseHistoryDomainSvc RecordView err", err)
+	}
+
+	return nil
+}
+
+func (b *BrowseHistoryDomainSvc) ListHistory(ctx context.Context, userID int64, offset, limit int) ([]*do.BrowseRecord, int64, error) {
+	records, total, err := b.cache.ListBrowseRecords(ctx, userID, offset, limit)
+	if err != nil {
+		return nil, 0, errcode.Wrap("BrowseHistoryDomainSvc ListHistory err", err)
+	}
+
+	return records, total, nil
+}
+
+func (b *BrowseHistoryDomainSvc) ClearHistory(ctx context.Context, userID int64) error {
+	if err := b.cache.ClearBrowseRecords(ctx, userID); err != nil {
+		return errcode.Wrap("BrowseHistoryDomainSvc ClearHistory err", err)
+	}
+
+	return nil
+}
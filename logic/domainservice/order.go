@@ -0,0 +1,193 @@
+package domainservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/metrics"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type OrderDomainSvc struct {
+	orderDao        *dao.OrderDao
+	commodityDao    *dao.CommodityDao
+	couponDomainSvc *CouponDomainSvc
+}
+
+func NewOrderDomainSvc(orderDao *dao.OrderDao, commodityDao *dao.CommodityDao, couponDomainSvc *CouponDomainSvc) *OrderDomainSvc {
+	return &OrderDomainSvc{orderDao: orderDao, commodityDao: commodityDao, couponDomainSvc: couponDomainSvc}
+}
+
+// Checkout 下单前试算价格. autoSelect为true时自动挑选最优的优惠券组合, 否则按couponIDs结算
+func (o *OrderDomainSvc) Checkout(ctx context.Context, userID int64, items []*do.OrderItem, couponIDs []int64, autoSelect bool) (*do.PriceResult, error) {
+	_, lines, err := o.buildOrder(ctx, userID, items)
+	if err != nil {
+		return nil, err
+	}
+
+	if autoSelect {
+		return o.couponDomainSvc.BestPrice(ctx, userID, lines)
+	}
+
+	return o.couponDomainSvc.CalculatePrice(ctx, userID, lines, couponIDs)
+}
+
+// CreateOrder 校验商品, 按SKU当前价格和所选优惠券计算金额后下单, items只需要带SkuID和Quantity
+func (o *OrderDomainSvc) CreateOrder(ctx context.Context, userID int64, items []*do.OrderItem, couponIDs []int64) (*do.Order, error) {
+	order, lines, err := o.buildOrder(ctx, userID, items)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := o.couponDomainSvc.CalculatePrice(ctx, userID, lines, couponIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, line := range price.Lines {
+		order.Items[i].DiscountAmount = line.DiscountAmount
+		order.Items[i].PayAmount = line.PayAmount
+	}
+	order.DiscountAmount = price.DiscountAmount
+	order.PayAmount = price.PayAmount
+	order.CouponIDs = price.CouponIDs()
+
+	if err = o.orderDao.CreateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	metrics.OrdersCreated.WithLabelValues("normal").Inc()
+
+	return order, nil
+}
+
+// buildOrder 合并SKU数量并校验商品状态和库存, 按SKU当前价格生成不含优惠的订单快照和对应的结算行
+func (o *OrderDomainSvc) buildOrder(ctx context.Context, userID int64, items []*do.OrderItem) (*do.Order, []*do.PriceLine, error) {
+	// 同一SKU合并数量
+	quantities := make(map[int64]int64, len(items))
+	skuIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		if _, ok := quantities[item.SkuID]; !ok {
+			skuIDs = append(skuIDs, item.SkuID)
+		}
+		quantities[item.SkuID] += item.Quantity
+	}
+
+	skus, err := o.commodityDao.FindSkusByIDs(ctx, skuIDs)
+	if err != nil {
+		return nil, nil, errcode.Wrap("OrderDomainSvc buildOrder FindSkusByIDs err", err)
+	}
+
+	if len(skus) != len(skuIDs) {
+		return nil, nil, errcode.ErrCommodityNotFound
+	}
+
+	order := &do.Order{
+		OrderNo:  util.GenSerialNo("O"),
+		UserID:   userID,
+		State:    enum.OrderStateCreated,
+		ExpireAt: time.Now().Add(enum.OrderPayExpireDuration),
+		Items:    make([]*do.OrderItem, 0, len(skus)),
+	}
+	lines := make([]*do.PriceLine, 0, len(skus))
+	for _, sku := range skus {
+		if sku.Commodity == nil || sku.Commodity.IsPublished != enum.CommodityPublished {
+			return nil, nil, errcode.ErrCommodityUnpublished
+		}
+
+		quantity := quantities[sku.ID]
+		if sku.Stock < quantity {
+			return nil, nil, errcode.ErrCommodityStockNotEnough
+		}
+
+		item := &do.OrderItem{
+			CommodityID:   sku.CommodityID,
+			SkuID:         sku.ID,
+			CommodityName: sku.Commodity.Name,
+			SkuName:       sku.Name,
+			CoverImg:      sku.Commodity.CoverImg,
+			Price:         sku.Price,
+			Quantity:      quantity,
+			Amount:        sku.Price * quantity,
+		}
+		item.PayAmount = item.Amount
+		order.Items = append(order.Items, item)
+		order.TotalAmount += item.Amount
+		lines = append(lines, &do.PriceLine{
+			SkuID:       sku.ID,
+			CommodityID: sku.CommodityID,
+			CategoryID:  sku.Commodity.CategoryID,
+			Price:       sku.Price,
+			Quantity:    quantity,
+		})
+	}
+	order.PayAmount = order.TotalAmount
+
+	return order, lines, nil
+}
+
+// GetUserOrder 查询用户自己的订单, 不属于该用户的订单视为不存在
+func (o *OrderDomainSvc) GetUserOrder(ctx context.Context, userID int64, orderNo string) (*do.Order, error) {
+	order, err := o.orderDao.FindOrderByNo(ctx, orderNo)
+	if err != nil {
+		return nil, errcode.Wrap("OrderDomainSvc GetUserOrder err", err)
+	}
+
+	if order == nil || order.UserID != userID {
+		return nil, errcode.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+// CloseExpiredOrders 定时关闭超时未支付的订单, 解锁优惠券并归还库存, 直到ctx取消
+func (o *OrderDomainSvc) CloseExpiredOrders(ctx context.Context) {
+	ticker := time.NewTicker(enum.OrderCloseInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := o.closeExpiredBatch(ctx)
+			if err != nil {
+				logger.New(ctx).Error("close expired orders failed", "err", err)
+				break
+			}
+			if n < enum.OrderCloseBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeExpiredBatch 关闭一批超时未支付的订单, 返回查到的订单数. 关闭前刚支付成功的订单跳过
+func (o *OrderDomainSvc) closeExpiredBatch(ctx context.Context) (int, error) {
+	orders, err := o.orderDao.FindExpiredOrders(ctx, time.Now(), enum.OrderCloseBatchSize)
+	if err != nil {
+		return 0, errcode.Wrap("OrderDomainSvc closeExpiredBatch FindExpiredOrders err", err)
+	}
+
+	for _, order := range orders {
+		if !order.CanTransitTo(enum.OrderStateClosed) {
+			continue
+		}
+		closed, err := o.orderDao.CloseUnpaidOrder(ctx, order)
+		if err != nil {
+			return 0, errcode.Wrap("OrderDomainSvc closeExpiredBatch CloseUnpaidOrder err", err)
+		}
+		if closed {
+			logger.New(ctx).Info("expired order closed", "order_no", order.OrderNo)
+		}
+	}
+
+	return len(orders), nil
+}
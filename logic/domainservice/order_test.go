@@ -0,0 +1,78 @@
+package domainservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+)
+
+func TestCloseExpiredOrders(t *testing.T) {
+	db := newSqliteDB(t, &model.Order{}, &model.OrderItem{}, &model.CommoditySku{}, &model.UserCoupon{}, &model.FlashSaleOrder{})
+	svc := NewOrderDomainSvc(dao.NewOrderDao(db), nil, nil)
+	ctx := context.Background()
+
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	db.db.Create(&model.CommoditySku{ID: 1, Stock: 5})
+	orders := []*model.Order{
+		{OrderNo: "expired", UserID: 1, State: enum.OrderStateCreated, ExpireAt: past, Items: []*model.OrderItem{{SkuID: 1, Quantity: 2}}},
+		{OrderNo: "flash", UserID: 1, State: enum.OrderStateCreated, ExpireAt: past, Items: []*model.OrderItem{{SkuID: 1, Quantity: 1}}},
+		{OrderNo: "paid", UserID: 1, State: enum.OrderStatePaid, ExpireAt: past, Items: []*model.OrderItem{{SkuID: 1, Quantity: 1}}},
+		{OrderNo: "pending", UserID: 1, State: enum.OrderStateCreated, ExpireAt: future, Items: []*model.OrderItem{{SkuID: 1, Quantity: 1}}},
+	}
+	for _, order := range orders {
+		if err := db.db.Create(order).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.db.Create(&model.FlashSaleOrder{FlashSaleID: 1, UserID: 1, OrderNo: "flash"})
+	db.db.Create(&model.UserCoupon{ID: 1, UserID: 1, State: enum.UserCouponStateLocked, OrderNo: "expired"})
+	db.db.Create(&model.UserCoupon{ID: 2, UserID: 1, State: enum.UserCouponStateLocked, OrderNo: "pending"})
+
+	n, err := svc.closeExpiredBatch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("closed %d orders, want 2", n)
+	}
+
+	states := make(map[string]int8)
+	var orderPOs []*model.Order
+	db.db.Find(&orderPOs)
+	for _, po := range orderPOs {
+		states[po.OrderNo] = po.State
+	}
+	if states["expired"] != enum.OrderStateClosed || states["flash"] != enum.OrderStateClosed ||
+		states["paid"] != enum.OrderStatePaid || states["pending"] != enum.OrderStateCreated {
+		t.Errorf("order states = %v", states)
+	}
+
+	// 普通订单归还库存, 秒杀订单的库存由活动结算归还
+	sku := new(model.CommoditySku)
+	db.db.First(sku, 1)
+	if sku.Stock != 7 {
+		t.Errorf("sku stock = %d, want 7", sku.Stock)
+	}
+
+	var coupons []*model.UserCoupon
+	db.db.Order("id").Find(&coupons)
+	if coupons[0].State != enum.UserCouponStateUnused || coupons[0].OrderNo != "" {
+		t.Errorf("coupon of closed order = %+v, want unlocked", coupons[0])
+	}
+	if coupons[1].State != enum.UserCouponStateLocked {
+		t.Errorf("coupon of pending order = %+v, want still locked", coupons[1])
+	}
+
+	// 再次执行不会重复归还库存
+	if _, err = svc.closeExpiredBatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	db.db.First(sku, 1)
+	if sku.Stock != 7 {
+		t.Errorf("sku stock after second run = %d, want 7", sku.Stock)
+	}
+}
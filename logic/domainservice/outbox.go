@@ -0,0 +1,183 @@
+package domainservice
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/tx"
+	"github.com/kackerx/go-mall/library/eventbus"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+const (
+	outboxMaxBackoff   = 5 * time.Minute
+	outboxPublishWait  = 5 * time.Second
+	outboxMaxErrLength = 500
+)
+
+// OutboxRelayOption 中继的轮询参数, 零值使用默认值
+type OutboxRelayOption struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	LeaseTimeout time.Duration // 认领一批事件的时长, 至少要比单个事件的投递超时长
+}
+
+// OutboxDomainSvc 事务性发件箱: 业务代码在事务里调用Add把事件和业务数据一起落库,
+// RunRelay在后台把落库的事件投递给Publisher, 投递失败按指数退避重试, 保证至少一次
+type OutboxDomainSvc struct {
+	outboxDao *dao.OutboxDao
+	tx        *tx.Manager
+	publisher eventbus.Publisher
+	option    OutboxRelayOption
+}
+
+func NewOutboxDomainSvc(outboxDao *dao.OutboxDao, txManager *tx.Manager, publisher eventbus.Publisher, option OutboxRelayOption) *OutboxDomainSvc {
+	if option.PollInterval <= 0 {
+		option.PollInterval = 500 * time.Millisecond
+	}
+	if option.BatchSize <= 0 {
+		option.BatchSize = 100
+	}
+	if option.MaxAttempts <= 0 {
+		option.MaxAttempts = 10
+	}
+	if option.LeaseTimeout <= 2*outboxPublishWait {
+		option.LeaseTimeout = time.Minute
+	}
+
+	return &OutboxDomainSvc{outboxDao: outboxDao, tx: txManager, publisher: publisher, option: option}
+}
+
+// Add 写入一条待发布事件, ctx需要带着业务数据所在的事务, 否则事件和业务数据不保证同时成功
+func (o *OutboxDomainSvc) Add(ctx context.Context, topic, key string, payload any) error {
+	if _, ok := tx.FromContext(ctx); !ok {
+		logger.New(ctx).Warn("outbox event added outside transaction", "topic", topic, "key", key)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errcode.Wrap("OutboxDomainSvc Add marshal err", err)
+	}
+
+	return o.outboxDao.CreateEvent(ctx, &do.OutboxEvent{
+		EventID:     uuid.NewString(),
+		Topic:       topic,
+		EventKey:    key,
+		Payload:     string(data),
+		Status:      enum.OutboxStatusPending,
+		NextRetryAt: time.Now(),
+	})
+}
+
+// RunRelay 循环投递到期的事件直到ctx取消, 一批取满时不等下一次轮询直接继续
+func (o *OutboxDomainSvc) RunRelay(ctx context.Context) {
+	ticker := time.NewTicker(o.option.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			n, err := o.relayBatch(ctx)
+			if err != nil {
+				logger.New(ctx).Error("outbox relay batch failed", "err", err)
+				break
+			}
+			if n < o.option.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// relayBatch 短事务认领一批到期事件, 事务外逐个投递, 再用一个短事务写回结果. 认领快到期时停止投递,
+// 剩下的事件释放认领, 避免和重新认领它们的中继同时投递
+func (o *OutboxDomainSvc) relayBatch(ctx context.Context) (int, error) {
+	owner := uuid.NewString()
+	claimedAt := time.Now()
+	events, err := o.outboxDao.ClaimDueEvents(ctx, owner, claimedAt, claimedAt.Add(o.option.LeaseTimeout), o.option.BatchSize)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	var published, failed []*do.OutboxEvent
+	var unsent []int64
+	deadline := claimedAt.Add(o.option.LeaseTimeout - outboxPublishWait)
+	for _, event := range events {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			unsent = append(unsent, event.ID)
+			continue
+		}
+		if err = o.publish(ctx, event); err != nil {
+			o.scheduleRetry(event, err, time.Now())
+			logger.New(ctx).Warn("outbox publish failed", "event_id", event.EventID, "topic", event.Topic,
+				"attempts", event.Attempts, "status", event.Status, "err", err)
+			failed = append(failed, event)
+			continue
+		}
+		published = append(published, event)
+	}
+
+	// 退出时ctx已取消, 已投递的结果也要写回去, 否则会在认领过期后重复投递
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	err = o.tx.Do(ctx, func(ctx context.Context) error {
+		for _, event := range published {
+			if err := o.outboxDao.MarkPublished(ctx, event.ID, owner, now); err != nil {
+				return err
+			}
+		}
+		for _, event := range failed {
+			if err := o.outboxDao.MarkFailed(ctx, event, owner, now); err != nil {
+				return err
+			}
+		}
+		return o.outboxDao.ReleaseLease(ctx, unsent, owner, now)
+	})
+
+	return len(events), err
+}
+
+func (o *OutboxDomainSvc) publish(ctx context.Context, event *do.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, outboxPublishWait)
+	defer cancel()
+
+	return o.publisher.Publish(ctx, &eventbus.Event{
+		ID:         event.EventID,
+		Topic:      event.Topic,
+		Key:        event.EventKey,
+		Payload:    []byte(event.Payload),
+		OccurredAt: event.CreatedAt,
+	})
+}
+
+// scheduleRetry 按1s, 2s, 4s...退避, 最长5分钟, 超过最大次数标记为dead
+func (o *OutboxDomainSvc) scheduleRetry(event *do.OutboxEvent, err error, now time.Time) {
+	event.Attempts++
+	event.LastError = err.Error()
+	if runes := []rune(event.LastError); len(runes) > outboxMaxErrLength {
+		event.LastError = string(runes[:outboxMaxErrLength])
+	}
+	if event.Attempts >= o.option.MaxAttempts {
+		event.Status = enum.OutboxStatusDead
+		return
+	}
+
+	backoff := outboxMaxBackoff
+	if event.Attempts < 20 {
+		backoff = min(time.Second<<(event.Attempts-1), outboxMaxBackoff)
+	}
+	event.NextRetryAt = now.Add(backoff)
+}
@@ -0,0 +1,81 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/dal/tx"
+	"github.com/kackerx/go-mall/library/eventbus"
+)
+
+// recordPublisher 记录投递的事件, topic为fail时返回错误
+type recordPublisher struct {
+	mu        sync.Mutex
+	published []string
+	inTx      bool
+}
+
+func (p *recordPublisher) Publish(ctx context.Context, event *eventbus.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := tx.FromContext(ctx); ok {
+		p.inTx = true
+	}
+	if event.Topic == "fail" {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event.Topic)
+	return nil
+}
+
+func TestOutboxRelayBatch(t *testing.T) {
+	db := newSqliteDB(t, &model.OutboxEvent{})
+	outboxDao := dao.NewOutboxDao(db)
+	publisher := new(recordPublisher)
+	svc := NewOutboxDomainSvc(outboxDao, tx.NewManager(db), publisher, OutboxRelayOption{})
+	ctx := context.Background()
+
+	for _, topic := range []string{"leased", "ok", "fail"} {
+		if err := svc.Add(ctx, topic, "k", map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 另一个中继已经认领了leased, 认领到期前不会被再次取走
+	now := time.Now()
+	if _, err := outboxDao.ClaimDueEvents(ctx, "other", now, now.Add(time.Minute), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := svc.relayBatch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(publisher.published) != 1 || publisher.published[0] != "ok" {
+		t.Fatalf("relayed %d, published %v, want ok only", n, publisher.published)
+	}
+	if publisher.inTx {
+		t.Error("publish should run outside transaction")
+	}
+
+	var events []*model.OutboxEvent
+	db.db.Order("id").Find(&events)
+	byTopic := make(map[string]*model.OutboxEvent, len(events))
+	for _, e := range events {
+		byTopic[e.Topic] = e
+	}
+	if e := byTopic["ok"]; e.Status != enum.OutboxStatusPublished {
+		t.Errorf("ok status = %d, want published", e.Status)
+	}
+	if e := byTopic["fail"]; e.Status != enum.OutboxStatusPending || e.Attempts != 1 || e.LeaseUntil.After(time.Now()) {
+		t.Errorf("fail event = %+v, want pending with 1 attempt and lease released", e)
+	}
+	if e := byTopic["leased"]; e.Status != enum.OutboxStatusPending || e.LeaseOwner != "other" {
+		t.Errorf("leased event = %+v, want still held by other", e)
+	}
+}
@@ -0,0 +1,380 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/metrics"
+	"github.com/kackerx/go-mall/common/util"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/tx"
+	paygw "github.com/kackerx/go-mall/library/payment"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+const paymentGatewayTimeout = 5 * time.Second
+
+type PaymentDomainSvc struct {
+	paymentDao    *dao.PaymentDao
+	orderDao      *dao.OrderDao
+	couponDao     *dao.CouponDao
+	outbox        *OutboxDomainSvc
+	tx            *tx.Manager
+	gateways      *paygw.Registry
+	notifyBaseURL string
+}
+
+func NewPaymentDomainSvc(
+	paymentDao *dao.PaymentDao,
+	orderDao *dao.OrderDao,
+	couponDao *dao.CouponDao,
+	outbox *OutboxDomainSvc,
+	txManager *tx.Manager,
+	gateways *paygw.Registry,
+	notifyBaseURL string,
+) *PaymentDomainSvc {
+	return &PaymentDomainSvc{
+		paymentDao:    paymentDao,
+		orderDao:      orderDao,
+		couponDao:     couponDao,
+		outbox:        outbox,
+		tx:            txManager,
+		gateways:      gateways,
+		notifyBaseURL: notifyBaseURL,
+	}
+}
+
+// CreatePayment 为订单发起一次支付, 同一幂等键重复请求返回同一个支付单.
+// 一个订单同时只允许有一笔待支付或已成功的支付单, 换了幂等键重复发起时返回同渠道的待支付单, 否则拒绝.
+// 上次向网关下单超时、还没有支付链接的待支付单, 重试时用原来的支付单号重新向网关下单
+func (p *PaymentDomainSvc) CreatePayment(ctx context.Context, userID int64, orderNo, channel, idempotencyKey string) (*do.Payment, error) {
+	if _, ok := p.gateways.Get(channel); !ok {
+		return nil, errcode.ErrPaymentChannel
+	}
+
+	existPayment, err := p.paymentDao.FindPaymentByIdempotencyKey(ctx, idempotencyKey)
+	if err != nil {
+		return nil, errcode.Wrap("PaymentDomainSvc CreatePayment FindPaymentByIdempotencyKey err", err)
+	}
+	if existPayment != nil {
+		if existPayment.OrderNo != orderNo || existPayment.UserID != userID {
+			return nil, errcode.ErrPaymentKeyConflict
+		}
+		if !needGatewayRetry(existPayment) {
+			return existPayment, nil
+		}
+	}
+
+	order, err := p.orderDao.FindOrderByNo(ctx, orderNo)
+	if err != nil {
+		return nil, errcode.Wrap("PaymentDomainSvc CreatePayment FindOrderByNo err", err)
+	}
+	if order == nil || order.UserID != userID {
+		return nil, errcode.ErrOrderNotFound
+	}
+	if !order.CanTransitTo(enum.OrderStatePaid) {
+		return nil, errcode.ErrOrderStateInvalid
+	}
+	if time.Now().After(order.ExpireAt) {
+		return nil, errcode.ErrOrderExpired
+	}
+	if existPayment != nil {
+		return p.requestGateway(ctx, order, existPayment)
+	}
+
+	payment := &do.Payment{
+		PaymentNo:      util.GenSerialNo("P"),
+		IdempotencyKey: idempotencyKey,
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		UserID:         userID,
+		Channel:        channel,
+		Amount:         order.PayAmount,
+		Status:         enum.PaymentStatusPending,
+	}
+	// 锁住订单行再检查, 同一订单并发用不同幂等键发起支付时只有一个能建出支付单, 避免重复扣款
+	var activePayment *do.Payment
+	err = p.tx.Do(ctx, func(ctx context.Context) error {
+		if err := p.orderDao.LockOrder(ctx, order.ID); err != nil {
+			return err
+		}
+		if activePayment, err = p.paymentDao.FindActivePaymentByOrderID(ctx, order.ID); err != nil || activePayment != nil {
+			return err
+		}
+		return p.paymentDao.CreatePayment(ctx, payment)
+	})
+	if err != nil {
+		// 并发请求使用同一幂等键时唯一索引冲突, 返回先写入的那一个
+		if existPayment, _ = p.paymentDao.FindPaymentByIdempotencyKey(ctx, idempotencyKey); existPayment != nil {
+			return existPayment, nil
+		}
+		return nil, errcode.Wrap("PaymentDomainSvc CreatePayment err", err)
+	}
+	if activePayment != nil {
+		if activePayment.Status != enum.PaymentStatusPending || activePayment.Channel != channel {
+			return nil, errcode.ErrPaymentInProgress
+		}
+		if needGatewayRetry(activePayment) {
+			return p.requestGateway(ctx, order, activePayment)
+		}
+		return activePayment, nil
+	}
+
+	return p.requestGateway(ctx, order, payment)
+}
+
+// needGatewayRetry 待支付但还没拿到支付链接, 说明上次向网关下单超时了
+func needGatewayRetry(payment *do.Payment) bool {
+	return payment.Status == enum.PaymentStatusPending && payment.PayURL == ""
+}
+
+// requestGateway 向网关下单并保存网关交易号和支付链接, 网关明确失败时把支付单标记为失败
+func (p *PaymentDomainSvc) requestGateway(ctx context.Context, order *do.Order, payment *do.Payment) (*do.Payment, error) {
+	gw, ok := p.gateways.Get(payment.Channel)
+	if !ok {
+		return nil, errcode.ErrPaymentChannel
+	}
+
+	gwCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+	resp, err := gw.CreatePayment(gwCtx, &paygw.CreatePaymentReq{
+		PaymentNo: payment.PaymentNo,
+		Amount:    payment.Amount,
+		Subject:   "go-mall订单" + order.OrderNo,
+		NotifyURL: p.notifyBaseURL + "/payment/notify/" + payment.Channel,
+		ExpireAt:  order.ExpireAt,
+	})
+	if errors.Is(err, paygw.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		// 网关可能已受理, 支付单保持待支付, 等回调、客户端主动查询或下次发起支付时重新下单
+		return nil, errcode.ErrPaymentGatewayTimeout.WithCause(err)
+	}
+	if err != nil {
+		payment.FailReason = err.Error()
+		if _, markErr := p.paymentDao.MarkPaymentFailed(ctx, payment); markErr != nil {
+			logger.New(ctx).Error("MarkPaymentFailed err", "err", markErr, "payment_no", payment.PaymentNo)
+		}
+		return nil, errcode.Wrap("PaymentDomainSvc requestGateway err", err)
+	}
+
+	payment.TradeNo = resp.TradeNo
+	payment.PayURL = resp.PayURL
+	if err = p.paymentDao.UpdateGatewayTrade(ctx, payment); err != nil {
+		return nil, errcode.Wrap("PaymentDomainSvc requestGateway UpdateGatewayTrade err", err)
+	}
+
+	return payment, nil
+}
+
+// QueryPayment 查询支付单, 待支付时主动向网关查询一次并同步结果, 用于回调丢失的补偿
+func (p *PaymentDomainSvc) QueryPayment(ctx context.Context, userID int64, paymentNo string) (*do.Payment, error) {
+	payment, err := p.paymentDao.FindPaymentByNo(ctx, paymentNo)
+	if err != nil {
+		return nil, errcode.Wrap("PaymentDomainSvc QueryPayment err", err)
+	}
+	if payment == nil || payment.UserID != userID {
+		return nil, errcode.ErrPaymentNotFound
+	}
+
+	if payment.Status != enum.PaymentStatusPending {
+		return payment, nil
+	}
+
+	gw, ok := p.gateways.Get(payment.Channel)
+	if !ok {
+		return nil, errcode.ErrPaymentChannel
+	}
+
+	gwCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+	resp, err := gw.QueryPayment(gwCtx, payment.PaymentNo)
+	if errors.Is(err, paygw.ErrTradeNotFound) {
+		return payment, nil
+	}
+	if err != nil {
+		return nil, errcode.Wrap("PaymentDomainSvc QueryPayment gateway err", err)
+	}
+
+	if err = p.applyTradeResult(ctx, payment, &paygw.Notification{
+		PaymentNo: payment.PaymentNo,
+		TradeNo:   resp.TradeNo,
+		Status:    resp.Status,
+		Amount:    resp.Amount,
+		PaidAt:    resp.PaidAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return p.paymentDao.FindPaymentByNo(ctx, paymentNo)
+}
+
+// HandleNotify 处理网关的异步回调, 验签后同步支付结果, 重复回调幂等返回成功
+func (p *PaymentDomainSvc) HandleNotify(ctx context.Context, channel string, header http.Header, body []byte) error {
+	gw, ok := p.gateways.Get(channel)
+	if !ok {
+		return errcode.ErrPaymentChannel
+	}
+
+	notification, err := gw.VerifyCallback(ctx, header, body)
+	if err != nil {
+		return errcode.ErrPaymentSignature.WithCause(err)
+	}
+
+	payment, err := p.paymentDao.FindPaymentByNo(ctx, notification.PaymentNo)
+	if err != nil {
+		return errcode.Wrap("PaymentDomainSvc HandleNotify FindPaymentByNo err", err)
+	}
+	if payment == nil || payment.Channel != channel {
+		return errcode.ErrPaymentNotFound
+	}
+
+	return p.applyTradeResult(ctx, payment, notification)
+}
+
+// applyTradeResult 把网关侧的支付结果落到支付单, 支付成功时通过订单状态机把订单流转为已支付
+func (p *PaymentDomainSvc) applyTradeResult(ctx context.Context, payment *do.Payment, result *paygw.Notification) error {
+	log := logger.New(ctx)
+	if payment.Status != enum.PaymentStatusPending {
+		log.Info("payment already handled", "payment_no", payment.PaymentNo, "status", payment.Status)
+		return nil
+	}
+
+	payment.TradeNo = result.TradeNo
+	metrics.PaymentsResult.WithLabelValues(payment.Channel, string(result.Status)).Inc()
+	switch result.Status {
+	case paygw.TradeStatusFailed:
+		payment.FailReason = "gateway trade failed"
+		if _, err := p.paymentDao.MarkPaymentFailed(ctx, payment); err != nil {
+			return errcode.Wrap("PaymentDomainSvc applyTradeResult MarkPaymentFailed err", err)
+		}
+		return nil
+	case paygw.TradeStatusSuccess:
+	default:
+		return nil
+	}
+
+	if result.Amount != payment.Amount {
+		log.Error("payment amount mismatch", "payment_no", payment.PaymentNo, "amount", payment.Amount, "paid", result.Amount)
+		return errcode.ErrPaymentAmountMismatch
+	}
+
+	order, err := p.orderDao.FindOrderByNo(ctx, payment.OrderNo)
+	if err != nil {
+		return errcode.Wrap("PaymentDomainSvc applyTradeResult FindOrderByNo err", err)
+	}
+	if order == nil {
+		return errcode.ErrOrderNotFound
+	}
+
+	payment.PaidAt = result.PaidAt
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = time.Now()
+	}
+
+	fromState := order.State
+	if err = order.TransitTo(enum.OrderStatePaid); err != nil {
+		// 订单已关闭或已被其他支付单支付, 支付单照常记为成功, 在事务里写退款事件原路退回
+		log.Warn("order cannot transit to paid", "err", err, "payment_no", payment.PaymentNo)
+		order = nil
+	} else {
+		order.PaidAt = payment.PaidAt
+	}
+
+	// 支付单置为成功、订单流转为已支付、核销订单锁定的优惠券、写入订单已支付事件在同一个事务中完成.
+	// 重复回调时支付单已不是待支付状态, 不再动订单; 订单已不在fromState时只更新支付单并写退款事件
+	err = p.tx.Do(ctx, func(ctx context.Context) error {
+		paymentUpdated, err := p.paymentDao.MarkPaymentSuccess(ctx, payment)
+		if err != nil {
+			return err
+		}
+		if !paymentUpdated {
+			return nil
+		}
+
+		orderUpdated := false
+		if order != nil {
+			if orderUpdated, err = p.orderDao.MarkOrderPaid(ctx, order, fromState); err != nil {
+				return err
+			}
+		}
+		if !orderUpdated {
+			tx.AfterCommit(ctx, func(ctx context.Context) {
+				logger.New(ctx).Warn("PaymentSuccessWithoutOrderPaid", "payment_no", payment.PaymentNo, "order_no", payment.OrderNo)
+			})
+			return p.outbox.Add(ctx, do.EventPaymentRefundRequired, payment.PaymentNo, &do.PaymentRefundRequiredEvent{
+				PaymentNo: payment.PaymentNo,
+				OrderNo:   payment.OrderNo,
+				Channel:   payment.Channel,
+				Amount:    payment.Amount,
+			})
+		}
+
+		if err = p.couponDao.UseLockedCoupons(ctx, order.OrderNo, order.PaidAt); err != nil {
+			return err
+		}
+
+		return p.outbox.Add(ctx, do.EventOrderPaid, order.OrderNo, &do.OrderPaidEvent{
+			OrderNo:   order.OrderNo,
+			UserID:    order.UserID,
+			PaymentNo: payment.PaymentNo,
+			Channel:   payment.Channel,
+			Amount:    payment.Amount,
+			PaidAt:    order.PaidAt,
+		})
+	})
+	if err != nil {
+		return errcode.Wrap("PaymentDomainSvc applyTradeResult MarkPaymentSuccess err", err)
+	}
+
+	payment.Status = enum.PaymentStatusSuccess
+	return nil
+}
+
+// RefundOrphanPayment 处理EventPaymentRefundRequired, 把支付成功但订单已无法支付的钱原路退回.
+// 退款单号由支付单号派生, 事件重复投递时网关按退款单号幂等, 支付单已退款时直接返回
+func (p *PaymentDomainSvc) RefundOrphanPayment(ctx context.Context, paymentNo string) error {
+	payment, err := p.paymentDao.FindPaymentByNo(ctx, paymentNo)
+	if err != nil {
+		return errcode.Wrap("PaymentDomainSvc RefundOrphanPayment FindPaymentByNo err", err)
+	}
+	if payment == nil {
+		return errcode.ErrPaymentNotFound
+	}
+	if payment.Status != enum.PaymentStatusSuccess {
+		return nil
+	}
+
+	gw, ok := p.gateways.Get(payment.Channel)
+	if !ok {
+		return errcode.ErrPaymentChannel
+	}
+
+	gwCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+	resp, err := gw.Refund(gwCtx, &paygw.RefundReq{
+		PaymentNo:    payment.PaymentNo,
+		RefundNo:     "R" + payment.PaymentNo,
+		RefundAmount: payment.Amount,
+		TotalAmount:  payment.Amount,
+		Reason:       "order not payable",
+	})
+	if err != nil {
+		return errcode.Wrap("PaymentDomainSvc RefundOrphanPayment gateway err", err)
+	}
+	if resp.Status != paygw.TradeStatusSuccess {
+		return errcode.Wrap("PaymentDomainSvc RefundOrphanPayment gateway err", fmt.Errorf("refund status %s", resp.Status))
+	}
+
+	payment.FailReason = "order not payable, refunded " + resp.RefundTradeNo
+	if _, err = p.paymentDao.MarkPaymentRefunded(ctx, payment); err != nil {
+		return errcode.Wrap("PaymentDomainSvc RefundOrphanPayment MarkPaymentRefunded err", err)
+	}
+	metrics.PaymentsResult.WithLabelValues(payment.Channel, "REFUNDED").Inc()
+
+	return nil
+}
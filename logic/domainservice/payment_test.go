@@ -0,0 +1,233 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+	"github.com/kackerx/go-mall/dal/tx"
+	"github.com/kackerx/go-mall/library/eventbus"
+	paygw "github.com/kackerx/go-mall/library/payment"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+// sqliteDB 用内存SQLite跑DAO, 事务中的调用取ctx上的事务连接
+type sqliteDB struct {
+	db *gorm.DB
+}
+
+func (s sqliteDB) Conn(ctx context.Context) *gorm.DB {
+	if db, ok := tx.FromContext(ctx); ok {
+		return db.WithContext(ctx)
+	}
+	return s.db.WithContext(ctx)
+}
+
+func (s sqliteDB) Master(ctx context.Context) *gorm.DB {
+	return s.Conn(ctx)
+}
+
+func newSqliteDB(t *testing.T, models ...any) sqliteDB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	// 内存库只有一个连接, 事务内外的语句串行执行
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return sqliteDB{db}
+}
+
+// fakeGateway 下单直接成功, timeout为true时下单超时; 记录退款请求
+type fakeGateway struct {
+	channel string
+	timeout bool
+
+	mu      sync.Mutex
+	refunds map[string]*paygw.RefundReq
+}
+
+func (g *fakeGateway) Channel() string { return g.channel }
+
+func (g *fakeGateway) CreatePayment(ctx context.Context, req *paygw.CreatePaymentReq) (*paygw.CreatePaymentResp, error) {
+	if g.timeout {
+		return nil, paygw.ErrTimeout
+	}
+	return &paygw.CreatePaymentResp{TradeNo: "T" + req.PaymentNo, PayURL: "fake://" + req.PaymentNo}, nil
+}
+
+func (g *fakeGateway) QueryPayment(ctx context.Context, paymentNo string) (*paygw.QueryPaymentResp, error) {
+	return nil, paygw.ErrTradeNotFound
+}
+
+func (g *fakeGateway) Refund(ctx context.Context, req *paygw.RefundReq) (*paygw.RefundResp, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.refunds == nil {
+		g.refunds = make(map[string]*paygw.RefundReq)
+	}
+	g.refunds[req.RefundNo] = req
+	return &paygw.RefundResp{RefundTradeNo: "RT" + req.RefundNo, Status: paygw.TradeStatusSuccess}, nil
+}
+
+func (g *fakeGateway) VerifyCallback(ctx context.Context, header http.Header, body []byte) (*paygw.Notification, error) {
+	return nil, paygw.ErrSignature
+}
+
+type paymentFixture struct {
+	svc      *PaymentDomainSvc
+	db       sqliteDB
+	gateways map[string]*fakeGateway
+}
+
+func newPaymentFixture(t *testing.T) *paymentFixture {
+	db := newSqliteDB(t, &model.Order{}, &model.OrderItem{}, &model.Payment{}, &model.UserCoupon{}, &model.OutboxEvent{})
+	txManager := tx.NewManager(db)
+	outbox := NewOutboxDomainSvc(dao.NewOutboxDao(db), txManager, eventbus.NewInProcessPublisher(), OutboxRelayOption{})
+
+	gateways := map[string]*fakeGateway{"mock": {channel: "mock"}, "other": {channel: "other"}}
+	svc := NewPaymentDomainSvc(dao.NewPaymentDao(db), dao.NewOrderDao(db), dao.NewCouponDao(db), outbox, txManager,
+		paygw.NewRegistry(gateways["mock"], gateways["other"]), "http://localhost")
+
+	return &paymentFixture{svc: svc, db: db, gateways: gateways}
+}
+
+func (f *paymentFixture) createOrder(t *testing.T, orderNo string, state int8) {
+	t.Helper()
+	f.createOrderExpireAt(t, orderNo, state, time.Now().Add(time.Hour))
+}
+
+func (f *paymentFixture) createOrderExpireAt(t *testing.T, orderNo string, state int8, expireAt time.Time) {
+	t.Helper()
+	err := f.db.db.Create(&model.Order{OrderNo: orderNo, UserID: 1, State: state, PayAmount: 100, ExpireAt: expireAt}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreatePaymentOnePerOrder(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+	f.createOrder(t, "O1", enum.OrderStateCreated)
+
+	first, err := f.svc.CreatePayment(ctx, 1, "O1", "mock", "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 换幂等键再次发起同渠道支付, 返回已有的待支付单
+	second, err := f.svc.CreatePayment(ctx, 1, "O1", "mock", "k2")
+	if err != nil || second.PaymentNo != first.PaymentNo {
+		t.Fatalf("second payment = %+v, err = %v, want %s", second, err, first.PaymentNo)
+	}
+
+	_, err = f.svc.CreatePayment(ctx, 1, "O1", "other", "k3")
+	if !errors.Is(err, errcode.ErrPaymentInProgress) {
+		t.Fatalf("other channel err = %v, want ErrPaymentInProgress", err)
+	}
+
+	var count int64
+	f.db.db.Model(&model.Payment{}).Where("order_no = ?", "O1").Count(&count)
+	if count != 1 {
+		t.Errorf("payments of order = %d, want 1", count)
+	}
+}
+
+// 网关下单超时后支付单没有支付链接, 再次发起支付时用原来的支付单号重新下单
+func TestCreatePaymentRetryAfterTimeout(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+	f.createOrder(t, "O3", enum.OrderStateCreated)
+
+	f.gateways["mock"].timeout = true
+	if _, err := f.svc.CreatePayment(ctx, 1, "O3", "mock", "k1"); !errors.Is(err, errcode.ErrPaymentGatewayTimeout) {
+		t.Fatalf("err = %v, want ErrPaymentGatewayTimeout", err)
+	}
+	f.gateways["mock"].timeout = false
+
+	for _, key := range []string{"k1", "k2"} {
+		payment, err := f.svc.CreatePayment(ctx, 1, "O3", "mock", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payment.PayURL != "fake://"+payment.PaymentNo || payment.IdempotencyKey != "k1" {
+			t.Errorf("retry with %s: payment = %+v, want the timed out payment with pay url", key, payment)
+		}
+	}
+
+	var payments []*model.Payment
+	f.db.db.Where("order_no = ?", "O3").Find(&payments)
+	if len(payments) != 1 || payments[0].PayURL == "" {
+		t.Errorf("payments of order = %+v, want 1 with pay url", payments)
+	}
+}
+
+func TestCreatePaymentOrderExpired(t *testing.T) {
+	f := newPaymentFixture(t)
+	f.createOrderExpireAt(t, "O4", enum.OrderStateCreated, time.Now().Add(-time.Minute))
+
+	if _, err := f.svc.CreatePayment(context.Background(), 1, "O4", "mock", "k1"); !errors.Is(err, errcode.ErrOrderExpired) {
+		t.Errorf("err = %v, want ErrOrderExpired", err)
+	}
+}
+
+func TestPaymentSuccessForClosedOrderRefunds(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+	f.createOrder(t, "O2", enum.OrderStateCreated)
+
+	payment, err := f.svc.CreatePayment(ctx, 1, "O2", "mock", "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 回调到达前订单已超时关闭
+	f.db.db.Model(&model.Order{}).Where("order_no = ?", "O2").Update("state", enum.OrderStateClosed)
+
+	err = f.svc.applyTradeResult(ctx, payment, &paygw.Notification{
+		PaymentNo: payment.PaymentNo,
+		TradeNo:   payment.TradeNo,
+		Status:    paygw.TradeStatusSuccess,
+		Amount:    payment.Amount,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := new(model.OutboxEvent)
+	if err = f.db.db.Where("topic = ?", do.EventPaymentRefundRequired).First(event).Error; err != nil {
+		t.Fatalf("refund event not written: %v", err)
+	}
+
+	// 事件重复投递, 只退一次且支付单记为已退款
+	for range 2 {
+		if err = f.svc.RefundOrphanPayment(ctx, event.EventKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if refunds := f.gateways["mock"].refunds; len(refunds) != 1 || refunds["R"+payment.PaymentNo].RefundAmount != payment.Amount {
+		t.Errorf("gateway refunds = %+v", refunds)
+	}
+	refunded, _ := f.svc.paymentDao.FindPaymentByNo(ctx, payment.PaymentNo)
+	if refunded.Status != enum.PaymentStatusRefunded {
+		t.Errorf("payment status = %d, want refunded", refunded.Status)
+	}
+}
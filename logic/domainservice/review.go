@@ -0,0 +1,217 @@
+package domainservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/dal/cache"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/logic/do"
+)
+
+type ReviewDomainSvc struct {
+	reviewDao *dao.ReviewDao
+	orderDao  *dao.OrderDao
+	userDao   *dao.UserDao
+	cache     *cache.Cache
+}
+
+func NewReviewDomainSvc(reviewDao *dao.ReviewDao, orderDao *dao.OrderDao, userDao *dao.UserDao, cache *cache.Cache) *ReviewDomainSvc {
+	return &ReviewDomainSvc{reviewDao: reviewDao, orderDao: orderDao, userDao: userDao, cache: cache}
+}
+
+// CreateReview 评价已完成订单中的一个明细, 每个明细只能评价一次, 评价需审核后才展示
+func (r *ReviewDomainSvc) CreateReview(ctx context.Context, userID, orderItemID int64, rating int8, content string, images []string) (*do.Review, error) {
+	order, err := r.orderDao.FindOrderByItemID(ctx, orderItemID)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc CreateReview FindOrderByItemID err", err)
+	}
+	if order == nil || order.UserID != userID {
+		return nil, errcode.ErrOrderNotFound
+	}
+	if order.State != enum.OrderStateCompleted {
+		return nil, errcode.ErrReviewNotAllowed
+	}
+
+	exist, err := r.reviewDao.FindReviewByOrderItem(ctx, orderItemID, 0)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc CreateReview FindReviewByOrderItem err", err)
+	}
+	if exist != nil {
+		return nil, errcode.ErrReviewDuplicate
+	}
+
+	review := &do.Review{
+		OrderItemID: orderItemID,
+		OrderID:     order.ID,
+		UserID:      userID,
+		Rating:      rating,
+		Content:     content,
+		Images:      images,
+		State:       enum.ReviewStatePending,
+	}
+	for _, item := range order.Items {
+		if item.ID == orderItemID {
+			review.CommodityID = item.CommodityID
+			review.SkuID = item.SkuID
+			review.SkuName = item.SkuName
+		}
+	}
+
+	if err = r.reviewDao.CreateReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// FollowUpReview 对自己的评价追评一次, 追评不带评分, 同样需要审核
+func (r *ReviewDomainSvc) FollowUpReview(ctx context.Context, userID, reviewID int64, content string, images []string) (*do.Review, error) {
+	parent, err := r.reviewDao.FindReviewByID(ctx, reviewID)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc FollowUpReview FindReviewByID err", err)
+	}
+	if parent == nil || parent.UserID != userID || parent.IsFollowUp() {
+		return nil, errcode.ErrReviewNotFound
+	}
+	if parent.State == enum.ReviewStateRejected {
+		return nil, errcode.ErrReviewStateInvalid
+	}
+
+	exist, err := r.reviewDao.FindReviewByOrderItem(ctx, parent.OrderItemID, parent.ID)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc FollowUpReview FindReviewByOrderItem err", err)
+	}
+	if exist != nil {
+		return nil, errcode.ErrReviewFollowUpLimit
+	}
+
+	followUp := &do.Review{
+		ParentID:    parent.ID,
+		OrderItemID: parent.OrderItemID,
+		OrderID:     parent.OrderID,
+		UserID:      userID,
+		CommodityID: parent.CommodityID,
+		SkuID:       parent.SkuID,
+		SkuName:     parent.SkuName,
+		Content:     content,
+		Images:      images,
+		State:       enum.ReviewStatePending,
+	}
+	if err = r.reviewDao.CreateReview(ctx, followUp); err != nil {
+		return nil, err
+	}
+
+	return followUp, nil
+}
+
+// ModerateReview 审核评价, approve为false时驳回或下架. 评价进出"审核通过"时增量更新商品的评分统计
+func (r *ReviewDomainSvc) ModerateReview(ctx context.Context, adminID, reviewID int64, approve bool, reason string) (*do.Review, error) {
+	review, err := r.reviewDao.FindReviewByID(ctx, reviewID)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc ModerateReview FindReviewByID err", err)
+	}
+	if review == nil {
+		return nil, errcode.ErrReviewNotFound
+	}
+
+	toState := int8(enum.ReviewStateRejected)
+	if approve {
+		toState = enum.ReviewStateApproved
+	}
+
+	countedBefore := review.CountsInStats()
+	fromState, err := review.TransitTo(toState)
+	if err != nil {
+		return nil, err
+	}
+	review.ModeratedBy = adminID
+	review.RejectReason = reason
+
+	updated, err := r.reviewDao.ModerateReview(ctx, review, fromState)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errcode.ErrReviewStateInvalid
+	}
+
+	var delta int64
+	switch countedAfter := review.CountsInStats(); {
+	case countedAfter && !countedBefore:
+		delta = 1
+	case !countedAfter && countedBefore:
+		delta = -1
+	}
+	if delta != 0 {
+		// 统计更新失败不影响审核结果, 只会让统计暂时偏差
+		if err = r.cache.IncrRatingStats(ctx, review.CommodityID, review.Rating, delta); err != nil {
+			logger.New(ctx).Error("incr rating stats failed", "review_id", review.ID, "err", err)
+		}
+	}
+
+	return review, nil
+}
+
+// ReplyReview 商家回复评价, 重复回复覆盖之前的内容
+func (r *ReviewDomainSvc) ReplyReview(ctx context.Context, reviewID int64, content string) (*do.Review, error) {
+	review, err := r.reviewDao.FindReviewByID(ctx, reviewID)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc ReplyReview FindReviewByID err", err)
+	}
+	if review == nil {
+		return nil, errcode.ErrReviewNotFound
+	}
+
+	review.MerchantReply = content
+	review.RepliedAt = time.Now()
+	if err = r.reviewDao.ReplyReview(ctx, review.ID, review.MerchantReply, review.RepliedAt); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (r *ReviewDomainSvc) ListApprovedReviews(ctx context.Context, commodityID int64, page *do.PageQuery) ([]*do.Review, int64, error) {
+	return r.reviewDao.ListApprovedReviews(ctx, commodityID, page)
+}
+
+func (r *ReviewDomainSvc) ListReviews(ctx context.Context, filter *do.ReviewListFilter, page *do.PageQuery) ([]*do.Review, int64, error) {
+	return r.reviewDao.ListReviews(ctx, filter, page)
+}
+
+// GetReviewers 评价的作者, key为用户ID
+func (r *ReviewDomainSvc) GetReviewers(ctx context.Context, reviews []*do.Review) (map[int64]*do.UserBaseInfo, error) {
+	userIDs := make([]int64, 0, len(reviews))
+	for _, review := range reviews {
+		userIDs = append(userIDs, review.UserID)
+	}
+	if len(userIDs) == 0 {
+		return map[int64]*do.UserBaseInfo{}, nil
+	}
+
+	return r.userDao.FindUsersByIDs(ctx, userIDs)
+}
+
+// GetRatingStats 商品评分统计, Redis中没有时从数据库全量统计后写回
+func (r *ReviewDomainSvc) GetRatingStats(ctx context.Context, commodityID int64) (*do.RatingStats, error) {
+	stats, err := r.cache.GetRatingStats(ctx, commodityID)
+	if err != nil {
+		return nil, errcode.Wrap("ReviewDomainSvc GetRatingStats err", err)
+	}
+	if stats != nil {
+		return stats, nil
+	}
+
+	if stats, err = r.reviewDao.AggregateRatingStats(ctx, commodityID); err != nil {
+		return nil, err
+	}
+	if err = r.cache.SetRatingStats(ctx, stats); err != nil {
+		logger.New(ctx).Error("set rating stats failed", "commodity_id", commodityID, "err", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,89 @@
+package domainservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kackerx/go-mall/common/enum"
+	"github.com/kackerx/go-mall/common/errcode"
+	"github.com/kackerx/go-mall/dal/cache"
+	"github.com/kackerx/go-mall/dal/dao"
+	"github.com/kackerx/go-mall/dal/model"
+)
+
+func newTestCache(t *testing.T) *cache.Cache {
+	t.Helper()
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return cache.New(rdb)
+}
+
+// 评价进出审核通过时增量更新评分统计, 追评和驳回待审核评价不影响统计, 结果和数据库全量统计一致
+func TestModerateReviewRatingStats(t *testing.T) {
+	db := newSqliteDB(t, &model.Review{})
+	reviewDao := dao.NewReviewDao(db)
+	svc := NewReviewDomainSvc(reviewDao, nil, nil, newTestCache(t))
+	ctx := context.Background()
+
+	reviews := []*model.Review{
+		{OrderItemID: 1, CommodityID: 1, Rating: 5},
+		{OrderItemID: 2, CommodityID: 1, Rating: 3},
+		{OrderItemID: 3, CommodityID: 1, Rating: 1},
+		{OrderItemID: 1, ParentID: 1, CommodityID: 1}, // 第一条评价的追评
+	}
+	for _, review := range reviews {
+		if err := db.db.Create(review).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 先把空统计写入Redis, 之后的审核走增量更新
+	if _, err := svc.GetRatingStats(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		reviewID int64
+		approve  bool
+		count    int64
+		sum      int64
+	}{
+		{1, true, 1, 5},
+		{2, true, 2, 8},
+		{4, true, 2, 8},  // 追评通过
+		{3, false, 2, 8}, // 驳回待审核的评价
+		{1, false, 1, 3}, // 下架已通过的评价
+	}
+	for _, step := range steps {
+		if _, err := svc.ModerateReview(ctx, 9, step.reviewID, step.approve, ""); err != nil {
+			t.Fatalf("moderate review %d: %v", step.reviewID, err)
+		}
+		stats, err := svc.GetRatingStats(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Count != step.count || stats.Sum != step.sum {
+			t.Errorf("after moderating review %d: count=%d sum=%d, want %d %d", step.reviewID, stats.Count, stats.Sum, step.count, step.sum)
+		}
+	}
+
+	stats, _ := svc.GetRatingStats(ctx, 1)
+	want, err := reviewDao.AggregateRatingStats(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != want.Count || stats.Sum != want.Sum || stats.Distribution[3] != want.Distribution[3] || stats.Distribution[5] != want.Distribution[5] {
+		t.Errorf("cached stats %+v, aggregated %+v", stats, want)
+	}
+
+	// 下架后的评价不能再次通过
+	if _, err = svc.ModerateReview(ctx, 9, 1, true, ""); !errors.Is(err, errcode.ErrReviewStateInvalid) {
+		t.Errorf("approve rejected review err = %v, want ErrReviewStateInvalid", err)
+	}
+	if review, _ := reviewDao.FindReviewByID(ctx, 1); review.State != enum.ReviewStateRejected {
+		t.Errorf("review state = %d, want rejected", review.State)
+	}
+}
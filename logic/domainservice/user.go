@@ -7,6 +7,7 @@ import (
 	"github.com/kackerx/go-mall/common/enum"
 	"github.com/kackerx/go-mall/common/errcode"
 	"github.com/kackerx/go-mall/common/logger"
+	"github.com/kackerx/go-mall/common/metrics"
 	"github.com/kackerx/go-mall/common/util"
 	"github.com/kackerx/go-mall/dal/cache"
 	"github.com/kackerx/go-mall/dal/dao"
@@ -15,10 +16,11 @@ import (
 
 type UserDomainSvc struct {
 	userDao *dao.UserDao
+	cache   *cache.Cache
 }
 
-func NewUserDomainSvc() *UserDomainSvc {
-	return &UserDomainSvc{}
+func NewUserDomainSvc(userDao *dao.UserDao, cache *cache.Cache) *UserDomainSvc {
+	return &UserDomainSvc{userDao: userDao, cache: cache}
 }
 
 func (us *UserDomainSvc) GetUserBaseInfo(userID int64) *do.UserBaseInfo {
@@ -62,18 +64,18 @@ func (us *UserDomainSvc) GenAuthToken(ctx context.Context, userID int64, platfor
 	userSession.RefreshToken = refreshToken
 
 	// 删除旧token
-	if err = cache.DelOldSessionTokens(ctx, userSession); err != nil {
+	if err = us.cache.DelOldSessionTokens(ctx, userSession); err != nil {
 		err = errcode.Wrap("DelOldSessionTokens err", err)
 		return
 	}
 
 	// 设置缓存
-	if err = cache.SetUserToken(ctx, userSession); err != nil {
+	if err = us.cache.SetUserToken(ctx, userSession); err != nil {
 		err = errcode.Wrap("设置token缓存错误", err)
 		return
 	}
 
-	if err = cache.SetUserSession(ctx, userSession); err != nil {
+	if err = us.cache.SetUserSession(ctx, userSession); err != nil {
 		err = errcode.Wrap("设置Session错误", err)
 		return
 	}
@@ -88,8 +90,8 @@ func (us *UserDomainSvc) GenAuthToken(ctx context.Context, userID int64, platfor
 
 func (us *UserDomainSvc) RefreshToken(ctx context.Context, refreshToken string) (resp *do.TokenInfo, err error) {
 	logger := logger.New(ctx)
-	ok, err := cache.LockTokenRefresh(ctx, refreshToken)
-	defer cache.UnlockTokenRefresh(ctx, refreshToken)
+	ok, err := us.cache.LockTokenRefresh(ctx, refreshToken)
+	defer us.cache.UnlockTokenRefresh(ctx, refreshToken)
 
 	if err != nil {
 		err = errcode.Wrap("设置refresh锁失败", err)
@@ -102,14 +104,14 @@ func (us *UserDomainSvc) RefreshToken(ctx context.Context, refreshToken string)
 	}
 
 	// 用旧refreshToken获取对应session, 如果是被窃取的refreshToken, 延迟过期也能获取到, 但是和最新的已经不同了
-	session, err := cache.GetRefreshToken(ctx, refreshToken)
+	session, err := us.cache.GetRefreshToken(ctx, refreshToken)
 	if err != nil || session == nil {
 		logger.Error("GetRefreshToken faild", "err", err)
 		err = errcode.ErrToken
 		return
 	}
 
-	platformSession, err := cache.GetUserPlatformSession(ctx, session.UserID, session.Platform)
+	platformSession, err := us.cache.GetUserPlatformSession(ctx, session.UserID, session.Platform)
 	if err != nil {
 		logger.Error("GetUserPlatformSession faild", "err", err)
 		err = errcode.ErrToken
@@ -148,10 +150,16 @@ func (us *UserDomainSvc) RegisterUser(ctx context.Context, user *do.UserBaseInfo
 		return 0, errcode.Wrap("UserDomainSvc RegisterUser BcryptPassword err", err)
 	}
 
-	return us.userDao.CreateUser(ctx, user, bcryptPassword)
+	userID, err = us.userDao.CreateUser(ctx, user, bcryptPassword)
+	metrics.UserRegisters.WithLabelValues(metrics.Result(err)).Inc()
+	return userID, err
 }
 
 func (us *UserDomainSvc) LoginUser(ctx context.Context, userName, password, platform string) (tokenInfo *do.TokenInfo, err error) {
+	defer func() {
+		metrics.UserLogins.WithLabelValues(platform, metrics.Result(err)).Inc()
+	}()
+
 	user, err := us.userDao.FindUserByUserName(ctx, userName)
 	if err != nil {
 		return nil, errcode.Wrap("UserDomainSvc LoginUser err", err)
@@ -165,24 +173,24 @@ func (us *UserDomainSvc) LoginUser(ctx context.Context, userName, password, plat
 		return nil, errcode.ErrUserNotRight
 	}
 
-	return us.GenAuthToken(ctx, int64(user.ID), platform, "")
+	return us.GenAuthToken(ctx, user.ID, platform, "")
 }
 
 func (us *UserDomainSvc) LoginoutUser(ctx context.Context, userID int64, platform string) (err error) {
-	session, err := cache.GetUserPlatformSession(ctx, userID, platform)
+	session, err := us.cache.GetUserPlatformSession(ctx, userID, platform)
 	if err != nil {
 		return errcode.Wrap("UserDomainSvc LoginoutUser GetUserPlatformSession err", err)
 	}
 
-	if err = cache.DelAccessToken(ctx, session.AccessToken); err != nil {
+	if err = us.cache.DelAccessToken(ctx, session.AccessToken); err != nil {
 		return errcode.Wrap("UserDomainSvc LoginoutUser DelAccessToken err", err)
 	}
 
-	if err = cache.DelRefreshToken(ctx, session.RefreshToken); err != nil {
+	if err = us.cache.DelRefreshToken(ctx, session.RefreshToken); err != nil {
 		return errcode.Wrap("UserDomainSvc LoginoutUser DelRefreshToken err", err)
 	}
 
-	if err = cache.DelUserSessionOnPlatform(ctx, userID, platform); err != nil {
+	if err = us.cache.DelUserSessionOnPlatform(ctx, userID, platform); err != nil {
 		return errcode.Wrap("UserDomainSvc LoginoutUser DelUserSessionOnPlatform err", err)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"embed"
 	"io"
+	"io/fs"
 )
 
 //go:embed *
@@ -17,3 +18,14 @@ func LoadResourceFile(path string) (io.Reader, error) {
 
 	return bytes.NewReader(bs), nil
 }
+
+// MigrationFS 版本化迁移的SQL文件, 文件名格式为<版本号>_<名称>.up.sql / .down.sql
+func MigrationFS() fs.FS {
+	sub, err := fs.Sub(f, "migrations")
+	if err != nil {
+		// 目录随代码一起嵌入, 只有路径写错时才会走到这里
+		panic(err)
+	}
+
+	return sub
+}
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS `outbox_events`;
+DROP TABLE IF EXISTS `favorites`;
+DROP TABLE IF EXISTS `reviews`;
+DROP TABLE IF EXISTS `flash_sale_orders`;
+DROP TABLE IF EXISTS `flash_sales`;
+DROP TABLE IF EXISTS `user_coupons`;
+DROP TABLE IF EXISTS `coupon_templates`;
+DROP TABLE IF EXISTS `after_sale_logs`;
+DROP TABLE IF EXISTS `after_sale_items`;
+DROP TABLE IF EXISTS `after_sales`;
+DROP TABLE IF EXISTS `payments`;
+DROP TABLE IF EXISTS `order_items`;
+DROP TABLE IF EXISTS `orders`;
+DROP TABLE IF EXISTS `stores`;
+DROP TABLE IF EXISTS `commodity_categories`;
+DROP TABLE IF EXISTS `commodity_skus`;
+DROP TABLE IF EXISTS `commodities`;
+DROP TABLE IF EXISTS `demo_orders`;
+DROP TABLE IF EXISTS `users`;
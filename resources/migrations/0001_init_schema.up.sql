@@ -0,0 +1,326 @@
+-- 初始表结构, 与引入版本化迁移前AutoMigrate建出的表一致, 另外补上了users表.
+-- 用IF NOT EXISTS, 之前用AutoMigrate建过表的库执行up只会记录版本号
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint AUTO_INCREMENT,
+  `nickname` varchar(64) NOT NULL DEFAULT '',
+  `user_name` varchar(64) NOT NULL DEFAULT '',
+  `password` varchar(255) NOT NULL DEFAULT '',
+  `verified` tinyint NOT NULL DEFAULT 0,
+  `avatar` varchar(255) NOT NULL DEFAULT '',
+  `slogan` varchar(255) NOT NULL DEFAULT '',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `is_blocked` tinyint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_user_name` (`user_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `demo_orders` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `amout` varchar(191) NOT NULL DEFAULT '',
+  `code` varchar(191) NOT NULL DEFAULT '',
+  `state` tinyint NOT NULL DEFAULT 0,
+  `paid_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `commodities` (
+  `id` bigint AUTO_INCREMENT,
+  `store_id` bigint NOT NULL DEFAULT 0,
+  `category_id` bigint NOT NULL DEFAULT 0,
+  `name` varchar(191) NOT NULL DEFAULT '',
+  `intro` varchar(191) NOT NULL DEFAULT '',
+  `cover_img` varchar(191) NOT NULL DEFAULT '',
+  `is_published` tinyint NOT NULL DEFAULT 0,
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_commodities_store_id` (`store_id`),
+  INDEX `idx_commodities_category_id` (`category_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `commodity_skus` (
+  `id` bigint AUTO_INCREMENT,
+  `commodity_id` bigint NOT NULL DEFAULT 0,
+  `name` varchar(191) NOT NULL DEFAULT '',
+  `price` bigint NOT NULL DEFAULT 0,
+  `stock` bigint NOT NULL DEFAULT 0,
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_commodity_skus_commodity_id` (`commodity_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `commodity_categories` (
+  `id` bigint AUTO_INCREMENT,
+  `level` bigint NOT NULL DEFAULT 0,
+  `parent_id` bigint NOT NULL DEFAULT 0,
+  `name` varchar(191) NOT NULL DEFAULT '',
+  `icon_img` varchar(191) NOT NULL DEFAULT '',
+  `rank` bigint NOT NULL DEFAULT 0,
+  `created_by` varchar(191) NOT NULL DEFAULT '',
+  `updated_by` varchar(191) NOT NULL DEFAULT '',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_commodity_categories_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `stores` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(191) NOT NULL DEFAULT '',
+  `logo` varchar(191) NOT NULL DEFAULT '',
+  `intro` varchar(191) NOT NULL DEFAULT '',
+  `is_open` tinyint NOT NULL DEFAULT 0,
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `orders` (
+  `id` bigint AUTO_INCREMENT,
+  `order_no` varchar(191) NOT NULL DEFAULT '',
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `state` tinyint NOT NULL DEFAULT 0,
+  `total_amount` bigint NOT NULL DEFAULT 0,
+  `discount_amount` bigint NOT NULL DEFAULT 0,
+  `pay_amount` bigint NOT NULL DEFAULT 0,
+  `paid_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `expire_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_orders_order_no` (`order_no`),
+  INDEX `idx_orders_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `order_items` (
+  `id` bigint AUTO_INCREMENT,
+  `order_id` bigint NOT NULL DEFAULT 0,
+  `commodity_id` bigint NOT NULL DEFAULT 0,
+  `sku_id` bigint NOT NULL DEFAULT 0,
+  `commodity_name` varchar(191) NOT NULL DEFAULT '',
+  `sku_name` varchar(191) NOT NULL DEFAULT '',
+  `cover_img` varchar(191) NOT NULL DEFAULT '',
+  `price` bigint NOT NULL DEFAULT 0,
+  `quantity` bigint NOT NULL DEFAULT 0,
+  `amount` bigint NOT NULL DEFAULT 0,
+  `discount_amount` bigint NOT NULL DEFAULT 0,
+  `pay_amount` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_order_items_order_id` (`order_id`),
+  INDEX `idx_order_items_sku_id` (`sku_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `payments` (
+  `id` bigint AUTO_INCREMENT,
+  `payment_no` varchar(191) NOT NULL DEFAULT '',
+  `idempotency_key` varchar(191) NOT NULL DEFAULT '',
+  `order_id` bigint NOT NULL DEFAULT 0,
+  `order_no` varchar(191) NOT NULL DEFAULT '',
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `channel` varchar(191) NOT NULL DEFAULT '',
+  `amount` bigint NOT NULL DEFAULT 0,
+  `status` tinyint NOT NULL DEFAULT 0,
+  `trade_no` varchar(191) NOT NULL DEFAULT '',
+  `pay_url` varchar(191) NOT NULL DEFAULT '',
+  `fail_reason` varchar(191) NOT NULL DEFAULT '',
+  `paid_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_payments_order_id` (`order_id`),
+  UNIQUE INDEX `idx_payments_payment_no` (`payment_no`),
+  UNIQUE INDEX `idx_payments_idempotency_key` (`idempotency_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `after_sales` (
+  `id` bigint AUTO_INCREMENT,
+  `ticket_no` varchar(191) NOT NULL DEFAULT '',
+  `order_id` bigint NOT NULL DEFAULT 0,
+  `order_no` varchar(191) NOT NULL DEFAULT '',
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `type` tinyint NOT NULL DEFAULT 0,
+  `state` tinyint NOT NULL DEFAULT 0,
+  `reason` varchar(191) NOT NULL DEFAULT '',
+  `refund_amount` bigint NOT NULL DEFAULT 0,
+  `payment_no` varchar(191) NOT NULL DEFAULT '',
+  `refund_trade_no` varchar(191) NOT NULL DEFAULT '',
+  `audit_remark` varchar(191) NOT NULL DEFAULT '',
+  `audited_by` bigint NOT NULL DEFAULT 0,
+  `audited_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `refunded_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_after_sales_state` (`state`),
+  UNIQUE INDEX `idx_after_sales_ticket_no` (`ticket_no`),
+  INDEX `idx_after_sales_order_id` (`order_id`),
+  INDEX `idx_after_sales_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `after_sale_items` (
+  `id` bigint AUTO_INCREMENT,
+  `after_sale_id` bigint NOT NULL DEFAULT 0,
+  `order_item_id` bigint NOT NULL DEFAULT 0,
+  `sku_id` bigint NOT NULL DEFAULT 0,
+  `quantity` bigint NOT NULL DEFAULT 0,
+  `refund_amount` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_after_sale_items_after_sale_id` (`after_sale_id`),
+  INDEX `idx_after_sale_items_order_item_id` (`order_item_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `after_sale_logs` (
+  `id` bigint AUTO_INCREMENT,
+  `after_sale_id` bigint NOT NULL DEFAULT 0,
+  `operator_type` varchar(191) NOT NULL DEFAULT '',
+  `operator_id` bigint NOT NULL DEFAULT 0,
+  `action` varchar(191) NOT NULL DEFAULT '',
+  `from_state` tinyint NOT NULL DEFAULT 0,
+  `to_state` tinyint NOT NULL DEFAULT 0,
+  `remark` varchar(191) NOT NULL DEFAULT '',
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_after_sale_logs_after_sale_id` (`after_sale_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `coupon_templates` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(191) NOT NULL DEFAULT '',
+  `type` tinyint NOT NULL DEFAULT 0,
+  `threshold` bigint NOT NULL DEFAULT 0,
+  `discount_amount` bigint NOT NULL DEFAULT 0,
+  `discount_rate` bigint NOT NULL DEFAULT 0,
+  `max_discount` bigint NOT NULL DEFAULT 0,
+  `scope_type` tinyint NOT NULL DEFAULT 0,
+  `scope_ids` varchar(1024) NOT NULL DEFAULT '',
+  `user_segment` varchar(191) NOT NULL DEFAULT '',
+  `stackable` tinyint NOT NULL DEFAULT 0,
+  `total_stock` bigint NOT NULL DEFAULT 0,
+  `per_user_limit` bigint NOT NULL DEFAULT 1,
+  `valid_days` bigint NOT NULL DEFAULT 0,
+  `claim_start_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `claim_end_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `created_by` bigint NOT NULL DEFAULT 0,
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `user_coupons` (
+  `id` bigint AUTO_INCREMENT,
+  `template_id` bigint NOT NULL DEFAULT 0,
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `state` tinyint NOT NULL DEFAULT 0,
+  `valid_from` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `valid_to` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `order_no` varchar(191) NOT NULL DEFAULT '',
+  `used_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_coupons_user_id` (`user_id`),
+  INDEX `idx_user_coupons_order_no` (`order_no`),
+  INDEX `idx_user_coupons_template_id` (`template_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `flash_sales` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(191) NOT NULL DEFAULT '',
+  `sku_id` bigint NOT NULL DEFAULT 0,
+  `price` bigint NOT NULL DEFAULT 0,
+  `total_stock` bigint NOT NULL DEFAULT 0,
+  `start_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `end_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `warmed` tinyint NOT NULL DEFAULT 0,
+  `created_by` bigint NOT NULL DEFAULT 0,
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_flash_sales_sku_id` (`sku_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `flash_sale_orders` (
+  `id` bigint AUTO_INCREMENT,
+  `flash_sale_id` bigint NOT NULL DEFAULT 0,
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `order_no` varchar(191) NOT NULL DEFAULT '',
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_flash_sale_user` (`flash_sale_id`,`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `reviews` (
+  `id` bigint AUTO_INCREMENT,
+  `parent_id` bigint NOT NULL DEFAULT 0,
+  `order_item_id` bigint NOT NULL DEFAULT 0,
+  `order_id` bigint NOT NULL DEFAULT 0,
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `commodity_id` bigint NOT NULL DEFAULT 0,
+  `sku_id` bigint NOT NULL DEFAULT 0,
+  `sku_name` varchar(191) NOT NULL DEFAULT '',
+  `rating` tinyint NOT NULL DEFAULT 0,
+  `content` varchar(1000) NOT NULL DEFAULT '',
+  `images` varchar(2048) NOT NULL DEFAULT '',
+  `state` tinyint NOT NULL DEFAULT 0,
+  `reject_reason` varchar(191) NOT NULL DEFAULT '',
+  `moderated_by` bigint NOT NULL DEFAULT 0,
+  `merchant_reply` varchar(1000) NOT NULL DEFAULT '',
+  `replied_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_order_item_parent` (`parent_id`,`order_item_id`),
+  INDEX `idx_reviews_user_id` (`user_id`),
+  INDEX `idx_reviews_commodity_id` (`commodity_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `favorites` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `target_type` tinyint NOT NULL DEFAULT 0,
+  `target_id` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uk_user_target` (`user_id`,`target_type`,`target_id`),
+  INDEX `idx_target` (`target_type`,`target_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id` bigint AUTO_INCREMENT,
+  `event_id` varchar(191) NOT NULL DEFAULT '',
+  `topic` varchar(191) NOT NULL DEFAULT '',
+  `event_key` varchar(191) NOT NULL DEFAULT '',
+  `payload` text,
+  `status` tinyint NOT NULL DEFAULT 0,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `next_retry_at` datetime(3) NULL,
+  `last_error` varchar(512) NOT NULL DEFAULT '',
+  `published_at` datetime(3) NULL DEFAULT '1970-01-01 00:00:00',
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_outbox_events_event_id` (`event_id`),
+  INDEX `idx_status_retry` (`status`,`next_retry_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,18 @@
+-- 用户收货地址
+
+CREATE TABLE IF NOT EXISTS `user_addresses` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL DEFAULT 0,
+  `user_name` varchar(191) NOT NULL DEFAULT '',
+  `user_phone` varchar(32) NOT NULL DEFAULT '',
+  `province` varchar(64) NOT NULL DEFAULT '',
+  `city` varchar(64) NOT NULL DEFAULT '',
+  `district` varchar(64) NOT NULL DEFAULT '',
+  `detail` varchar(255) NOT NULL DEFAULT '',
+  `is_user_default` tinyint NOT NULL DEFAULT 0,
+  `is_del` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_addresses_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE `flash_sales` DROP COLUMN `settled`;
@@ -0,0 +1,3 @@
+-- 秒杀活动结束后把没卖完的库存退回SKU, settled标记已结算过
+
+ALTER TABLE `flash_sales` ADD COLUMN `settled` tinyint NOT NULL DEFAULT 0 AFTER `warmed`;
@@ -0,0 +1 @@
+ALTER TABLE `outbox_events` DROP COLUMN `lease_owner`, DROP COLUMN `lease_until`;
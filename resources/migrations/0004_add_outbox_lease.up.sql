@@ -0,0 +1,5 @@
+-- outbox中继先短事务认领一批事件, 事务外投递, 再短事务写结果; 认领过期后其他中继可以重新认领
+
+ALTER TABLE `outbox_events`
+  ADD COLUMN `lease_owner` varchar(64) NOT NULL DEFAULT '' AFTER `last_error`,
+  ADD COLUMN `lease_until` datetime(3) NULL DEFAULT '1970-01-01 00:00:00' AFTER `lease_owner`;
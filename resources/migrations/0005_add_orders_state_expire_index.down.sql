@@ -0,0 +1 @@
+ALTER TABLE `orders` DROP INDEX `idx_state_expire`;
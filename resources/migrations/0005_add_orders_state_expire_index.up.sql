@@ -0,0 +1,3 @@
+-- 定时关闭超时未支付的订单, 按状态和支付截止时间扫描
+
+ALTER TABLE `orders` ADD INDEX `idx_state_expire` (`state`, `expire_at`);
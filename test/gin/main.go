@@ -61,7 +61,7 @@ func main() {
 
 	e := gin.Default()
 	// e.Use(otelgin.Middleware("dola"))
-	e.Use(middleware.TracingMiddleware())
+	e.Use(middleware.StartTrace())
 
 	e.GET("/hehe", func(c *gin.Context) {
 		ctx := c.Request.Context()